@@ -4,11 +4,15 @@ go 1.18
 
 require (
 	github.com/go-ping/ping v1.1.0
+	github.com/google/gopacket v1.1.19
 	github.com/google/nftables v0.0.0-20220611213346-a346d51f53b3
 	github.com/vishvananda/netlink v1.2.1-beta.2
 	github.com/vishvananda/netns v0.0.0-20211101163701-50045581ed74
+	go.uber.org/multierr v1.7.0
 	go.uber.org/zap v1.21.0
+	golang.org/x/net v0.0.0-20220114011407-0dd24b26b47d
 	golang.org/x/sys v0.0.0-20220627191245-f75cf1eec38b
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 	kernel.org/pub/linux/libs/security/libcap/cap v1.2.64
 )
 
@@ -20,9 +24,7 @@ require (
 	github.com/mdlayher/netlink v1.5.0 // indirect
 	github.com/mdlayher/socket v0.1.1 // indirect
 	go.uber.org/atomic v1.9.0 // indirect
-	go.uber.org/multierr v1.7.0 // indirect
 	golang.org/x/mod v0.5.1 // indirect
-	golang.org/x/net v0.0.0-20220114011407-0dd24b26b47d // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
 	golang.org/x/tools v0.1.8 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
@@ -1,17 +1,24 @@
 package gont
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	nft "github.com/google/nftables"
 	"github.com/stv0g/gont/internal/utils"
 	nl "github.com/vishvananda/netlink"
 	"github.com/vishvananda/netns"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/sys/unix"
 )
 
@@ -32,15 +39,44 @@ type BaseNode struct {
 	ExistingNamespace       string
 	ExistingDockerContainer string
 	LogToDebug              bool
+	LogToDebugSet           bool // LogToDebug has been set explicitly
+	LogLevel                zapcore.Level
+	TimeOffset              time.Duration
+	Hostname                string
+	SeccompProfile          *SeccompProfile
+	CgroupParent            string
+	NSSwitchHosts           []string
+
+	meta map[string]string
+
+	multicastRouters map[int]*multicastRouter
+	qdiscMonitors    []*QdiscMonitor
+	latencySpikes    []*LatencySpikes
+	fileServers      []*FileServer
+	teams            []*team
+	gates            []*Gate
+	memberships      []*MulticastMembership
+
+	adoptedInterfaces []*Interface
+
+	initCmd *exec.Cmd
 
 	logger *zap.Logger
 }
 
 func (n *Network) AddNode(name string, opts ...Option) (*BaseNode, error) {
+	if err := checkOptions(opts, targetNode); err != nil {
+		return nil, err
+	}
+
+	return n.addNode(name, opts)
+}
+
+func (n *Network) addNode(name string, opts []Option) (*BaseNode, error) {
 	var err error
 
 	basePath := filepath.Join(n.BasePath, "nodes", name)
-	for _, path := range []string{"ns"} {
+	for _, path := range []string{"ns", "files"} {
 		path = filepath.Join(basePath, path)
 		if err := os.MkdirAll(path, 0755); err != nil {
 			return nil, err
@@ -51,20 +87,46 @@ func (n *Network) AddNode(name string, opts ...Option) (*BaseNode, error) {
 		name:     name,
 		network:  n,
 		BasePath: basePath,
-		logger:   zap.L().Named("node").With(zap.String("node", name)),
+		LogLevel: zap.DebugLevel,
+		logger:   n.Logger.Named("node").With(zap.String("node", name)),
 	}
 
-	// Enable log if level is debug
-	node.LogToDebug = node.logger.Core().Enabled(zap.DebugLevel)
-
-	node.logger.Info("Adding new node")
-
 	for _, opt := range opts {
 		if nopt, ok := opt.(NodeOption); ok {
 			nopt.Apply(node)
 		}
 	}
 
+	// Restrict verbosity of node-scoped logs
+	if node.LogLevel > zap.DebugLevel {
+		node.logger = node.logger.WithOptions(zap.IncreaseLevel(node.LogLevel))
+	}
+
+	// Enable log if level is debug unless set by the LogToDebug option
+	if !node.LogToDebugSet {
+		node.LogToDebug = node.logger.Core().Enabled(zap.DebugLevel)
+	}
+
+	node.logger.Info("Adding new node")
+
+	if node.TimeOffset != 0 {
+		if _, err := os.Stat("/proc/self/ns/time"); err != nil {
+			return nil, fmt.Errorf("kernel lacks support for time namespaces: %w", err)
+		}
+	}
+
+	if node.SeccompProfile != nil {
+		if _, err := node.SeccompProfile.compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	if node.NSSwitchHosts != nil {
+		if err := node.GenerateNSSwitchConf(); err != nil {
+			return nil, fmt.Errorf("failed to generate nsswitch.conf: %w", err)
+		}
+	}
+
 	if node.ExistingNamespace != "" {
 		// Use an existing namespace created by "ip netns add"
 		nsh, err := netns.GetFromName(node.ExistingNamespace)
@@ -90,9 +152,15 @@ func (n *Network) AddNode(name string, opts ...Option) (*BaseNode, error) {
 	} else {
 		// Create a new network namespace
 		nsName := fmt.Sprintf("%s%s-%s", n.NSPrefix, n.Name, name)
-		if node.Namespace, err = NewNamespace(nsName); err != nil {
+		if node.Namespace, err = newNamespace(nsName, node.logger); err != nil {
 			return nil, err
 		}
+
+		if n.DisableIPv6 {
+			if err := node.DisableIPv6(); err != nil {
+				return nil, fmt.Errorf("failed to disable IPv6: %w", err)
+			}
+		}
 	}
 
 	if node.nlHandle == nil {
@@ -103,7 +171,7 @@ func (n *Network) AddNode(name string, opts ...Option) (*BaseNode, error) {
 	}
 
 	src := fmt.Sprintf("/proc/self/fd/%d", int(node.NsHandle))
-	dst := filepath.Join(basePath, "ns", "net")
+	dst := node.NetNSPath()
 	if err := utils.Touch(dst); err != nil {
 		return nil, err
 	}
@@ -111,6 +179,18 @@ func (n *Network) AddNode(name string, opts ...Option) (*BaseNode, error) {
 		return nil, fmt.Errorf("failed to bind mount netns fd: %s", err)
 	}
 
+	if node.CgroupParent != "" {
+		if err := node.createCgroup(); err != nil {
+			return nil, err
+		}
+	}
+
+	if n.Init && node.ExistingNamespace == "" && node.ExistingDockerContainer == "" {
+		if err := node.startInit(); err != nil {
+			return nil, err
+		}
+	}
+
 	n.Register(node)
 
 	return node, nil
@@ -122,6 +202,21 @@ func (n *BaseNode) NetNSHandle() netns.NsHandle {
 	return n.NsHandle
 }
 
+// NetNSPath returns the path of a file referring to the network namespace of the node
+func (n *BaseNode) NetNSPath() string {
+	if n.IsHostNode() {
+		return fmt.Sprintf("/proc/%d/ns/net", os.Getpid())
+	}
+
+	return filepath.Join(n.BasePath, "ns", "net")
+}
+
+// DebugCommand returns a shell command which starts an interactive shell
+// in the network namespace of the node, e.g. for attaching to a paused test.
+func (n *BaseNode) DebugCommand() string {
+	return fmt.Sprintf("nsenter --net=%s bash", n.NetNSPath())
+}
+
 func (n *BaseNode) NetlinkHandle() *nl.Handle {
 	return n.nlHandle
 }
@@ -153,6 +248,22 @@ func (n *BaseNode) Interface(name string) *Interface {
 	return nil
 }
 
+// SetMeta attaches an arbitrary key/value pair to the node.
+// The metadata is not interpreted by Gont itself.
+func (n *BaseNode) SetMeta(key, value string) {
+	if n.meta == nil {
+		n.meta = map[string]string{}
+	}
+
+	n.meta[key] = value
+}
+
+// Meta returns the metadata value stored for key
+// or an empty string if it has not been set.
+func (n *BaseNode) Meta(key string) string {
+	return n.meta[key]
+}
+
 func (n *BaseNode) ConfigureInterface(i *Interface) error {
 	logger := n.logger.With(zap.Any("intf", i))
 	logger.Info("Configuring interface")
@@ -184,6 +295,15 @@ func (n *BaseNode) ConfigureInterface(i *Interface) error {
 		}
 	}
 
+	if i.LinkAttrs.Alias != "" {
+		logger.Info("Setting interface alias",
+			zap.String("alias", i.LinkAttrs.Alias),
+		)
+		if err := n.nlHandle.LinkSetAlias(i.Link, i.LinkAttrs.Alias); err != nil {
+			return err
+		}
+	}
+
 	if i.LinkAttrs.Group != 0 {
 		logger.Info("Setting interface group",
 			zap.Uint32("group", i.LinkAttrs.Group),
@@ -193,22 +313,58 @@ func (n *BaseNode) ConfigureInterface(i *Interface) error {
 		}
 	}
 
+	if err := n.addInterfaceQdiscs(i, logger); err != nil {
+		return err
+	}
+
+	if err := n.runInterfaceHooks(i, i.PreUp); err != nil {
+		return fmt.Errorf("failed to run pre-up hook: %w", err)
+	}
+
+	logger.Info("Setting interface up")
+	if err := n.nlHandle.LinkSetUp(i.Link); err != nil {
+		return err
+	}
+
+	if err := n.runInterfaceHooks(i, i.PostUp); err != nil {
+		return fmt.Errorf("failed to run post-up hook: %w", err)
+	}
+
+	n.Interfaces = append(n.Interfaces, i)
+
+	if err := n.network.updateHostsFile(); err != nil {
+		return fmt.Errorf("failed to update hosts file: %w", err)
+	}
+
+	return nil
+}
+
+// addInterfaceQdiscs adds the Netem and TBF qdiscs configured by the options of the interface.
+//
+// Unless specified explicitly, Netem uses the handle 1:0 at the root and TBF uses
+// the handle 2:0 below Netem or at the root. If the parent of one qdisc belongs to
+// the other, the other one is added first.
+func (n *BaseNode) addInterfaceQdiscs(i *Interface, logger *zap.Logger) error {
+	type qdisc struct {
+		name string
+		nl.Qdisc
+	}
+
+	qdiscs := []qdisc{}
+
 	var pHandle uint32 = nl.HANDLE_ROOT
 	if i.Flags&WithQdiscNetem != 0 {
-		attr := nl.QdiscAttrs{
-			LinkIndex: i.Link.Attrs().Index,
-			Handle:    nl.MakeHandle(1, 0),
-			Parent:    pHandle,
-		}
+		attrs := i.netemQdiscAttrs(i.Link)
 
-		netem := nl.NewNetem(attr, i.Netem)
-
-		logger.Info("Adding Netem qdisc to interface")
-		if err := n.nlHandle.QdiscAdd(netem); err != nil {
-			return err
+		if i.NetemSlot != nil {
+			if err := i.NetemSlot.Validate(); err != nil {
+				return fmt.Errorf("invalid Netem slot: %w", err)
+			}
 		}
 
-		pHandle = netem.Handle
+		qdiscs = append(qdiscs, qdisc{"Netem", nl.NewNetem(attrs, i.Netem)})
+
+		pHandle = attrs.Handle
 	}
 	if i.Flags&WithQdiscTbf != 0 {
 		i.Tbf.LinkIndex = i.Link.Attrs().Index
@@ -216,43 +372,150 @@ func (n *BaseNode) ConfigureInterface(i *Interface) error {
 		i.Tbf.Minburst = 1600
 		i.Tbf.Buffer = 300000
 		i.Tbf.Peakrate = 0x1000000
-		i.Tbf.QdiscAttrs = nl.QdiscAttrs{
-			LinkIndex: i.Link.Attrs().Index,
-			Handle:    nl.MakeHandle(2, 0),
-			Parent:    pHandle,
+		if i.Tbf.Handle == 0 {
+			i.Tbf.Handle = nl.MakeHandle(2, 0)
+		}
+		if i.Tbf.Parent == 0 {
+			i.Tbf.Parent = pHandle
 		}
 
-		logger.Info("Adding TBF qdisc to interface")
-		if err := n.nlHandle.QdiscAdd(&i.Tbf); err != nil {
-			return err
+		qdiscs = append(qdiscs, qdisc{"TBF", &i.Tbf})
+	}
+
+	if len(qdiscs) == 0 {
+		return nil
+	}
+
+	existing, err := n.nlHandle.QdiscList(i.Link)
+	if err != nil {
+		return fmt.Errorf("failed to list qdiscs: %w", err)
+	}
+
+	used := map[uint16]bool{}
+	for _, q := range existing {
+		if major, _ := nl.MajorMinor(q.Attrs().Handle); major != 0 {
+			used[major] = true
 		}
 	}
 
-	logger.Info("Setting interface up")
-	if err := n.nlHandle.LinkSetUp(i.Link); err != nil {
-		return err
+	for _, q := range qdiscs {
+		major, minor := nl.MajorMinor(q.Attrs().Handle)
+		if minor != 0 {
+			return fmt.Errorf("invalid handle %s of %s qdisc: minor number must be zero", nl.HandleStr(q.Attrs().Handle), q.name)
+		} else if used[major] {
+			return fmt.Errorf("handle %s of %s qdisc is already in use", nl.HandleStr(q.Attrs().Handle), q.name)
+		}
+
+		used[major] = true
 	}
 
-	n.Interfaces = append(n.Interfaces, i)
+	if len(qdiscs) == 2 {
+		parent, _ := nl.MajorMinor(qdiscs[0].Attrs().Parent)
+		if other, _ := nl.MajorMinor(qdiscs[1].Attrs().Handle); parent == other {
+			qdiscs[0], qdiscs[1] = qdiscs[1], qdiscs[0]
+		}
+	}
+
+	for _, q := range qdiscs {
+		logger.Info("Adding "+q.name+" qdisc to interface",
+			zap.String("handle", nl.HandleStr(q.Attrs().Handle)),
+			zap.String("parent", nl.HandleStr(q.Attrs().Parent)))
 
-	if err := n.network.GenerateHostsFile(); err != nil {
-		return fmt.Errorf("failed to update hosts file")
+		if err := n.network.captureKernelLog(func() error {
+			if netem, ok := q.Qdisc.(*nl.Netem); ok && i.NetemSlot != nil {
+				return n.addNetemQdisc(netem, i.NetemSlot)
+			}
+
+			return n.nlHandle.QdiscAdd(q.Qdisc)
+		}); err != nil {
+			return fmt.Errorf("failed to add %s qdisc: %w", q.name, err)
+		}
 	}
 
 	return nil
 }
 
+// runInterfaceHooks invokes the hooks within the network namespace of the node
+func (n *BaseNode) runInterfaceHooks(i *Interface, hooks []InterfaceHook) error {
+	for _, hook := range hooks {
+		if err := n.RunFunc(func() error {
+			return hook(i)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsHostNode returns true if the node represents the
+// default network namespace of the host
+func (n *BaseNode) IsHostNode() bool {
+	return n.network != nil && n.network.HostNode != nil && n.network.HostNode.BaseNode == n
+}
+
+func (n *BaseNode) base() *BaseNode {
+	return n
+}
+
+// Teardown stops all services of the node and removes its namespace.
+//
+// All steps are attempted even if some of them fail
+// so that the namespace of the node does not leak.
+// The errors of all failed steps are combined.
 func (n *BaseNode) Teardown() error {
-	if err := n.Namespace.Close(); err != nil {
-		return err
+	n.stopQdiscMonitors()
+	n.stopLatencySpikes()
+
+	err := multierr.Combine(
+		n.closeFileServers(),
+		n.stopTeams(),
+		n.closeGates(),
+		n.restoreInterfaces(),
+		n.closeMulticastRouters(),
+		n.leaveMulticastGroups(),
+	)
+
+	// We must never delete or unmount the default namespace of the host.
+	// Hence we only remove the interfaces which have been added by Gont.
+	if n.IsHostNode() {
+		return multierr.Append(err, n.teardownInterfaces())
 	}
 
-	nsMount := filepath.Join(n.BasePath, "ns", "net")
-	if err := unix.Unmount(nsMount, 0); err != nil {
-		return err
+	err = multierr.Combine(err,
+		n.stopInit(),
+		n.removeCgroup(),
+		n.Namespace.Close(),
+	)
+
+	if uerr := unix.Unmount(n.NetNSPath(), 0); uerr != nil {
+		err = multierr.Append(err, fmt.Errorf("failed to unmount namespace: %w", uerr))
 	}
 
-	return os.RemoveAll(n.BasePath)
+	return multierr.Append(err, os.RemoveAll(n.BasePath))
+}
+
+func (n *BaseNode) teardownInterfaces() error {
+	for _, i := range n.Interfaces {
+		if i.Link == nil {
+			continue
+		}
+
+		// The interface might have been removed already together with its peer
+		if _, err := n.nlHandle.LinkByIndex(i.Link.Attrs().Index); err != nil {
+			continue
+		}
+
+		n.logger.Info("Deleting interface", zap.Any("intf", i))
+
+		if err := n.nlHandle.LinkDel(i.Link); err != nil {
+			return fmt.Errorf("failed to delete interface %s: %w", i.Name, err)
+		}
+	}
+
+	n.Interfaces = nil
+
+	return nil
 }
 
 func (n *BaseNode) WriteProcFS(path, value string) error {
@@ -283,7 +546,65 @@ func (n *BaseNode) EnableForwarding() error {
 	return n.WriteProcFS("/proc/sys/net/ipv6/conf/all/forwarding", "1")
 }
 
-func (n *BaseNode) LinkAddAddress(name string, addr net.IPNet) error {
+// DisableIPv6 disables IPv6 for all existing and future interfaces of the node
+func (n *BaseNode) DisableIPv6() error {
+	for _, intf := range []string{"all", "default"} {
+		fn := filepath.Join("/proc/sys/net/ipv6/conf", intf, "disable_ipv6")
+		if err := n.WriteProcFS(fn, "1"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetIPv6PrivacyExtensions controls the generation of temporary addresses
+// (RFC 4941) by stateless address autoconfiguration (SLAAC) on the interface iface.
+// The names "all" and "default" configure all or future interfaces of the node.
+//
+// Valid levels are:
+//   - 0: do not generate temporary addresses (default)
+//   - 1: generate temporary addresses but prefer public addresses as source
+//   - 2: generate temporary addresses and prefer them as source
+//
+// The level applies to addresses which are configured by subsequent router advertisements.
+func (n *BaseNode) SetIPv6PrivacyExtensions(iface string, level int) error {
+	if level < 0 || level > 2 {
+		return fmt.Errorf("invalid privacy extensions level: %d", level)
+	}
+
+	if n.network.DisableIPv6 {
+		return errors.New("failed to set privacy extensions: IPv6 is disabled")
+	}
+
+	fn := filepath.Join("/proc/sys/net/ipv6/conf", iface, "use_tempaddr")
+	if err := n.WriteProcFS(fn, strconv.Itoa(level)); err != nil {
+		return fmt.Errorf("failed to set privacy extensions: %w", err)
+	}
+
+	return nil
+}
+
+// SetCongestionControl sets the default TCP congestion control algorithm
+// of the network namespace of the node.
+func (n *BaseNode) SetCongestionControl(algo string) error {
+	if err := n.WriteProcFS("/proc/sys/net/ipv4/tcp_congestion_control", algo); err != nil {
+		available, _ := os.ReadFile("/proc/sys/net/ipv4/tcp_available_congestion_control")
+		return fmt.Errorf("failed to set congestion control algorithm %s (is the tcp_%s module loaded? available: %s): %w",
+			algo, algo, strings.TrimSpace(string(available)), err)
+	}
+
+	return nil
+}
+
+// LinkAddAddress adds an address to the interface name of the node.
+//
+// Flags and lifetimes of the address can be set via AddressOptions.
+func (n *BaseNode) LinkAddAddress(name string, addr net.IPNet, opts ...Option) error {
+	if err := checkOptions(opts, targetAddress); err != nil {
+		return err
+	}
+
 	link, err := n.nlHandle.LinkByName(name)
 	if err != nil {
 		return err
@@ -293,6 +614,16 @@ func (n *BaseNode) LinkAddAddress(name string, addr net.IPNet) error {
 		IPNet: &addr,
 	}
 
+	for _, opt := range opts {
+		if aopt, ok := opt.(AddressOption); ok {
+			aopt.Apply(nlAddr)
+		}
+	}
+
+	if nlAddr.Broadcast != nil && (addr.IP.To4() == nil || nlAddr.Broadcast.To4() == nil) {
+		return errors.New("broadcast addresses are only supported for IPv4")
+	}
+
 	n.logger.Info("Adding new address to interface",
 		zap.String("intf", fmt.Sprintf("%s/%s", n, name)),
 		zap.String("addr", addr.String()),
@@ -305,13 +636,51 @@ func (n *BaseNode) LinkAddAddress(name string, addr net.IPNet) error {
 	return err
 }
 
+// AddRoute installs the route r in the node.
+//
+// Besides the destination and gateway, attributes like the metric (Priority),
+// the path MTU (MTU) and the advertised TCP MSS (AdvMSS) are honored.
 func (n *BaseNode) AddRoute(r *nl.Route) error {
 	n.logger.Info("Add route",
 		zap.Any("dst", r.Dst),
 		zap.Any("gw", r.Gw),
+		zap.Int("metric", r.Priority),
 	)
 
-	return n.nlHandle.RouteAdd(r)
+	return n.network.captureKernelLog(func() error {
+		return n.nlHandle.RouteAdd(r)
+	})
+}
+
+// AddRouteWithMetric installs a route to dst via the gateway gw with the metric.
+//
+// Of multiple routes to the same destination, the one with the lowest metric is preferred.
+func (n *BaseNode) AddRouteWithMetric(dst net.IPNet, gw net.IP, metric int) error {
+	if metric < 0 {
+		return fmt.Errorf("invalid metric: %d", metric)
+	}
+
+	return n.AddRoute(&nl.Route{
+		Dst:      &dst,
+		Gw:       gw,
+		Priority: metric,
+	})
+}
+
+// AddRoutes installs multiple routes in a single pass using AddRoute.
+//
+// In contrast to calling AddRoute repeatedly, AddRoutes continues
+// after a failed route and returns all errors combined.
+// The routes are installed in the order in which they have been passed.
+func (n *BaseNode) AddRoutes(routes ...*nl.Route) error {
+	var errs error
+	for _, r := range routes {
+		if err := n.AddRoute(r); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to add route %s: %w", r, err))
+		}
+	}
+
+	return errs
 }
 
 func (n *BaseNode) AddDefaultRoute(gw net.IP) error {
@@ -328,6 +697,64 @@ func (n *BaseNode) AddDefaultRoute(gw net.IP) error {
 	})
 }
 
+// ReplaceDefaultRoute atomically replaces the default route of the address
+// family of gw so that there is no window without a default route.
+//
+// The gateway must be part of a subnet which is directly connected to the node.
+func (n *BaseNode) ReplaceDefaultRoute(gw net.IP) error {
+	dst, family := &DefaultIPv6Mask, nl.FAMILY_V6
+	if gw.To4() != nil {
+		dst, family = &DefaultIPv4Mask, nl.FAMILY_V4
+	}
+
+	addrs, err := n.nlHandle.AddrList(nil, family)
+	if err != nil {
+		return fmt.Errorf("failed to list addresses: %w", err)
+	}
+
+	linkIndex := -1
+	for _, addr := range addrs {
+		if addr.IPNet.Contains(gw) && !addr.IP.Equal(gw) {
+			linkIndex = addr.LinkIndex
+			break
+		}
+	}
+
+	if linkIndex < 0 {
+		return fmt.Errorf("gateway %s is not part of a connected subnet", gw)
+	}
+
+	n.logger.Info("Replace default route",
+		zap.Any("gw", gw),
+	)
+
+	return n.nlHandle.RouteReplace(&nl.Route{
+		Dst:       dst,
+		Gw:        gw,
+		LinkIndex: linkIndex,
+	})
+}
+
+// AddBlackholeRoute installs a route of type typ which discards all packets
+// destined to dst. Depending on the type, the sender is notified with an error.
+func (n *BaseNode) AddBlackholeRoute(dst net.IPNet, typ RouteType) error {
+	switch typ {
+	case RouteBlackhole, RouteUnreachable, RouteProhibit:
+	default:
+		return fmt.Errorf("invalid route type: %d", typ)
+	}
+
+	n.logger.Info("Add route",
+		zap.Any("dst", dst),
+		zap.Stringer("type", typ),
+	)
+
+	return n.nlHandle.RouteAdd(&nl.Route{
+		Dst:  &dst,
+		Type: int(typ),
+	})
+}
+
 // AddInterface adds an interface to the list of configured interfaces
 func (n *BaseNode) AddInterface(i *Interface) {
 	n.ConfiguredInterfaces = append(n.ConfiguredInterfaces, i)
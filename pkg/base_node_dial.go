@@ -0,0 +1,68 @@
+package gont
+
+import (
+	"context"
+	"net"
+	"strconv"
+)
+
+const dnsPort = 53
+
+// Resolver returns a resolver which performs DNS lookups from within the
+// network namespace of the node.
+//
+// The resolver queries the first nameserver configured for the network.
+// Without configured nameservers the nameservers of the host are used.
+func (n *BaseNode) Resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if len(n.network.Nameservers) > 0 {
+				address = net.JoinHostPort(n.network.Nameservers[0].String(), strconv.Itoa(dnsPort))
+			}
+
+			return n.dialContext(ctx, &net.Dialer{}, network, address)
+		},
+	}
+}
+
+// Dial connects to the address on the named network from within
+// the network namespace of the node.
+//
+// Hostnames are resolved using the resolver returned by Resolver.
+func (n *BaseNode) Dial(network, address string, opts ...Option) (net.Conn, error) {
+	return n.DialContext(context.Background(), network, address, opts...)
+}
+
+// DialContext connects to the address on the named network from within
+// the network namespace of the node using the provided context.
+func (n *BaseNode) DialContext(ctx context.Context, network, address string, opts ...Option) (net.Conn, error) {
+	d := &net.Dialer{
+		Resolver: n.Resolver(),
+
+		// Fast fallback dials from additional goroutines
+		// which are not running in the namespace of the node
+		FallbackDelay: -1,
+	}
+
+	for _, opt := range opts {
+		if dopt, ok := opt.(DialOption); ok {
+			dopt.Apply(d)
+		}
+	}
+
+	return n.dialContext(ctx, d, network, address)
+}
+
+func (n *BaseNode) dialContext(ctx context.Context, d *net.Dialer, network, address string) (net.Conn, error) {
+	var conn net.Conn
+
+	if err := n.RunFunc(func() (err error) {
+		conn, err = d.DialContext(ctx, network, address)
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
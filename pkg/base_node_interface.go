@@ -0,0 +1,364 @@
+package gont
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// AddInterfaceLive adds an interface to a node which is already running
+// and configures it immediately.
+//
+// If i.Link is set, the link is moved from the network namespace of the host
+// into the namespace of the node and renamed to i.Name.
+// Otherwise, a new dummy interface is created.
+//
+// If the interface can not be configured, the link is deleted again.
+// Adopted interfaces are moved back into the namespace of the host instead.
+func (n *BaseNode) AddInterfaceLive(i *Interface) error {
+	return n.addInterfaceLive(i, &nl.Dummy{
+		LinkAttrs: nl.LinkAttrs{
+			Name: i.Name,
+		},
+	})
+}
+
+// addInterfaceLive adds the interface to the running node.
+// If i.Link is not set, the link is created from newLink.
+func (n *BaseNode) addInterfaceLive(i *Interface, newLink nl.Link) error {
+	if n.Interface(i.Name) != nil {
+		return fmt.Errorf("interface %s already exists", i.Name)
+	}
+
+	if _, err := n.nlHandle.LinkByName(i.Name); err == nil {
+		return fmt.Errorf("interface %s already exists in namespace", i.Name)
+	}
+
+	if i.Node == nil {
+		if i.Node = n.node(); i.Node == nil {
+			return fmt.Errorf("failed to find node %s", n.name)
+		}
+	}
+
+	n.logger.Info("Adding interface to running node", zap.Any("intf", i))
+
+	name := i.Name
+	if i.Link != nil {
+		name = i.Link.Attrs().Name
+
+		if err := n.network.HostNode.nlHandle.LinkSetNsFd(i.Link, int(n.NsHandle)); err != nil {
+			return fmt.Errorf("failed to move interface to namespace: %w", err)
+		}
+	} else if err := n.network.captureKernelLog(func() error {
+		return n.nlHandle.LinkAdd(newLink)
+	}); err != nil {
+		return fmt.Errorf("failed to add link: %w", err)
+	}
+
+	if err := n.configureInterfaceLive(i, name); err != nil {
+		// Do not leave a partially configured link behind
+		if rerr := n.rollbackInterfaceLive(i, name); rerr != nil {
+			n.logger.Error("Failed to roll back interface", zap.Error(rerr))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// configureInterfaceLive renames and configures a link which
+// has been moved into or created in the namespace of the node.
+func (n *BaseNode) configureInterfaceLive(i *Interface, name string) error {
+	if name != i.Name {
+		link, err := n.nlHandle.LinkByName(name)
+		if err != nil {
+			return fmt.Errorf("failed to find interface %s: %w", name, err)
+		}
+
+		if err := n.nlHandle.LinkSetName(link, i.Name); err != nil {
+			return fmt.Errorf("failed to rename interface: %w", err)
+		}
+	}
+
+	var err error
+	if i.Link, err = n.nlHandle.LinkByName(i.Name); err != nil {
+		return fmt.Errorf("failed to find interface %s: %w", i.Name, err)
+	}
+
+	if err := i.Configure(); err != nil {
+		return fmt.Errorf("failed to configure interface: %w", err)
+	}
+
+	return nil
+}
+
+// rollbackInterfaceLive removes a link which could not be configured
+// by addInterfaceLive. Adopted interfaces are restored to the host instead.
+func (n *BaseNode) rollbackInterfaceLive(i *Interface, name string) error {
+	if n.Interface(i.Name) == i {
+		return n.DelInterface(i.Name)
+	}
+
+	if n.isAdopted(i) {
+		return n.restoreInterface(i)
+	}
+
+	// The link still has its original name if renaming failed
+	for _, name := range []string{i.Name, name} {
+		if link, err := n.nlHandle.LinkByName(name); err == nil {
+			if err := n.nlHandle.LinkDel(link); err != nil {
+				return fmt.Errorf("failed to delete interface %s: %w", name, err)
+			}
+
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// node returns the registered node which embeds this base node
+func (n *BaseNode) node() Node {
+	if n.IsHostNode() {
+		return n.network.HostNode
+	}
+
+	n.network.NodesLock.RLock()
+	defer n.network.NodesLock.RUnlock()
+
+	return n.network.Nodes[n.name]
+}
+
+// DelInterface removes an interface from a running node.
+//
+// The link is deleted from the namespace of the node together with all
+// addresses and routes which depend on it. For veth interfaces, the kernel
+// also removes the peer interface. Interfaces which have been adopted from
+// the host are moved back into the namespace of the host instead.
+func (n *BaseNode) DelInterface(name string) error {
+	idx := -1
+	for j, i := range n.Interfaces {
+		if i.Name == name {
+			idx = j
+			break
+		}
+	}
+
+	if idx < 0 {
+		return fmt.Errorf("interface %s does not exist", name)
+	}
+
+	i := n.Interfaces[idx]
+
+	if n.isAdopted(i) {
+		if err := n.restoreInterface(i); err != nil {
+			return err
+		}
+	} else {
+		n.logger.Info("Deleting interface", zap.Any("intf", i))
+
+		// The interface might have been removed already together with its peer
+		if link, err := n.nlHandle.LinkByName(name); err == nil {
+			if err := n.nlHandle.LinkDel(link); err != nil {
+				return fmt.Errorf("failed to delete interface %s: %w", name, err)
+			}
+		}
+	}
+
+	n.Interfaces = append(n.Interfaces[:idx], n.Interfaces[idx+1:]...)
+
+	if err := n.network.updateHostsFile(); err != nil {
+		return fmt.Errorf("failed to update hosts file: %w", err)
+	}
+
+	return nil
+}
+
+// AdoptInterface moves an existing interface from the network namespace
+// of the host into the node and configures it.
+//
+// Adopted interfaces are moved back to the namespace of the host when the
+// node is torn down. If the process crashes, the kernel returns physical
+// interfaces to the namespace of the host as soon as the namespace of the
+// node is destroyed. Virtual interfaces are destroyed together with it.
+func (n *BaseNode) AdoptInterface(name string, opts ...Option) (*Interface, error) {
+	if n.IsHostNode() {
+		return nil, errors.New("cant adopt interface into the host node")
+	}
+
+	link, err := n.network.HostNode.nlHandle.LinkByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find host interface %s: %w", name, err)
+	}
+
+	i := &Interface{
+		Name: name,
+		Link: link,
+	}
+
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case InterfaceOption:
+			opt.Apply(i)
+		case LinkOption:
+			opt.Apply(&i.LinkAttrs)
+		}
+	}
+
+	n.adoptedInterfaces = append(n.adoptedInterfaces, i)
+
+	if err := n.AddInterfaceLive(i); err != nil {
+		// Make sure the host does not loose its interface
+		if rerr := n.restoreInterface(i); rerr != nil {
+			n.logger.Error("Failed to restore adopted interface", zap.Error(rerr))
+		}
+
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// AdoptLink wraps an existing link in the namespace of the node into
+// an interface which is managed by Gont.
+//
+// This allows the use of links which have been created out-of-band like the
+// tap devices of virtual machines. The link is not reconfigured.
+// Like other interfaces, it is deleted when the node is torn down.
+func (n *BaseNode) AdoptLink(name string) (*Interface, error) {
+	if n.Interface(name) != nil {
+		return nil, fmt.Errorf("interface %s already exists", name)
+	}
+
+	link, err := n.nlHandle.LinkByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find link %s: %w", name, err)
+	}
+
+	node := n.node()
+	if node == nil {
+		return nil, fmt.Errorf("failed to find node %s", n.name)
+	}
+
+	i := &Interface{
+		Name: name,
+		Node: node,
+		Link: link,
+	}
+
+	n.logger.Info("Adopting link", zap.Any("intf", i))
+
+	n.Interfaces = append(n.Interfaces, i)
+
+	return i, nil
+}
+
+func (n *BaseNode) isAdopted(i *Interface) bool {
+	for _, a := range n.adoptedInterfaces {
+		if a == i {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restoreInterface moves an adopted interface back into the namespace of the host
+func (n *BaseNode) restoreInterface(i *Interface) error {
+	for j, a := range n.adoptedInterfaces {
+		if a == i {
+			n.adoptedInterfaces = append(n.adoptedInterfaces[:j], n.adoptedInterfaces[j+1:]...)
+			break
+		}
+	}
+
+	// The link might not have been moved yet
+	link, err := n.nlHandle.LinkByName(i.Name)
+	if err != nil {
+		return nil
+	}
+
+	n.logger.Info("Restoring adopted interface to host", zap.Any("intf", i))
+
+	if err := n.nlHandle.LinkSetDown(link); err != nil {
+		return fmt.Errorf("failed to set interface down: %w", err)
+	}
+
+	if err := n.nlHandle.LinkSetNsFd(link, int(n.network.HostNode.NsHandle)); err != nil {
+		return fmt.Errorf("failed to move interface to host namespace: %w", err)
+	}
+
+	return nil
+}
+
+func (n *BaseNode) restoreInterfaces() error {
+	for len(n.adoptedInterfaces) > 0 {
+		if err := n.restoreInterface(n.adoptedInterfaces[0]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WaitReady blocks until all interfaces of the node are up and have a carrier
+// and all their IPv6 addresses have left the tentative state of the
+// duplicate address detection (DAD).
+//
+// The timeout is controlled by the deadline of the context.
+func (n *BaseNode) WaitReady(ctx context.Context) error {
+	t := time.NewTicker(10 * time.Millisecond)
+	defer t.Stop()
+
+	for {
+		ready, err := n.isReady()
+		if err != nil {
+			return err
+		} else if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("node %s is not ready: %w", n.name, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+func (n *BaseNode) isReady() (bool, error) {
+	for _, i := range n.Interfaces {
+		link, err := n.nlHandle.LinkByName(i.Name)
+		if err != nil {
+			return false, fmt.Errorf("failed to find interface %s: %w", i.Name, err)
+		}
+
+		if link.Attrs().Flags&net.FlagUp == 0 {
+			return false, nil
+		}
+
+		if link.Attrs().RawFlags&unix.IFF_LOWER_UP == 0 {
+			return false, nil
+		}
+
+		addrs, err := n.nlHandle.AddrList(link, nl.FAMILY_V6)
+		if err != nil {
+			return false, fmt.Errorf("failed to list addresses: %w", err)
+		}
+
+		for _, addr := range addrs {
+			if addr.Flags&unix.IFA_F_TENTATIVE != 0 {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,115 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// SendPacket transmits a packet out of the interface iface of the node.
+//
+// Packets starting with an Ethernet layer are transmitted as raw frames (L2).
+// Packets starting with an IPv4 or IPv6 layer are transmitted via a raw IP socket (L3)
+// bound to the interface. In this case, the kernel resolves the link-layer address of the
+// next-hop.
+func (n *BaseNode) SendPacket(iface string, pkt gopacket.Packet) error {
+	lyrs := pkt.Layers()
+	if len(lyrs) == 0 {
+		return errors.New("empty packet")
+	}
+
+	switch lyrs[0].LayerType() {
+	case layers.LayerTypeEthernet:
+		return n.sendFrame(iface, pkt.Data())
+
+	case layers.LayerTypeIPv4:
+		ip := lyrs[0].(*layers.IPv4)
+		return n.sendIPPacket(iface, unix.AF_INET, ip.DstIP, pkt.Data())
+
+	case layers.LayerTypeIPv6:
+		ip := lyrs[0].(*layers.IPv6)
+		return n.sendIPPacket(iface, unix.AF_INET6, ip.DstIP, pkt.Data())
+
+	default:
+		return fmt.Errorf("unsupported layer type: %s", lyrs[0].LayerType())
+	}
+}
+
+// sendFrame transmits a raw Ethernet frame via an AF_PACKET socket
+func (n *BaseNode) sendFrame(iface string, frame []byte) error {
+	fd, sa, err := n.openPacketSocket(iface)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	n.logger.Debug("Sending frame",
+		zap.String("intf", iface),
+		zap.Int("len", len(frame)),
+	)
+
+	return unix.Sendto(fd, frame, 0, sa)
+}
+
+// openPacketSocket opens an AF_PACKET socket in the namespace of the node
+// and returns it together with the link-layer address of the interface.
+func (n *BaseNode) openPacketSocket(iface string) (int, *unix.SockaddrLinklayer, error) {
+	link, err := n.nlHandle.LinkByName(iface)
+	if err != nil {
+		return -1, nil, fmt.Errorf("failed to find interface %s: %w", iface, err)
+	}
+
+	var fd int
+	if err := n.RunFunc(func() (err error) {
+		fd, err = unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0)
+		return
+	}); err != nil {
+		return -1, nil, fmt.Errorf("failed to open packet socket: %w", err)
+	}
+
+	sa := &unix.SockaddrLinklayer{
+		Ifindex: link.Attrs().Index,
+	}
+
+	return fd, sa, nil
+}
+
+// sendIPPacket transmits a raw IP packet including its header via a raw socket
+func (n *BaseNode) sendIPPacket(iface string, family int, dst net.IP, pkt []byte) error {
+	var fd int
+	if err := n.RunFunc(func() (err error) {
+		fd, err = unix.Socket(family, unix.SOCK_RAW, unix.IPPROTO_RAW)
+		return
+	}); err != nil {
+		return fmt.Errorf("failed to open raw socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	if err := unix.BindToDevice(fd, iface); err != nil {
+		return fmt.Errorf("failed to bind socket to interface %s: %w", iface, err)
+	}
+
+	var sa unix.Sockaddr
+	if family == unix.AF_INET {
+		sa4 := &unix.SockaddrInet4{}
+		copy(sa4.Addr[:], dst.To4())
+		sa = sa4
+	} else {
+		sa6 := &unix.SockaddrInet6{}
+		copy(sa6.Addr[:], dst.To16())
+		sa = sa6
+	}
+
+	n.logger.Debug("Sending packet",
+		zap.String("intf", iface),
+		zap.Any("dst", dst),
+		zap.Int("len", len(pkt)),
+	)
+
+	return unix.Sendto(fd, pkt, 0, sa)
+}
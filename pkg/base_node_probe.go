@@ -0,0 +1,244 @@
+package gont
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// probeTimeout is the duration for which ARPProbe and NDPProbe wait for a reply
+const probeTimeout = time.Second
+
+// ARPProbe sends an ARP request for the IPv4 address target out of the
+// interface iface and returns the link-layer address of the reply.
+//
+// The first IPv4 address of the interface is used as the sender address.
+// Without an address, an ARP probe with an unspecified sender is sent.
+// An error wrapping os.ErrDeadlineExceeded is returned if there is no reply within a second.
+func (n *BaseNode) ARPProbe(iface string, target net.IP) (net.HardwareAddr, error) {
+	if target.To4() == nil {
+		return nil, fmt.Errorf("invalid IPv4 address: %s", target)
+	}
+
+	fd, link, err := n.openProbeSocket(iface, unix.ETH_P_ARP)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	src, err := n.probeSource(link, nl.FAMILY_V4)
+	if err != nil {
+		return nil, err
+	} else if src == nil {
+		src = net.IPv4zero
+	}
+
+	srcMAC := link.Attrs().HardwareAddr
+
+	eth := &layers.Ethernet{
+		SrcMAC:       srcMAC,
+		DstMAC:       layers.EthernetBroadcast,
+		EthernetType: layers.EthernetTypeARP,
+	}
+
+	arp := &layers.ARP{
+		AddrType:          layers.LinkTypeEthernet,
+		Protocol:          layers.EthernetTypeIPv4,
+		HwAddressSize:     6,
+		ProtAddressSize:   4,
+		Operation:         layers.ARPRequest,
+		SourceHwAddress:   srcMAC,
+		SourceProtAddress: src.To4(),
+		DstHwAddress:      make([]byte, 6),
+		DstProtAddress:    target.To4(),
+	}
+
+	return n.probe(fd, link, target, func(p gopacket.Packet) net.HardwareAddr {
+		if arp, ok := p.Layer(layers.LayerTypeARP).(*layers.ARP); ok &&
+			arp.Operation == layers.ARPReply && bytes.Equal(arp.SourceProtAddress, target.To4()) {
+			return arp.SourceHwAddress
+		}
+
+		return nil
+	}, eth, arp)
+}
+
+// NDPProbe sends an IPv6 neighbor solicitation for the address target out of the
+// interface iface and returns the link-layer address of the neighbor advertisement.
+//
+// The first IPv6 address of the interface is used as the source address.
+// Without an address, the solicitation is sent from the unspecified address.
+// An error wrapping os.ErrDeadlineExceeded is returned if there is no reply within a second.
+func (n *BaseNode) NDPProbe(iface string, target net.IP) (net.HardwareAddr, error) {
+	if target.To4() != nil || target.To16() == nil {
+		return nil, fmt.Errorf("invalid IPv6 address: %s", target)
+	}
+
+	fd, link, err := n.openProbeSocket(iface, unix.ETH_P_IPV6)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	src, err := n.probeSource(link, nl.FAMILY_V6)
+	if err != nil {
+		return nil, err
+	}
+
+	srcMAC := link.Attrs().HardwareAddr
+
+	// Solicited-node multicast address
+	dst := net.ParseIP("ff02::1:ff00:0")
+	copy(dst[13:], target.To16()[13:])
+
+	eth := &layers.Ethernet{
+		SrcMAC:       srcMAC,
+		DstMAC:       net.HardwareAddr{0x33, 0x33, dst[12], dst[13], dst[14], dst[15]},
+		EthernetType: layers.EthernetTypeIPv6,
+	}
+
+	ip := &layers.IPv6{
+		Version:    6,
+		NextHeader: layers.IPProtocolICMPv6,
+		HopLimit:   255,
+		SrcIP:      net.IPv6unspecified,
+		DstIP:      dst,
+	}
+
+	icmp := &layers.ICMPv6{
+		TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeNeighborSolicitation, 0),
+	}
+
+	ns := &layers.ICMPv6NeighborSolicitation{
+		TargetAddress: target.To16(),
+	}
+
+	// The source link-layer address must not be included for an unspecified source
+	if src != nil {
+		ip.SrcIP = src
+		ns.Options = layers.ICMPv6Options{
+			{
+				Type: layers.ICMPv6OptSourceAddress,
+				Data: srcMAC,
+			},
+		}
+	}
+
+	if err := icmp.SetNetworkLayerForChecksum(ip); err != nil {
+		return nil, err
+	}
+
+	return n.probe(fd, link, target, func(p gopacket.Packet) net.HardwareAddr {
+		na, ok := p.Layer(layers.LayerTypeICMPv6NeighborAdvertisement).(*layers.ICMPv6NeighborAdvertisement)
+		if !ok || !na.TargetAddress.Equal(target) {
+			return nil
+		}
+
+		for _, opt := range na.Options {
+			if opt.Type == layers.ICMPv6OptTargetAddress {
+				return net.HardwareAddr(opt.Data)
+			}
+		}
+
+		return nil
+	}, eth, ip, icmp, ns)
+}
+
+// openProbeSocket opens an AF_PACKET socket in the namespace of the node
+// which receives frames of the EtherType proto from the interface iface.
+func (n *BaseNode) openProbeSocket(iface string, proto uint16) (int, nl.Link, error) {
+	link, err := n.nlHandle.LinkByName(iface)
+	if err != nil {
+		return -1, nil, fmt.Errorf("failed to find interface %s: %w", iface, err)
+	}
+
+	var fd int
+	if err := n.RunFunc(func() (err error) {
+		fd, err = unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, int(htons(proto)))
+		return
+	}); err != nil {
+		return -1, nil, fmt.Errorf("failed to open packet socket: %w", err)
+	}
+
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{
+		Protocol: htons(proto),
+		Ifindex:  link.Attrs().Index,
+	}); err != nil {
+		unix.Close(fd)
+		return -1, nil, fmt.Errorf("failed to bind packet socket: %w", err)
+	}
+
+	// Wake up regularly to check the deadline
+	tv := unix.NsecToTimeval(int64(10 * time.Millisecond))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return -1, nil, fmt.Errorf("failed to set timeout: %w", err)
+	}
+
+	return fd, link, nil
+}
+
+// probeSource returns the first address of the family configured on the link
+// or nil if there is none.
+func (n *BaseNode) probeSource(link nl.Link, family int) (net.IP, error) {
+	addrs, err := n.nlHandle.AddrList(link, family)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if addr.Flags&(unix.IFA_F_TENTATIVE|unix.IFA_F_DADFAILED) == 0 {
+			return addr.IP, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// probe transmits the request and waits for a reply from which match extracts a link-layer address
+func (n *BaseNode) probe(fd int, link nl.Link, target net.IP, match func(p gopacket.Packet) net.HardwareAddr, request ...gopacket.SerializableLayer) (net.HardwareAddr, error) {
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{
+		ComputeChecksums: true,
+		FixLengths:       true,
+	}, request...); err != nil {
+		return nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	n.logger.Debug("Probing neighbor",
+		zap.String("intf", link.Attrs().Name),
+		zap.Any("target", target))
+
+	if err := unix.Sendto(fd, buf.Bytes(), 0, &unix.SockaddrLinklayer{
+		Ifindex: link.Attrs().Index,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	frame := make([]byte, 1<<16)
+	for deadline := time.Now().Add(probeTimeout); time.Now().Before(deadline); {
+		l, _, err := unix.Recvfrom(fd, frame, 0)
+		if err != nil {
+			continue
+		}
+
+		p := gopacket.NewPacket(frame[:l], layers.LayerTypeEthernet, gopacket.Default)
+		if mac := match(p); mac != nil {
+			return mac, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no reply from %s: %w", target, os.ErrDeadlineExceeded)
+}
+
+func htons(i uint16) uint16 {
+	return (i<<8)&0xff00 | i>>8
+}
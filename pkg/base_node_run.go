@@ -1,6 +1,9 @@
 package gont
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -8,8 +11,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zapio"
 )
 
@@ -27,6 +33,24 @@ func (n *BaseNode) Command(name string, args ...string) *exec.Cmd {
 				"GONT_UNSHARE=exec",
 				"GONT_NODE="+n.name,
 				"GONT_NETWORK="+n.network.Name)
+
+			if n.Hostname != "" {
+				c.Env = append(c.Env, hostnameEnv+"="+n.Hostname)
+			}
+
+			if n.TimeOffset != 0 {
+				c.Env = append(c.Env, timeOffsetEnv+"="+strconv.FormatInt(int64(n.TimeOffset), 10))
+			}
+
+			if n.SeccompProfile != nil {
+				// Marshaling can not fail as the profile only contains integers
+				profile, _ := json.Marshal(n.SeccompProfile)
+				c.Env = append(c.Env, seccompProfileEnv+"="+string(profile))
+			}
+
+			if n.CgroupParent != "" {
+				c.Env = append(c.Env, cgroupEnv+"="+n.CgroupPath())
+			}
 		} else {
 			c.Path = "/usr/bin/docker"
 			c.Args = append([]string{"docker", "exec", n.ExistingDockerContainer, name}, args...)
@@ -79,9 +103,13 @@ func (n *BaseNode) Start(cmd string, args ...any) (io.Reader, io.Reader, *exec.C
 	var stdout, stderr io.Reader
 
 	strargs := []string{}
+	cmdOpts := []CmdOption{}
 	for _, arg := range args {
 		var strarg string
 		switch arg := arg.(type) {
+		case CmdOption:
+			cmdOpts = append(cmdOpts, arg)
+			continue
 		case Node:
 			strarg = arg.Name()
 		case fmt.Stringer:
@@ -113,7 +141,10 @@ func (n *BaseNode) Start(cmd string, args ...any) (io.Reader, io.Reader, *exec.C
 		strargs = append(strargs, strarg)
 	}
 
-	c := n.Command(cmd, strargs...)
+	c, err := n.commandWithOptions(cmd, strargs, cmdOpts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	if stdout, err = c.StdoutPipe(); err != nil {
 		return nil, nil, nil, err
@@ -141,7 +172,7 @@ func (n *BaseNode) Start(cmd string, args ...any) (io.Reader, io.Reader, *exec.C
 	logger.Info("Process started")
 
 	if n.LogToDebug {
-		slogger := zap.L().With(zap.Int("pid", c.Process.Pid))
+		slogger := n.logger.With(zap.Int("pid", c.Process.Pid))
 
 		logStdout := &zapio.Writer{
 			Log:   slogger,
@@ -166,6 +197,130 @@ func (n *BaseNode) Start(cmd string, args ...any) (io.Reader, io.Reader, *exec.C
 	return stdout, stderr, c, nil
 }
 
+// RunLogged runs the command cmd in the node like Run and forwards each line
+// of its output to logger as soon as it has been written. Lines of the standard
+// output are logged at info and lines of the standard error output at warning level.
+//
+// The process is killed when the context is canceled.
+func (n *BaseNode) RunLogged(ctx context.Context, logger *zap.Logger, cmd string, args ...any) (*exec.Cmd, error) {
+	stdout, stderr, c, err := n.Start(cmd, args...)
+	if err != nil {
+		return c, err
+	}
+
+	logger = logger.With(
+		zap.String("node", n.name),
+		zap.String("cmd", cmd),
+		zap.Int("pid", c.Process.Pid),
+	)
+
+	wg := sync.WaitGroup{}
+	for _, s := range []struct {
+		r     io.Reader
+		level zapcore.Level
+	}{
+		{stdout, zap.InfoLevel},
+		{stderr, zap.WarnLevel},
+	} {
+		w := &zapio.Writer{
+			Log:   logger,
+			Level: s.level,
+		}
+
+		wg.Add(1)
+		go func(r io.Reader) {
+			defer wg.Done()
+
+			io.Copy(w, r)
+			w.Close()
+		}(s.r)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Process.Kill()
+		case <-done:
+		}
+	}()
+
+	// All output must be read before waiting for the process
+	wg.Wait()
+
+	if err := c.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return c, ctx.Err()
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+// commandWithOptions prepares the command cmd and applies the options opts to it.
+func (n *BaseNode) commandWithOptions(cmd string, args []string, opts []CmdOption) (*exec.Cmd, error) {
+	c := n.Command(cmd, args...)
+
+	for _, opt := range opts {
+		opt.Apply(c)
+	}
+
+	// The binary has already been resolved by exec.Command()
+	// using our own PATH rather than the one of the process.
+	if path, ok := lookupEnv(c.Env, "PATH"); ok && len(opts) > 0 && n.ExistingDockerContainer == "" && !strings.Contains(cmd, "/") {
+		bin, err := lookPath(cmd, path)
+		if err != nil {
+			return nil, err
+		}
+
+		c = n.Command(bin, args...)
+		c.Args[0] = cmd
+
+		for _, opt := range opts {
+			opt.Apply(c)
+		}
+	}
+
+	// Processes of the host node are not re-executed by ourself
+	// which would apply the limits before executing the program.
+	if n.NsHandle.Equal(n.network.HostNode.NsHandle) && hasRlimits(c.Env) {
+		return nil, errors.New("resource limits are not supported for processes of the host node")
+	}
+
+	return c, nil
+}
+
+// lookupEnv returns the last value of the variable key in env
+func lookupEnv(env []string, key string) (string, bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		if k, v, ok := strings.Cut(env[i], "="); ok && k == key {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// lookPath searches for an executable named file in the directories of path
+func lookPath(file, path string) (string, error) {
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+
+		bin := filepath.Join(dir, file)
+		if fi, err := os.Stat(bin); err == nil && fi.Mode().IsRegular() && fi.Mode()&0o111 != 0 {
+			return bin, nil
+		}
+	}
+
+	return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
+
 func (n *BaseNode) StartGo(script string, args ...any) (io.Reader, io.Reader, *exec.Cmd, error) {
 	tmp := filepath.Join(n.network.BasePath, fmt.Sprintf("go-build-%d", rand.Intn(1<<16)))
 
@@ -0,0 +1,193 @@
+package gont
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+const cgroupEnv = "GONT_CGROUP"
+
+// CgroupPath returns the path of the cgroup v2 directory
+// containing the processes of the node or an empty string
+// if the node has no cgroup.
+func (n *BaseNode) CgroupPath() string {
+	if n.CgroupParent == "" {
+		return ""
+	}
+
+	return filepath.Join(n.CgroupParent, fmt.Sprintf("gont-%s-%s", n.network.Name, n.name))
+}
+
+// Freeze suspends all processes of the node using the cgroup freezer.
+// It returns after all processes have been stopped.
+func (n *BaseNode) Freeze() error {
+	return n.setFrozen(true)
+}
+
+// Thaw resumes all processes of the node which have been suspended by Freeze().
+func (n *BaseNode) Thaw() error {
+	return n.setFrozen(false)
+}
+
+// Frozen returns true if the processes of the node are suspended.
+func (n *BaseNode) Frozen() (bool, error) {
+	if err := n.checkCgroup(); err != nil {
+		return false, err
+	}
+
+	return n.cgroupEvent("frozen")
+}
+
+func (n *BaseNode) setFrozen(frozen bool) error {
+	if err := n.checkCgroup(); err != nil {
+		return err
+	}
+
+	value := "0"
+	if frozen {
+		value = "1"
+	}
+
+	fn := filepath.Join(n.CgroupPath(), "cgroup.freeze")
+	if err := os.WriteFile(fn, []byte(value), 0); err != nil {
+		return fmt.Errorf("failed to freeze cgroup: %w", err)
+	}
+
+	// Freezing is performed asynchronously by the kernel
+	for deadline := time.Now().Add(time.Second); ; {
+		if f, err := n.cgroupEvent("frozen"); err != nil {
+			return err
+		} else if f == frozen {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			if frozen {
+				return errors.New("failed to freeze processes of cgroup")
+			}
+
+			return errors.New("failed to thaw processes of cgroup")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if frozen {
+		n.logger.Info("Froze processes")
+	} else {
+		n.logger.Info("Thawed processes")
+	}
+
+	return nil
+}
+
+func (n *BaseNode) checkCgroup() error {
+	if n.CgroupParent == "" {
+		return fmt.Errorf("node %s has no cgroup. Use the WithCgroup() option", n.name)
+	}
+
+	return nil
+}
+
+// cgroupEvent returns the state of key in the cgroup.events file of the node
+func (n *BaseNode) cgroupEvent(key string) (bool, error) {
+	fn := filepath.Join(n.CgroupPath(), "cgroup.events")
+	buf, err := os.ReadFile(fn)
+	if err != nil {
+		return false, fmt.Errorf("failed to read cgroup events: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		var k string
+		var v int
+		if _, err := fmt.Sscanf(scanner.Text(), "%s %d", &k, &v); err == nil && k == key {
+			return v != 0, nil
+		}
+	}
+
+	return false, fmt.Errorf("unknown cgroup event: %s", key)
+}
+
+// createCgroup creates the cgroup of the node below the cgroup v2 directory CgroupParent
+func (n *BaseNode) createCgroup() error {
+	if _, err := os.Stat(filepath.Join(n.CgroupParent, "cgroup.procs")); err != nil {
+		return fmt.Errorf("%s is not a cgroup v2 directory: %w", n.CgroupParent, err)
+	}
+
+	if err := os.Mkdir(n.CgroupPath(), 0755); err != nil {
+		return fmt.Errorf("failed to create cgroup: %w", err)
+	}
+
+	// The freezer is a core feature of cgroup v2 since Linux 5.2
+	if _, err := os.Stat(filepath.Join(n.CgroupPath(), "cgroup.freeze")); err != nil {
+		return multierr.Append(
+			fmt.Errorf("kernel lacks support for the cgroup freezer: %w", err),
+			os.Remove(n.CgroupPath()))
+	}
+
+	n.logger.Info("Created cgroup", zap.String("path", n.CgroupPath()))
+
+	return nil
+}
+
+// removeCgroup kills the remaining processes of the node and removes its cgroup
+func (n *BaseNode) removeCgroup() error {
+	if n.CgroupParent == "" {
+		return nil
+	}
+
+	if populated, err := n.cgroupEvent("populated"); err != nil {
+		return err
+	} else if populated {
+		// Frozen processes are killed as well
+		if err := os.WriteFile(filepath.Join(n.CgroupPath(), "cgroup.kill"), []byte("1"), 0); err != nil {
+			return fmt.Errorf("failed to kill processes: %w", err)
+		}
+
+		for deadline := time.Now().Add(time.Second); populated; {
+			if time.Now().After(deadline) {
+				return errors.New("failed to kill processes of cgroup")
+			}
+
+			time.Sleep(10 * time.Millisecond)
+
+			if populated, err = n.cgroupEvent("populated"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.Remove(n.CgroupPath()); err != nil {
+		return fmt.Errorf("failed to remove cgroup: %w", err)
+	}
+
+	return nil
+}
+
+// enterCgroup moves the calling process into the cgroup passed in its environment
+func enterCgroup() error {
+	path := os.Getenv(cgroupEnv)
+	if path == "" {
+		return nil
+	}
+
+	if err := os.Unsetenv(cgroupEnv); err != nil {
+		return err
+	}
+
+	// Writing 0 moves the writing process
+	if err := os.WriteFile(filepath.Join(path, "cgroup.procs"), []byte("0"), 0); err != nil {
+		return fmt.Errorf("failed to enter cgroup: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package gont_test
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+// cgroup2Mount returns the mount point of the cgroup v2 hierarchy
+func cgroup2Mount(t *testing.T) string {
+	f, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		t.Fatalf("Failed to open mounts: %s", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 2 && fields[2] == "cgroup2" {
+			return fields[1]
+		}
+	}
+
+	t.Skip("No cgroup v2 hierarchy mounted")
+
+	return ""
+}
+
+func TestFreeze(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		n1, n2 *g.BaseNode
+	)
+
+	parent := cgroup2Mount(t)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if n1, err = n.AddNode("n1", o.WithCgroup(parent)); err != nil {
+		t.Fatalf("Failed to create node: %s", err)
+	}
+
+	if n2, err = n.AddNode("n2"); err != nil {
+		t.Fatalf("Failed to create node: %s", err)
+	}
+
+	if err := n2.Freeze(); err == nil {
+		t.Error("Froze node without cgroup")
+	}
+
+	counter := filepath.Join(t.TempDir(), "counter")
+
+	read := func() int {
+		buf, err := os.ReadFile(counter)
+		if err != nil {
+			t.Fatalf("Failed to read counter: %s", err)
+		}
+
+		i, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+		if err != nil {
+			t.Fatalf("Failed to parse counter: %s", err)
+		}
+
+		return i
+	}
+
+	_, _, c, err := n1.Start("sh", "-c", `i=0; while true; do i=$((i+1)); echo $i > $0.tmp; mv $0.tmp $0; sleep 0.01; done`, counter)
+	if err != nil {
+		t.Fatalf("Failed to start counter: %s", err)
+	}
+	defer c.Process.Kill()
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := n1.Freeze(); err != nil {
+		t.Fatalf("Failed to freeze node: %s", err)
+	}
+
+	if frozen, err := n1.Frozen(); err != nil || !frozen {
+		t.Errorf("Node is not frozen: %v", err)
+	}
+
+	before := read()
+	time.Sleep(100 * time.Millisecond)
+
+	if after := read(); after != before {
+		t.Errorf("Counter progressed while frozen: %d != %d", after, before)
+	}
+
+	if err := n1.Thaw(); err != nil {
+		t.Fatalf("Failed to thaw node: %s", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if after := read(); after <= before {
+		t.Errorf("Counter did not progress after thawing: %d <= %d", after, before)
+	}
+
+	// Remaining processes are killed during teardown even if frozen
+	if err := n1.Freeze(); err != nil {
+		t.Fatalf("Failed to freeze node: %s", err)
+	}
+
+	path := n1.CgroupPath()
+
+	if err := n.Close(); err != nil {
+		t.Fatalf("Failed to close network: %s", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Cgroup has not been removed: %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package gont
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	nl "github.com/vishvananda/netlink"
+	nlraw "github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// AddFwmarkFilter attaches a fw classifier to the qdisc or class parent of the
+// interface iface which steers all packets carrying the firewall mark into the
+// class classID. Marks can be set with Host.AddMangleMark.
+func (n *BaseNode) AddFwmarkFilter(iface string, parent, mark, classID uint32) error {
+	link, err := n.nlHandle.LinkByName(iface)
+	if err != nil {
+		return fmt.Errorf("failed to find interface %s: %w", iface, err)
+	}
+
+	n.logger.Info("Adding fwmark filter",
+		zap.String("intf", iface),
+		zap.String("parent", nl.HandleStr(parent)),
+		zap.Uint32("mark", mark),
+		zap.String("class", nl.HandleStr(classID)))
+
+	if err := n.nlHandle.FilterAdd(&nl.FwFilter{
+		FilterAttrs: nl.FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    parent,
+			Handle:    mark,
+			Protocol:  unix.ETH_P_ALL,
+			Priority:  1,
+		},
+		ClassId: classID,
+	}); err != nil {
+		return fmt.Errorf("failed to add fwmark filter: %w", err)
+	}
+
+	return nil
+}
+
+// TCFilter is a traffic control classifier which can be attached to an
+// interface by Interface.AddFilter(). See U32Filter and FlowerFilter.
+type TCFilter interface {
+	filterAttrs() *TCFilterAttrs
+	add(i *Interface, link nl.Link) error
+}
+
+// TCFilterAttrs are common to all filter types
+type TCFilterAttrs struct {
+	// Parent is the handle of the qdisc or class to which the filter is attached
+	Parent   uint32
+	Priority uint16
+
+	// ClassID is the class into which matching packets are steered.
+	// A value of zero only executes the Actions.
+	ClassID uint32
+
+	// Actions are executed for matching packets
+	Actions []nl.Action
+}
+
+func (f *TCFilterAttrs) filterAttrs() *TCFilterAttrs {
+	return f
+}
+
+func (f *TCFilterAttrs) netlinkAttrs(link nl.Link, proto uint16) nl.FilterAttrs {
+	prio := f.Priority
+	if prio == 0 {
+		prio = 1
+	}
+
+	return nl.FilterAttrs{
+		LinkIndex: link.Attrs().Index,
+		Parent:    f.Parent,
+		Protocol:  proto,
+		Priority:  prio,
+	}
+}
+
+// U32Key matches the 32 bit word at Offset of the packet against Value.
+// Offsets are relative to the network header.
+// Only bits which are set in Mask are compared.
+type U32Key struct {
+	Offset int32
+	Value  uint32
+	Mask   uint32
+}
+
+// U32Filter matches packets by comparing words at fixed offsets
+type U32Filter struct {
+	TCFilterAttrs
+
+	// Protocol is the EtherType of the matched packets.
+	// By default, packets of all protocols are matched.
+	Protocol uint16
+
+	// All keys must match. Without keys, all packets are matched.
+	Keys []U32Key
+}
+
+func (f *U32Filter) add(i *Interface, link nl.Link) error {
+	proto := f.Protocol
+	if proto == 0 {
+		proto = unix.ETH_P_ALL
+	}
+
+	var sel *nl.TcU32Sel
+	if len(f.Keys) > 0 {
+		sel = &nl.TcU32Sel{
+			Flags: nl.TC_U32_TERMINAL,
+		}
+
+		for _, k := range f.Keys {
+			sel.Keys = append(sel.Keys, nl.TcU32Key{
+				Off:  k.Offset,
+				Val:  k.Value & k.Mask,
+				Mask: k.Mask,
+			})
+		}
+	}
+
+	return i.Node.NetlinkHandle().FilterAdd(&nl.U32{
+		FilterAttrs: f.netlinkAttrs(link, proto),
+		ClassId:     f.ClassID,
+		Sel:         sel,
+		Actions:     f.Actions,
+	})
+}
+
+// FlowerFilter matches packets by their IP addresses, protocol and ports.
+// Without any keys, packets of all protocols are matched.
+type FlowerFilter struct {
+	TCFilterAttrs
+
+	// Protocol is the EtherType of the matched packets, either
+	// unix.ETH_P_IP or unix.ETH_P_IPV6. By default, it is derived
+	// from the addresses. Without addresses, it is required for matching
+	// an IP protocol as there is no single EtherType covering both families.
+	Protocol uint16
+
+	// IPProto is the IP protocol number like unix.IPPROTO_TCP.
+	// It is required for matching ports.
+	IPProto uint8
+
+	Source      *net.IPNet
+	Destination *net.IPNet
+
+	SourcePort      uint16
+	DestinationPort uint16
+}
+
+func (f *FlowerFilter) add(i *Interface, link nl.Link) error {
+	proto := f.Protocol
+	for _, ipnet := range []*net.IPNet{f.Source, f.Destination} {
+		if ipnet == nil {
+			continue
+		}
+
+		var family uint16 = unix.ETH_P_IP
+		if ipnet.IP.To4() == nil {
+			family = unix.ETH_P_IPV6
+		}
+
+		if proto == 0 {
+			proto = family
+		} else if proto != family {
+			return errors.New("mismatching address families")
+		}
+	}
+
+	switch {
+	case proto == unix.ETH_P_IP, proto == unix.ETH_P_IPV6:
+	case proto != 0:
+		return fmt.Errorf("unsupported EtherType: %#04x", proto)
+	case f.IPProto != 0:
+		return errors.New("matching an IP protocol requires addresses or an EtherType")
+	default:
+		proto = unix.ETH_P_ALL
+	}
+
+	var srcPortType, dstPortType int
+	switch f.IPProto {
+	case unix.IPPROTO_TCP:
+		srcPortType, dstPortType = nlraw.TCA_FLOWER_KEY_TCP_SRC, nlraw.TCA_FLOWER_KEY_TCP_DST
+	case unix.IPPROTO_UDP:
+		srcPortType, dstPortType = nlraw.TCA_FLOWER_KEY_UDP_SRC, nlraw.TCA_FLOWER_KEY_UDP_DST
+	default:
+		if f.SourcePort != 0 || f.DestinationPort != 0 {
+			return errors.New("matching ports requires TCP or UDP as protocol")
+		}
+	}
+
+	// The netlink library lacks support for matching protocols and ports.
+	// Hence we construct the request ourself.
+	attrs := f.netlinkAttrs(link, proto)
+
+	req := nlraw.NewNetlinkRequest(unix.RTM_NEWTFILTER, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	req.AddData(&nlraw.TcMsg{
+		Family:  nlraw.FAMILY_ALL,
+		Ifindex: int32(attrs.LinkIndex),
+		Parent:  attrs.Parent,
+		Info:    nl.MakeHandle(attrs.Priority, nlraw.Swap16(attrs.Protocol)),
+	})
+	req.AddData(nlraw.NewRtAttr(nlraw.TCA_KIND, nlraw.ZeroTerminated("flower")))
+
+	opts := nlraw.NewRtAttr(nlraw.TCA_OPTIONS, nil)
+	if proto != unix.ETH_P_ALL {
+		opts.AddRtAttr(nlraw.TCA_FLOWER_KEY_ETH_TYPE, be16(proto))
+	}
+
+	if f.ClassID != 0 {
+		opts.AddRtAttr(nlraw.TCA_FLOWER_CLASSID, nlraw.Uint32Attr(f.ClassID))
+	}
+
+	if f.IPProto != 0 {
+		opts.AddRtAttr(nlraw.TCA_FLOWER_KEY_IP_PROTO, nlraw.Uint8Attr(f.IPProto))
+	}
+
+	addIP := func(ipnet *net.IPNet, v4, v4Mask, v6, v6Mask int) {
+		if ip := ipnet.IP.To4(); ip != nil {
+			opts.AddRtAttr(v4, ip)
+			opts.AddRtAttr(v4Mask, net.IP(ipnet.Mask).To4())
+		} else {
+			opts.AddRtAttr(v6, ipnet.IP.To16())
+			opts.AddRtAttr(v6Mask, ipnet.Mask)
+		}
+	}
+
+	if f.Source != nil {
+		addIP(f.Source, nlraw.TCA_FLOWER_KEY_IPV4_SRC, nlraw.TCA_FLOWER_KEY_IPV4_SRC_MASK, nlraw.TCA_FLOWER_KEY_IPV6_SRC, nlraw.TCA_FLOWER_KEY_IPV6_SRC_MASK)
+	}
+
+	if f.Destination != nil {
+		addIP(f.Destination, nlraw.TCA_FLOWER_KEY_IPV4_DST, nlraw.TCA_FLOWER_KEY_IPV4_DST_MASK, nlraw.TCA_FLOWER_KEY_IPV6_DST, nlraw.TCA_FLOWER_KEY_IPV6_DST_MASK)
+	}
+
+	if f.SourcePort != 0 {
+		opts.AddRtAttr(srcPortType, be16(f.SourcePort))
+	}
+
+	if f.DestinationPort != 0 {
+		opts.AddRtAttr(dstPortType, be16(f.DestinationPort))
+	}
+
+	if len(f.Actions) > 0 {
+		if err := nl.EncodeActions(opts.AddRtAttr(nlraw.TCA_FLOWER_ACT, nil), f.Actions); err != nil {
+			return err
+		}
+	}
+
+	req.AddData(opts)
+
+	s, err := nlraw.GetNetlinkSocketAt(i.Node.NetNSHandle(), netns.None(), unix.NETLINK_ROUTE)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	req.Sockets = map[int]*nlraw.SocketHandle{
+		unix.NETLINK_ROUTE: {Socket: s},
+	}
+
+	_, err = req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+// AddFilter attaches a filter to the qdisc or class of the interface
+// given by the Parent of the filter.
+//
+// The parent and the class referenced by the filter must already exist.
+func (i *Interface) AddFilter(f TCFilter) error {
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	tree, err := i.QdiscTree()
+	if err != nil {
+		return err
+	}
+
+	attrs := f.filterAttrs()
+	if attrs.ClassID == 0 && len(attrs.Actions) == 0 {
+		return errors.New("filter has neither a class nor actions")
+	}
+
+	if tree.Find(attrs.Parent) == nil {
+		return fmt.Errorf("parent %s does not exist", nl.HandleStr(attrs.Parent))
+	}
+
+	if attrs.ClassID != 0 {
+		if c := tree.Find(attrs.ClassID); c == nil || !c.IsClass() {
+			return fmt.Errorf("class %s does not exist", nl.HandleStr(attrs.ClassID))
+		}
+	}
+
+	if err := f.add(i, link); err != nil {
+		return fmt.Errorf("failed to add filter: %w", err)
+	}
+
+	return nil
+}
+
+func be16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
@@ -0,0 +1,115 @@
+package gont_test
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+// TestCongestionControl selects the congestion control algorithm
+// per node and per socket
+//
+//  h1 <-> h2
+func TestCongestionControl(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if err := h1.SetCongestionControl("does-not-exist"); err == nil {
+		t.Error("Set unknown congestion control algorithm")
+	}
+
+	available, err := os.ReadFile("/proc/sys/net/ipv4/tcp_available_congestion_control")
+	if err != nil {
+		t.Fatalf("Failed to read available algorithms: %s", err)
+	}
+
+	if !strings.Contains(string(available), "bbr") {
+		t.Skip("BBR congestion control is not available")
+	}
+
+	if err := h1.SetCongestionControl("bbr"); err != nil {
+		t.Fatalf("Failed to set congestion control: %s", err)
+	}
+
+	var algo []byte
+	if err := h1.RunFunc(func() (err error) {
+		algo, err = os.ReadFile("/proc/sys/net/ipv4/tcp_congestion_control")
+		return
+	}); err != nil {
+		t.Fatalf("Failed to read sysctl: %s", err)
+	}
+
+	if strings.TrimSpace(string(algo)) != "bbr" {
+		t.Errorf("Unexpected congestion control algorithm: %s", algo)
+	}
+
+	var l net.Listener
+	if err := h2.RunFunc(func() (err error) {
+		l, err = net.Listen("tcp", "10.0.0.2:8080")
+		return
+	}); err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer l.Close()
+
+	for _, expected := range []string{"bbr", "reno"} {
+		var dopts []g.Option
+		if expected != "bbr" {
+			dopts = append(dopts, o.CongestionControl(expected))
+		}
+
+		c, err := h1.Dial("tcp", "10.0.0.2:8080", dopts...)
+		if err != nil {
+			t.Fatalf("Failed to connect: %s", err)
+		}
+
+		rc, err := c.(*net.TCPConn).SyscallConn()
+		if err != nil {
+			t.Fatalf("Failed to get raw connection: %s", err)
+		}
+
+		var cc string
+		var serr error
+		if err := rc.Control(func(fd uintptr) {
+			cc, serr = unix.GetsockoptString(int(fd), unix.IPPROTO_TCP, unix.TCP_CONGESTION)
+		}); err != nil || serr != nil {
+			t.Fatalf("Failed to get socket option: %v %v", err, serr)
+		}
+
+		if cc = strings.TrimRight(cc, "\x00"); cc != expected {
+			t.Errorf("Unexpected socket congestion control algorithm: %s != %s", cc, expected)
+		}
+
+		c.Close()
+	}
+}
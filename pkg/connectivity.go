@@ -0,0 +1,73 @@
+package gont
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-ping/ping"
+)
+
+// Pair identifies the source and destination hosts of a ping
+type Pair struct {
+	Source      string
+	Destination string
+}
+
+// PingStats are the results of a ping between a pair of hosts
+type PingStats struct {
+	*ping.Statistics
+
+	Err error
+}
+
+// ConnectivityMatrix concurrently pings between every pair of the given hosts
+// using the first address of the destination host.
+//
+// If no hosts are given, all hosts and routers of the network are checked.
+// A pair has connectivity if the error of its PingStats is nil.
+//
+// If the context is canceled, the pings are stopped and the partial matrix
+// is returned together with the error of the context. Pairs whose ping has
+// been interrupted carry the error of the context as well.
+func (n *Network) ConnectivityMatrix(ctx context.Context, hosts ...*Host) (map[Pair]PingStats, error) {
+	if len(hosts) == 0 {
+		n.NodesLock.RLock()
+		hosts = n.Hosts()
+		for _, r := range n.Routers() {
+			hosts = append(hosts, r.Host)
+		}
+		n.NodesLock.RUnlock()
+	}
+
+	results := map[Pair]PingStats{}
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for _, a := range hosts {
+		for _, b := range hosts {
+			if a == b {
+				continue
+			}
+
+			wg.Add(1)
+			go func(a, b *Host) {
+				defer wg.Done()
+
+				stats, err := a.PingContext(ctx, b, "ip", 1, 2*time.Second, time.Second, false)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				results[Pair{a.Name(), b.Name()}] = PingStats{
+					Statistics: stats,
+					Err:        err,
+				}
+			}(a, b)
+		}
+	}
+
+	wg.Wait()
+
+	return results, ctx.Err()
+}
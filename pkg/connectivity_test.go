@@ -0,0 +1,163 @@
+package gont_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+// TestConnectivityMatrix checks the reachability between
+// all hosts attached to a switch
+//
+//  h1 <-> sw1 <-> h2
+//          ^
+//          |
+//          v
+//          h3
+func TestConnectivityMatrix(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		sw1 *g.Switch
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if sw1, err = n.AddSwitch("sw1"); err != nil {
+		t.Fatalf("Failed to add switch: %s", err)
+	}
+
+	hosts := []*g.Host{}
+	for i := 1; i <= 3; i++ {
+		h, err := n.AddHost(fmt.Sprintf("h%d", i),
+			o.Interface("veth0", sw1,
+				o.AddressIPv4(10, 0, 0, byte(i), 24)),
+		)
+		if err != nil {
+			t.Fatalf("Failed to add host: %s", err)
+		}
+
+		hosts = append(hosts, h)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	results, err := n.ConnectivityMatrix(ctx)
+	if err != nil {
+		t.Fatalf("Failed to check connectivity: %s", err)
+	}
+
+	if len(results) != 6 {
+		t.Fatalf("Expected 6 pairs, got %d", len(results))
+	}
+
+	for p, s := range results {
+		if s.Err != nil || s.PacketLoss != 0 {
+			t.Errorf("Pair %s -> %s has no connectivity: %v", p.Source, p.Destination, s.Err)
+		}
+	}
+
+	// Take down the link of h3
+	h3 := hosts[2]
+	if err := h3.NetlinkHandle().LinkSetDown(h3.Interface("veth0").Link); err != nil {
+		t.Fatalf("Failed to set link down: %s", err)
+	}
+
+	if results, err = n.ConnectivityMatrix(ctx); err != nil {
+		t.Fatalf("Failed to check connectivity: %s", err)
+	}
+
+	for p, s := range results {
+		affected := p.Source == "h3" || p.Destination == "h3"
+		if affected && s.Err == nil {
+			t.Errorf("Pair %s -> %s has unexpected connectivity", p.Source, p.Destination)
+		} else if !affected && s.Err != nil {
+			t.Errorf("Pair %s -> %s has no connectivity: %s", p.Source, p.Destination, s.Err)
+		}
+	}
+
+	// Restrict the check to a subset of the hosts
+	if results, err = n.ConnectivityMatrix(ctx, hosts[0], hosts[1]); err != nil {
+		t.Fatalf("Failed to check connectivity: %s", err)
+	}
+
+	if len(results) != 2 {
+		t.Errorf("Expected 2 pairs, got %d", len(results))
+	}
+}
+
+// TestConnectivityMatrixRouter checks that routers are included
+// and that canceled checks return the partial matrix
+//
+//  h1 <-> sw1 <-> r1
+func TestConnectivityMatrixRouter(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		sw1 *g.Switch
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if sw1, err = n.AddSwitch("sw1"); err != nil {
+		t.Fatalf("Failed to add switch: %s", err)
+	}
+
+	if _, err := n.AddHost("h1",
+		o.Interface("veth0", sw1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+	); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if _, err := n.AddRouter("r1",
+		o.Interface("veth0", sw1,
+			o.AddressIPv4(10, 0, 0, 254, 24)),
+	); err != nil {
+		t.Fatalf("Failed to add router: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	results, err := n.ConnectivityMatrix(ctx)
+	if err != nil {
+		t.Fatalf("Failed to check connectivity: %s", err)
+	}
+
+	for _, p := range []g.Pair{{"h1", "r1"}, {"r1", "h1"}} {
+		if s, ok := results[p]; !ok {
+			t.Errorf("Pair %s -> %s is missing", p.Source, p.Destination)
+		} else if s.Err != nil {
+			t.Errorf("Pair %s -> %s has no connectivity: %s", p.Source, p.Destination, s.Err)
+		}
+	}
+
+	cancel()
+
+	if results, err = n.ConnectivityMatrix(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected cancellation, got: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 pairs, got %d", len(results))
+	}
+
+	for p, s := range results {
+		if !errors.Is(s.Err, context.Canceled) {
+			t.Errorf("Pair %s -> %s has not been canceled: %v", p.Source, p.Destination, s.Err)
+		}
+	}
+}
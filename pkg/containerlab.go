@@ -0,0 +1,152 @@
+package gont
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// ContainerlabTopology is the subset of a containerlab topology
+// definition which is supported by Network.LoadContainerlab().
+//
+// See: https://containerlab.dev/manual/topo-def-file/
+type ContainerlabTopology struct {
+	Name     string `yaml:"name"`
+	Topology struct {
+		Nodes map[string]ContainerlabNode `yaml:"nodes"`
+		Links []ContainerlabLink          `yaml:"links"`
+	} `yaml:"topology"`
+}
+
+type ContainerlabNode struct {
+	Kind string `yaml:"kind"`
+
+	// Exec contains commands which are executed in the node
+	// after all links have been established.
+	Exec []string `yaml:"exec"`
+}
+
+type ContainerlabLink struct {
+	// Endpoints are pairs of "node:interface" strings
+	Endpoints []string `yaml:"endpoints"`
+}
+
+// LoadContainerlab adds the nodes and links of a containerlab topology
+// definition file to the network.
+//
+// Nodes of kind "linux" are added as hosts and nodes of kind "bridge" as switches.
+// Nodes of other kinds are skipped with a warning together with their links.
+// Addresses are usually assigned by "ip addr add" commands in the exec section
+// of the nodes which are run after all links have been established.
+func (n *Network) LoadContainerlab(fn string) error {
+	buf, err := os.ReadFile(fn)
+	if err != nil {
+		return fmt.Errorf("failed to read topology: %w", err)
+	}
+
+	var topo ContainerlabTopology
+	if err := yaml.Unmarshal(buf, &topo); err != nil {
+		return fmt.Errorf("failed to parse topology: %w", err)
+	}
+
+	return n.AddContainerlabTopology(&topo)
+}
+
+// AddContainerlabTopology adds the nodes and links of a parsed
+// containerlab topology definition to the network.
+func (n *Network) AddContainerlabTopology(topo *ContainerlabTopology) error {
+	// Sort names for a reproducible order of nodes
+	names := []string{}
+	for name := range topo.Topology.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Nodes are used as link endpoints and base nodes for executing commands
+	nodes := map[string]Node{}
+	bases := map[string]*BaseNode{}
+
+	for _, name := range names {
+		node := topo.Topology.Nodes[name]
+
+		switch node.Kind {
+		case "linux":
+			h, err := n.AddHost(name)
+			if err != nil {
+				return fmt.Errorf("failed to add host %s: %w", name, err)
+			}
+
+			nodes[name] = h
+			bases[name] = h.BaseNode
+
+		case "bridge":
+			sw, err := n.AddSwitch(name)
+			if err != nil {
+				return fmt.Errorf("failed to add switch %s: %w", name, err)
+			}
+
+			nodes[name] = sw
+			bases[name] = sw.BaseNode
+
+		default:
+			n.logger.Warn("Skipping node of unsupported kind",
+				zap.String("node", name),
+				zap.String("kind", node.Kind))
+		}
+	}
+
+	for _, link := range topo.Topology.Links {
+		if len(link.Endpoints) != 2 {
+			return fmt.Errorf("link must have exactly two endpoints: %v", link.Endpoints)
+		}
+
+		intfs := []*Interface{}
+
+		for _, ep := range link.Endpoints {
+			name, intf, ok := strings.Cut(ep, ":")
+			if !ok {
+				return fmt.Errorf("invalid endpoint: %s", ep)
+			}
+
+			if _, ok := topo.Topology.Nodes[name]; !ok {
+				return fmt.Errorf("endpoint %s refers to unknown node %s", ep, name)
+			}
+
+			if node, ok := nodes[name]; ok {
+				intfs = append(intfs, &Interface{
+					Name: intf,
+					Node: node,
+				})
+			}
+		}
+
+		if len(intfs) != 2 {
+			n.logger.Warn("Skipping link to node of unsupported kind",
+				zap.Strings("endpoints", link.Endpoints))
+			continue
+		}
+
+		if err := n.AddLink(intfs[0], intfs[1]); err != nil {
+			return fmt.Errorf("failed to add link %s: %w", strings.Join(link.Endpoints, " <-> "), err)
+		}
+	}
+
+	for _, name := range names {
+		node, ok := bases[name]
+		if !ok {
+			continue
+		}
+
+		for _, cmd := range topo.Topology.Nodes[name].Exec {
+			if out, _, err := node.Run("sh", "-c", cmd); err != nil {
+				return fmt.Errorf("failed to execute '%s' in node %s: %w\n%s", cmd, name, err, out)
+			}
+		}
+	}
+
+	return nil
+}
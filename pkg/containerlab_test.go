@@ -0,0 +1,111 @@
+package gont_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const clabTopology = `
+name: clab-test
+topology:
+  nodes:
+    h1:
+      kind: linux
+      exec:
+        - ip addr add 10.0.0.1/24 dev eth1
+    h2:
+      kind: linux
+      exec:
+        - ip addr add 10.0.0.2/24 dev eth1
+    sw1:
+      kind: bridge
+    r1:
+      kind: ceos
+  links:
+    - endpoints: ["h1:eth1", "sw1:eth1"]
+    - endpoints: ["h2:eth1", "sw1:eth2"]
+    - endpoints: ["h1:eth2", "r1:eth1"]
+`
+
+func TestLoadContainerlab(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "lab.clab.yml")
+	if err := os.WriteFile(fn, []byte(clabTopology), 0644); err != nil {
+		t.Fatalf("Failed to write topology: %s", err)
+	}
+
+	core, logs := observer.New(zap.WarnLevel)
+
+	n, err := g.NewNetwork(*nname, append(opts, o.WithLogger(zap.New(core)))...)
+	if err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if err := n.LoadContainerlab(fn); err != nil {
+		t.Fatalf("Failed to load topology: %s", err)
+	}
+
+	h1, ok := n.Nodes["h1"].(*g.Host)
+	if !ok {
+		t.Fatalf("Node h1 is not a host: %T", n.Nodes["h1"])
+	}
+
+	sw1, ok := n.Nodes["sw1"].(*g.Switch)
+	if !ok {
+		t.Fatalf("Node sw1 is not a switch: %T", n.Nodes["sw1"])
+	}
+
+	if _, ok := n.Nodes["r1"]; ok {
+		t.Error("Node of unsupported kind has been added")
+	}
+
+	for _, name := range []string{"eth1", "eth2"} {
+		if sw1.Interface(name) == nil {
+			t.Errorf("Switch is missing interface %s", name)
+		}
+	}
+
+	if h1.Interface("eth2") != nil {
+		t.Error("Link to node of unsupported kind has been added")
+	}
+
+	if logs.FilterField(zap.String("kind", "ceos")).Len() != 1 {
+		t.Error("Unsupported kind has not been warned about")
+	}
+
+	if logs.FilterMessage("Skipping link to node of unsupported kind").Len() != 1 {
+		t.Error("Skipped link has not been warned about")
+	}
+
+	// Addresses are assigned by the exec commands
+	if err := h1.RunFunc(func() error {
+		intf, err := net.InterfaceByName("eth1")
+		if err != nil {
+			return err
+		}
+
+		addrs, err := intf.Addrs()
+		if err != nil {
+			return err
+		}
+
+		for _, addr := range addrs {
+			if addr.String() == "10.0.0.1/24" {
+				return nil
+			}
+		}
+
+		t.Errorf("Address has not been assigned: %v", addrs)
+
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to get addresses: %s", err)
+	}
+}
@@ -0,0 +1,125 @@
+package gont
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	oneWayDelayInterval = 10 * time.Millisecond
+	oneWayDelayTimeout  = time.Second
+)
+
+// OneWayDelay sends count timestamped UDP datagrams from src to the first
+// address of dst and returns the one-way delays of the received datagrams
+// in the order in which they have been sent. Lost datagrams are omitted.
+//
+// The datagrams are sent in intervals of 10ms. After the last datagram,
+// OneWayDelay waits up to one second for outstanding datagrams.
+//
+// The delay is calculated by subtracting the timestamp of the sender from
+// the arrival time at the receiver. This requires synchronized clocks.
+// As both ends are handled by the calling process, they share a single clock.
+// Time namespaces configured by WithTimeOffset() are not considered as they
+// only shift the monotonic and boot-time clocks of processes started in a node.
+func (n *Network) OneWayDelay(src, dst *Host, count int) ([]time.Duration, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid number of datagrams: %d", count)
+	}
+
+	if src.network != n || dst.network != n {
+		return nil, errors.New("hosts must be on same network")
+	}
+
+	ip := dst.LookupAddress("ip")
+	if ip == nil {
+		return nil, errors.New("failed to find address")
+	}
+
+	var rconn, sconn *net.UDPConn
+	if err := dst.RunFunc(func() (err error) {
+		rconn, err = net.ListenUDP("udp", &net.UDPAddr{IP: ip.IP})
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+	defer rconn.Close()
+
+	if err := src.RunFunc(func() (err error) {
+		sconn, err = net.DialUDP("udp", nil, rconn.LocalAddr().(*net.UDPAddr))
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to dial: %w", err)
+	}
+	defer sconn.Close()
+
+	delays := make([]time.Duration, count)
+	received := make([]bool, count)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		buf := make([]byte, 12)
+		for left := count; left > 0; {
+			if n, err := rconn.Read(buf); err != nil {
+				return
+			} else if n != len(buf) {
+				continue
+			}
+
+			arrival := time.Now()
+
+			seq := binary.BigEndian.Uint32(buf[0:])
+			ts := int64(binary.BigEndian.Uint64(buf[4:]))
+
+			if seq >= uint32(count) || received[seq] {
+				continue
+			}
+
+			delays[seq] = arrival.Sub(time.Unix(0, ts))
+			received[seq] = true
+			left--
+		}
+	}()
+
+	buf := make([]byte, 12)
+	for seq := 0; seq < count; seq++ {
+		if seq > 0 {
+			time.Sleep(oneWayDelayInterval)
+		}
+
+		binary.BigEndian.PutUint32(buf[0:], uint32(seq))
+		binary.BigEndian.PutUint64(buf[4:], uint64(time.Now().UnixNano()))
+
+		if _, err := sconn.Write(buf); err != nil {
+			rconn.Close()
+			wg.Wait()
+			return nil, fmt.Errorf("failed to send: %w", err)
+		}
+	}
+
+	if err := rconn.SetReadDeadline(time.Now().Add(oneWayDelayTimeout)); err != nil {
+		return nil, err
+	}
+
+	wg.Wait()
+
+	results := []time.Duration{}
+	for seq, d := range delays {
+		if received[seq] {
+			results = append(results, d)
+		}
+	}
+
+	if len(results) == 0 && count > 0 {
+		return nil, errors.New("no datagrams received")
+	}
+
+	return results, nil
+}
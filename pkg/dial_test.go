@@ -0,0 +1,254 @@
+package gont_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers A queries for the given records
+// and responds with NXDOMAIN for all other names
+func serveDNS(conn net.PacketConn, records map[string]net.IP) {
+	buf := make([]byte, 512)
+
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		var req dnsmessage.Message
+		if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) == 0 {
+			continue
+		}
+
+		q := req.Questions[0]
+		resp := dnsmessage.Message{
+			Header: dnsmessage.Header{
+				ID:            req.ID,
+				Response:      true,
+				Authoritative: true,
+			},
+			Questions: req.Questions,
+		}
+
+		if ip, ok := records[q.Name.String()]; !ok {
+			resp.RCode = dnsmessage.RCodeNameError
+		} else if q.Type == dnsmessage.TypeA {
+			var a [4]byte
+			copy(a[:], ip.To4())
+
+			resp.Answers = []dnsmessage.Resource{
+				{
+					Header: dnsmessage.ResourceHeader{
+						Name:  q.Name,
+						Type:  dnsmessage.TypeA,
+						Class: dnsmessage.ClassINET,
+						TTL:   60,
+					},
+					Body: &dnsmessage.AResource{A: a},
+				},
+			}
+		}
+
+		out, err := resp.Pack()
+		if err != nil {
+			continue
+		}
+
+		conn.WriteTo(out, addr)
+	}
+}
+
+func TestDialResolver(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, append(opts,
+		o.Nameserver(net.IPv4(10, 0, 0, 2)),
+	)...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	// Start DNS and TCP servers in h2
+	var dnsConn net.PacketConn
+	var lstn net.Listener
+	if err := h2.RunFunc(func() (err error) {
+		if dnsConn, err = net.ListenPacket("udp", "10.0.0.2:53"); err != nil {
+			return err
+		}
+
+		lstn, err = net.Listen("tcp", "10.0.0.2:8080")
+		return err
+	}); err != nil {
+		t.Fatalf("Failed to start servers: %s", err)
+	}
+	defer dnsConn.Close()
+	defer lstn.Close()
+
+	go serveDNS(dnsConn, map[string]net.IP{
+		"server.gont.": net.IPv4(10, 0, 0, 2),
+	})
+
+	go func() {
+		for {
+			conn, err := lstn.Accept()
+			if err != nil {
+				return
+			}
+
+			io.WriteString(conn, "hello")
+			conn.Close()
+		}
+	}()
+
+	out, _, err := h1.Run("cat", "/etc/resolv.conf")
+	if err != nil {
+		t.Fatalf("Failed to read resolv.conf: %s", err)
+	}
+
+	if !strings.Contains(string(out), "nameserver 10.0.0.2") {
+		t.Errorf("Invalid resolv.conf: %s", out)
+	}
+
+	addrs, err := h1.Resolver().LookupHost(context.Background(), "server.gont")
+	if err != nil {
+		t.Fatalf("Failed to lookup host: %s", err)
+	}
+
+	if len(addrs) != 1 || addrs[0] != "10.0.0.2" {
+		t.Errorf("Invalid lookup result: %v", addrs)
+	}
+
+	conn, err := h1.Dial("tcp", "server.gont:8080")
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer conn.Close()
+
+	buf, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("Failed to read: %s", err)
+	}
+
+	if string(buf) != "hello" {
+		t.Errorf("Received invalid response: %s", buf)
+	}
+
+	if _, err := h1.Dial("tcp", "unknown.gont:8080"); err == nil {
+		t.Error("Dialing an unknown host did not fail")
+	}
+}
+
+func TestNSSwitch(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, append(opts,
+		o.Nameserver(net.IPv4(10, 0, 0, 2)),
+	)...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if _, err := n.AddHost("h0", o.WithNSSwitch([]string{"files dns"})); err == nil {
+		t.Error("Accepted invalid nsswitch source")
+	}
+
+	if h1, err = n.AddHost("h1", o.WithNSSwitch([]string{"files"})); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2", o.WithNSSwitch([]string{"dns"})); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	var dnsConn net.PacketConn
+	if err := h2.RunFunc(func() (err error) {
+		dnsConn, err = net.ListenPacket("udp", "10.0.0.2:53")
+		return err
+	}); err != nil {
+		t.Fatalf("Failed to start DNS server: %s", err)
+	}
+	defer dnsConn.Close()
+
+	// DNS answers differ from the hosts file
+	go serveDNS(dnsConn, map[string]net.IP{
+		"h1.":          net.IPv4(10, 0, 1, 1),
+		"server.gont.": net.IPv4(10, 0, 1, 2),
+	})
+
+	lookup := func(h *g.Host, name string) (string, error) {
+		out, _, err := h.Run("getent", "ahostsv4", name)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.Fields(string(out))[0], nil
+	}
+
+	out, _, err := h1.Run("cat", "/etc/nsswitch.conf")
+	if err != nil {
+		t.Fatalf("Failed to read nsswitch.conf: %s", err)
+	}
+
+	if !strings.Contains(string(out), "hosts: files\n") {
+		t.Errorf("Invalid nsswitch.conf: %s", out)
+	}
+
+	if addr, err := lookup(h1, "h2"); err != nil || addr != "10.0.0.2" {
+		t.Errorf("Failed to lookup host in hosts file: %s, %v", addr, err)
+	}
+
+	if addr, err := lookup(h1, "server.gont"); err == nil {
+		t.Errorf("Consulted DNS server despite files-only order: %s", addr)
+	}
+
+	if addr, err := lookup(h2, "h1"); err != nil || addr != "10.0.1.1" {
+		t.Errorf("Failed to lookup host via DNS: %s, %v", addr, err)
+	}
+
+	// Other nodes use the nsswitch.conf of the host
+	if out, _, err := n.HostNode.Run("cat", "/etc/nsswitch.conf"); err != nil || strings.Contains(string(out), "Gont") {
+		t.Errorf("Host node uses nsswitch.conf of nodes: %v", err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/stv0g/gont/internal/execvpe"
 	"github.com/stv0g/gont/internal/utils"
@@ -13,6 +15,11 @@ import (
 
 const (
 	gontNetworkSuffix = ".gont"
+
+	timeOffsetEnv = "GONT_TIME_OFFSET"
+	hostnameEnv   = "GONT_HOSTNAME"
+
+	seccompProfileEnv = "GONT_SECCOMP_PROFILE"
 )
 
 func init() {
@@ -20,7 +27,10 @@ func init() {
 	node := os.Getenv("GONT_NODE")
 	network := os.Getenv("GONT_NETWORK")
 
-	if unshare != "" {
+	if unshare == initUnshare {
+		runInit()
+		os.Exit(0)
+	} else if unshare != "" {
 		// Avoid recursion
 		if err := os.Unsetenv("GONT_UNSHARE"); err != nil {
 			panic(err)
@@ -43,19 +53,20 @@ func Exec(network, node string, args []string) error {
 		panic(err)
 	}
 
-	hostname := fmt.Sprintf("%s.%s%s", node, network, gontNetworkSuffix)
-	if err := syscall.Sethostname([]byte(hostname)); err != nil {
-		panic(err)
+	hostname := os.Getenv(hostnameEnv)
+	if hostname != "" {
+		if err := os.Unsetenv(hostnameEnv); err != nil {
+			return err
+		}
+	} else {
+		hostname = fmt.Sprintf("%s.%s%s", node, network, gontNetworkSuffix)
 	}
 
-	if err := syscall.Mount("none", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+	if err := syscall.Sethostname([]byte(hostname)); err != nil {
 		panic(err)
 	}
 
-	filesRootPath := filepath.Join(basePath, "files")
-
-	files, err := utils.FindFiles(filesRootPath)
-	if err != nil {
+	if err := syscall.Mount("none", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
 		panic(err)
 	}
 
@@ -64,11 +75,25 @@ func Exec(network, node string, args []string) error {
 	}
 
 	// Bind mount our files into the unshared rootfs
-	for _, path := range files {
-		src := filepath.Join(filesRootPath, path)
-		tgt := filepath.Join("/", path)
-		if err := syscall.Mount(src, tgt, "", syscall.MS_BIND, ""); err != nil {
-			return err
+	// Files of the node take precedence over those of the network
+	for _, filesRootPath := range []string{
+		filepath.Join(basePath, "files"),
+		filepath.Join(nodeDir, "files"),
+	} {
+		files, err := utils.FindFiles(filesRootPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			panic(err)
+		}
+
+		for _, path := range files {
+			src := filepath.Join(filesRootPath, path)
+			tgt := filepath.Join("/", path)
+			if err := syscall.Mount(src, tgt, "", syscall.MS_BIND, ""); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -83,6 +108,42 @@ func Exec(network, node string, args []string) error {
 		panic(err)
 	}
 
+	// Setup time namespace which gets entered by execve()
+	if offset := os.Getenv(timeOffsetEnv); offset != "" {
+		if err := os.Unsetenv(timeOffsetEnv); err != nil {
+			return err
+		}
+
+		d, err := strconv.ParseInt(offset, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid time offset: %w", err)
+		}
+
+		if err := unshareTime(time.Duration(d)); err != nil {
+			return err
+		}
+	}
+
+	if err := enterCgroup(); err != nil {
+		return err
+	}
+
+	if err := applyRlimits(); err != nil {
+		return err
+	}
+
+	// Install seccomp filter as late as possible as it also
+	// restricts the remaining system calls of this process
+	if profile := os.Getenv(seccompProfileEnv); profile != "" {
+		if err := os.Unsetenv(seccompProfileEnv); err != nil {
+			return err
+		}
+
+		if err := installSeccompProfile(profile); err != nil {
+			return err
+		}
+	}
+
 	// Run program
 	if err := execvpe.Execvpe(args[0], args, os.Environ()); err != nil {
 		panic(err)
@@ -90,3 +151,29 @@ func Exec(network, node string, args []string) error {
 
 	return nil
 }
+
+// unshareTime creates a new time namespace for the children of the calling
+// thread whose monotonic and boot-time clocks are shifted by offset.
+func unshareTime(offset time.Duration) error {
+	if err := unix.Unshare(unix.CLONE_NEWTIME); err != nil {
+		return fmt.Errorf("failed to unshare time namespace: %w", err)
+	}
+
+	// Nanoseconds must be positive
+	secs := int64(offset / time.Second)
+	nsecs := int64(offset % time.Second)
+	if nsecs < 0 {
+		secs--
+		nsecs += int64(time.Second)
+	}
+
+	offsets := fmt.Sprintf("monotonic %d %d\nboottime %d %d\n", secs, nsecs, secs, nsecs)
+
+	// Offsets can only be changed before the first process enters the namespace.
+	// The file refers to the main thread to which we are locked during init().
+	if err := os.WriteFile("/proc/self/timens_offsets", []byte(offsets), 0); err != nil {
+		return fmt.Errorf("failed to set time namespace offsets: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package gont
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// FileServer serves the files of a directory via HTTP or HTTPS
+// from within the network namespace of a node.
+type FileServer struct {
+	// Options
+
+	// TLSConfig enables HTTPS if set.
+	// Its KeyLogWriter can be used to decrypt captured traffic.
+	TLSConfig *tls.Config
+
+	node     *BaseNode
+	server   *http.Server
+	listener net.Listener
+	done     chan struct{}
+}
+
+// ServeFiles starts an in-process HTTP server which serves the files
+// in dir and accepts connections on the TCP address addr within the
+// network namespace of the node.
+//
+// The server is stopped by FileServer.Close() or when the node is torn down.
+func (n *BaseNode) ServeFiles(dir, addr string, opts ...Option) (*FileServer, error) {
+	s := &FileServer{
+		node: n,
+		done: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if fopt, ok := opt.(FileServerOption); ok {
+			fopt.Apply(s)
+		}
+	}
+
+	// Accepted connections belong to the namespace of the listener
+	if err := n.RunFunc(func() (err error) {
+		s.listener, err = net.Listen("tcp", addr)
+		return
+	}); err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s.server = &http.Server{
+		Handler:   http.FileServer(http.Dir(dir)),
+		TLSConfig: s.TLSConfig,
+	}
+
+	n.logger.Info("Serving files",
+		zap.String("dir", dir),
+		zap.String("url", s.URL()))
+
+	go func() {
+		defer close(s.done)
+
+		var err error
+		if s.TLSConfig != nil {
+			err = s.server.ServeTLS(s.listener, "", "")
+		} else {
+			err = s.server.Serve(s.listener)
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			n.logger.Error("Failed to serve files", zap.Error(err))
+		}
+	}()
+
+	n.fileServers = append(n.fileServers, s)
+
+	return s, nil
+}
+
+// Addr returns the address on which the server accepts connections
+func (s *FileServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// URL returns the base URL of the server
+func (s *FileServer) URL() string {
+	scheme := "http"
+	if s.TLSConfig != nil {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, s.Addr())
+}
+
+// Close stops the server and waits for it to finish
+func (s *FileServer) Close() error {
+	for i, fs := range s.node.fileServers {
+		if fs == s {
+			s.node.fileServers = append(s.node.fileServers[:i], s.node.fileServers[i+1:]...)
+			break
+		}
+	}
+
+	err := s.server.Close()
+	<-s.done
+
+	return err
+}
+
+func (n *BaseNode) closeFileServers() error {
+	for len(n.fileServers) > 0 {
+		if err := n.fileServers[0].Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package gont_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+// selfSignedCertificate creates a certificate for the IP address ip
+func selfSignedCertificate(t *testing.T, ip net.IP) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: ip.String()},
+		IPAddresses:  []net.IP{ip},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, pool
+}
+
+// fetch retrieves the body of url using connections from within the node
+func fetch(t *testing.T, n *g.BaseNode, url string, pool *x509.CertPool) []byte {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return n.DialContext(ctx, network, addr)
+			},
+			TLSClientConfig: &tls.Config{
+				RootCAs: pool,
+			},
+		},
+		Timeout: time.Second,
+	}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Failed to fetch %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to fetch %s: %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %s", err)
+	}
+
+	return body
+}
+
+//  h1 <-> h2
+func TestServeFiles(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	dir := t.TempDir()
+	content := []byte("Hello from h1")
+	if err := os.WriteFile(filepath.Join(dir, "index.txt"), content, 0644); err != nil {
+		t.Fatalf("Failed to write file: %s", err)
+	}
+
+	s, err := h1.ServeFiles(dir, "10.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("Failed to serve files: %s", err)
+	}
+
+	if body := fetch(t, h2.BaseNode, s.URL()+"/index.txt", nil); !bytes.Equal(body, content) {
+		t.Errorf("Mismatching content: %s", body)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to stop server: %s", err)
+	}
+
+	// The server must not accept connections after being stopped
+	if c, err := h2.Dial("tcp", "10.0.0.1:8080"); err == nil {
+		c.Close()
+		t.Error("Server still accepts connections")
+	}
+
+	// HTTPS with logged session keys
+	cert, pool := selfSignedCertificate(t, net.IPv4(10, 0, 0, 1))
+	keyLog := &bytes.Buffer{}
+
+	s, err = h1.ServeFiles(dir, "10.0.0.1:8443", o.WithTLSConfig(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		KeyLogWriter: keyLog,
+	}))
+	if err != nil {
+		t.Fatalf("Failed to serve files: %s", err)
+	}
+
+	if s.URL() != "https://10.0.0.1:8443" {
+		t.Errorf("Unexpected URL: %s", s.URL())
+	}
+
+	if body := fetch(t, h2.BaseNode, s.URL()+"/index.txt", pool); !bytes.Equal(body, content) {
+		t.Errorf("Mismatching content: %s", body)
+	}
+
+	if keyLog.Len() == 0 {
+		t.Error("No session keys have been logged")
+	}
+}
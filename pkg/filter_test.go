@@ -1,12 +1,16 @@
 package gont_test
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"testing"
 
+	"github.com/google/nftables/expr"
 	g "github.com/stv0g/gont/pkg"
 	o "github.com/stv0g/gont/pkg/options"
 	fo "github.com/stv0g/gont/pkg/options/filters"
+	nl "github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
 )
 
@@ -125,3 +129,316 @@ func TestFilterIPv6(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// TestMangleMark marks UDP traffic to a specific port and
+// steers it into a high-priority HTB class
+//
+//  h1 <-> h2
+func TestMangleMark(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	link, err := h1.NetlinkHandle().LinkByName("veth0")
+	if err != nil {
+		t.Fatalf("Failed to get link: %s", err)
+	}
+
+	var (
+		root     = nl.MakeHandle(1, 0)
+		classHi  = nl.MakeHandle(1, 10)
+		classLo  = nl.MakeHandle(1, 20)
+		mark     = uint32(10)
+		numPkts  = 10
+		markPort = 5000
+	)
+
+	htb := nl.NewHtb(nl.QdiscAttrs{
+		LinkIndex: link.Attrs().Index,
+		Handle:    root,
+		Parent:    nl.HANDLE_ROOT,
+	})
+	htb.Defcls = 20
+
+	if err := h1.NetlinkHandle().QdiscAdd(htb); err != nil {
+		t.Fatalf("Failed to add HTB qdisc: %s", err)
+	}
+
+	for i, class := range []uint32{classHi, classLo} {
+		if err := h1.NetlinkHandle().ClassAdd(nl.NewHtbClass(nl.ClassAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    root,
+			Handle:    class,
+		}, nl.HtbClassAttrs{
+			Rate: 100e6,
+			Ceil: 100e6,
+			Prio: uint32(i),
+		})); err != nil {
+			t.Fatalf("Failed to add HTB class: %s", err)
+		}
+	}
+
+	if err := h1.AddMangleMark([]expr.Any(fo.DestinationPort(uint16(markPort))), mark); err != nil {
+		t.Fatalf("Failed to add mangle mark: %s", err)
+	}
+
+	if err := h1.AddFwmarkFilter("veth0", root, mark, classHi); errors.Is(err, unix.ENOENT) {
+		t.Skip("Kernel lacks support for the fw classifier")
+	} else if err != nil {
+		t.Fatalf("Failed to add fwmark filter: %s", err)
+	}
+
+	for _, port := range []int{markPort, markPort + 1} {
+		c, err := h1.Dial("udp", fmt.Sprintf("10.0.0.2:%d", port))
+		if err != nil {
+			t.Fatalf("Failed to dial: %s", err)
+		}
+
+		for i := 0; i < numPkts; i++ {
+			if _, err := c.Write([]byte("hello")); err != nil {
+				t.Fatalf("Failed to send: %s", err)
+			}
+		}
+
+		c.Close()
+	}
+
+	classes, err := h1.NetlinkHandle().ClassList(link, root)
+	if err != nil {
+		t.Fatalf("Failed to list classes: %s", err)
+	}
+
+	for _, class := range classes {
+		attrs := class.Attrs()
+		if attrs.Handle != classHi && attrs.Handle != classLo {
+			continue
+		}
+
+		if pkts := attrs.Statistics.Basic.Packets; pkts != uint32(numPkts) {
+			t.Errorf("Class %s got %d packets instead of %d", nl.HandleStr(attrs.Handle), pkts, numPkts)
+		}
+	}
+}
+
+//  h1 <-> h2
+func TestTCFilter(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	i := h1.Interface("veth0")
+	link := i.Link
+
+	var (
+		root     = nl.MakeHandle(1, 0)
+		classHi  = nl.MakeHandle(1, 10)
+		classLo  = nl.MakeHandle(1, 20)
+		numPkts  = 10
+		hiPort   = 5000
+		flowPort = 6000
+	)
+
+	htb := nl.NewHtb(nl.QdiscAttrs{
+		LinkIndex: link.Attrs().Index,
+		Handle:    root,
+		Parent:    nl.HANDLE_ROOT,
+	})
+	htb.Defcls = 20
+
+	if err := h1.NetlinkHandle().QdiscAdd(htb); err != nil {
+		t.Fatalf("Failed to add HTB qdisc: %s", err)
+	}
+
+	for _, class := range []uint32{classHi, classLo} {
+		if err := h1.NetlinkHandle().ClassAdd(nl.NewHtbClass(nl.ClassAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    root,
+			Handle:    class,
+		}, nl.HtbClassAttrs{
+			Rate: 100e6,
+			Ceil: 100e6,
+		})); err != nil {
+			t.Fatalf("Failed to add HTB class: %s", err)
+		}
+	}
+
+	// Filters must reference existing classes
+	if err := i.AddFilter(&g.U32Filter{
+		TCFilterAttrs: g.TCFilterAttrs{
+			Parent:  root,
+			ClassID: nl.MakeHandle(1, 99),
+		},
+	}); err == nil {
+		t.Error("Added filter for missing class")
+	}
+
+	if err := i.AddFilter(&g.U32Filter{
+		TCFilterAttrs: g.TCFilterAttrs{
+			Parent:  nl.MakeHandle(2, 0),
+			ClassID: classHi,
+		},
+	}); err == nil {
+		t.Error("Added filter for missing parent")
+	}
+
+	// Ports without addresses are ambiguous between IPv4 and IPv6
+	if err := i.AddFilter(&g.FlowerFilter{
+		TCFilterAttrs: g.TCFilterAttrs{
+			Parent:  root,
+			ClassID: classHi,
+		},
+		IPProto:         unix.IPPROTO_UDP,
+		DestinationPort: uint16(flowPort),
+	}); err == nil {
+		t.Error("Added flower filter without EtherType")
+	}
+
+	if err := i.AddFilter(&g.FlowerFilter{
+		TCFilterAttrs: g.TCFilterAttrs{
+			Parent:  root,
+			ClassID: classHi,
+		},
+		Protocol:    unix.ETH_P_IPV6,
+		Destination: &net.IPNet{IP: net.IPv4(10, 0, 0, 2), Mask: net.CIDRMask(32, 32)},
+	}); err == nil {
+		t.Error("Added flower filter with mismatching EtherType")
+	}
+
+	// UDP with destination port hiPort
+	if err := i.AddFilter(&g.U32Filter{
+		TCFilterAttrs: g.TCFilterAttrs{
+			Parent:  root,
+			ClassID: classHi,
+		},
+		Protocol: unix.ETH_P_IP,
+		Keys: []g.U32Key{
+			{Offset: 8, Value: unix.IPPROTO_UDP << 16, Mask: 0x00ff0000},
+			{Offset: 20, Value: uint32(hiPort), Mask: 0x0000ffff},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to add u32 filter: %s", err)
+	}
+
+	ports := []int{hiPort, hiPort + 1}
+
+	if err := i.AddFilter(&g.FlowerFilter{
+		TCFilterAttrs: g.TCFilterAttrs{
+			Parent:   root,
+			Priority: 2,
+			ClassID:  classHi,
+		},
+		IPProto:         unix.IPPROTO_UDP,
+		Destination:     &net.IPNet{IP: net.IPv4(10, 0, 0, 2), Mask: net.CIDRMask(32, 32)},
+		DestinationPort: uint16(flowPort),
+	}); errors.Is(err, unix.ENOENT) || errors.Is(err, unix.EOPNOTSUPP) {
+		t.Log("Kernel lacks support for the flower classifier")
+	} else if err != nil {
+		t.Fatalf("Failed to add flower filter: %s", err)
+	} else {
+		ports = append(ports, flowPort)
+	}
+
+	expected := map[uint32]uint32{}
+
+	for _, port := range ports {
+		// Avoid ICMP port unreachable errors
+		var l net.PacketConn
+		if err := h2.RunFunc(func() (err error) {
+			l, err = net.ListenPacket("udp", fmt.Sprintf("10.0.0.2:%d", port))
+			return
+		}); err != nil {
+			t.Fatalf("Failed to listen: %s", err)
+		}
+		defer l.Close()
+
+		c, err := h1.Dial("udp", fmt.Sprintf("10.0.0.2:%d", port))
+		if err != nil {
+			t.Fatalf("Failed to dial: %s", err)
+		}
+
+		for j := 0; j < numPkts; j++ {
+			if _, err := c.Write([]byte("hello")); err != nil {
+				t.Fatalf("Failed to send: %s", err)
+			}
+		}
+
+		c.Close()
+
+		if port == hiPort+1 {
+			expected[classLo] += uint32(numPkts)
+		} else {
+			expected[classHi] += uint32(numPkts)
+		}
+	}
+
+	classes, err := h1.NetlinkHandle().ClassList(link, root)
+	if err != nil {
+		t.Fatalf("Failed to list classes: %s", err)
+	}
+
+	for _, class := range classes {
+		attrs := class.Attrs()
+		pkts := attrs.Statistics.Basic.Packets
+
+		switch attrs.Handle {
+		case classHi:
+			if pkts != expected[classHi] {
+				t.Errorf("Class %s got %d packets instead of %d", nl.HandleStr(attrs.Handle), pkts, expected[classHi])
+			}
+
+		case classLo:
+			// The default class also receives neighbor discovery packets
+			if pkts < expected[classLo] {
+				t.Errorf("Class %s got %d packets instead of at least %d", nl.HandleStr(attrs.Handle), pkts, expected[classLo])
+			}
+		}
+	}
+}
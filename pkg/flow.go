@@ -0,0 +1,201 @@
+package gont
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// FiveTuple identifies a transport-layer flow.
+// Packets of the flow are matched in both directions.
+type FiveTuple struct {
+	Protocol layers.IPProtocol
+
+	Src     net.IP
+	Dst     net.IP
+	SrcPort uint16
+	DstPort uint16
+}
+
+func (f FiveTuple) String() string {
+	return fmt.Sprintf("%s %s -> %s", f.Protocol,
+		net.JoinHostPort(f.Src.String(), strconv.Itoa(int(f.SrcPort))),
+		net.JoinHostPort(f.Dst.String(), strconv.Itoa(int(f.DstPort))))
+}
+
+// FlowSummary contains statistics about a flow found in a capture file.
+//
+// Forward refers to packets sent from the source to the destination
+// of the FiveTuple, Reverse to the opposite direction.
+type FlowSummary struct {
+	Packets        int `json:"packets"`
+	PacketsForward int `json:"packets_forward"`
+	PacketsReverse int `json:"packets_reverse"`
+	Bytes          int `json:"bytes"`
+
+	// TCP only
+	SYN         bool `json:"syn"`
+	SYNACK      bool `json:"synack"`
+	Established bool `json:"established"`
+	FINForward  bool `json:"fin_forward"`
+	FINReverse  bool `json:"fin_reverse"`
+	RST         bool `json:"rst"`
+	Retransmits int  `json:"retransmits"`
+}
+
+// HandshakeCompleted returns true if the three-way handshake of a TCP flow has been observed.
+func (s FlowSummary) HandshakeCompleted() bool {
+	return s.SYN && s.SYNACK && s.Established
+}
+
+// ClosedCleanly returns true if both sides of a TCP flow
+// have sent a FIN and the flow has not been reset.
+func (s FlowSummary) ClosedCleanly() bool {
+	return s.FINForward && s.FINReverse && !s.RST
+}
+
+// AnalyzeFlow summarizes the packets of a flow contained in the PCAP or PCAPng file at path.
+//
+// A TCP segment is counted as a retransmission if it does not carry
+// any sequence numbers beyond those already seen in its direction.
+func AnalyzeFlow(path string, flow FiveTuple) (FlowSummary, error) {
+	var s FlowSummary
+	var synAckSeq uint32
+
+	// Next expected sequence number per direction
+	nextSeq := [2]uint32{}
+	seenSeq := [2]bool{}
+
+	if err := readPacketFile(path, func(data []byte, ci gopacket.CaptureInfo, lt layers.LinkType) error {
+		p := decodePacket(data, lt)
+
+		reverse, ok := flow.match(p)
+		if !ok {
+			return nil
+		}
+
+		s.Packets++
+		s.Bytes += ci.Length
+
+		dir := 0
+		if reverse {
+			dir = 1
+			s.PacketsReverse++
+		} else {
+			s.PacketsForward++
+		}
+
+		tcp, ok := p.TransportLayer().(*layers.TCP)
+		if !ok {
+			return nil
+		}
+
+		switch {
+		case tcp.SYN && !tcp.ACK && !reverse:
+			s.SYN = true
+		case tcp.SYN && tcp.ACK && reverse:
+			s.SYNACK = true
+			synAckSeq = tcp.Seq
+		case tcp.ACK && !reverse && s.SYNACK && tcp.Ack == synAckSeq+1:
+			s.Established = true
+		}
+
+		if tcp.FIN {
+			if reverse {
+				s.FINReverse = true
+			} else {
+				s.FINForward = true
+			}
+		}
+
+		if tcp.RST {
+			s.RST = true
+		}
+
+		// SYN and FIN consume a sequence number
+		segLen := uint32(len(tcp.Payload))
+		if tcp.SYN {
+			segLen++
+		}
+		if tcp.FIN {
+			segLen++
+		}
+
+		if segLen == 0 {
+			return nil
+		}
+
+		end := tcp.Seq + segLen
+		if seenSeq[dir] && int32(end-nextSeq[dir]) <= 0 {
+			s.Retransmits++
+		} else {
+			nextSeq[dir] = end
+			seenSeq[dir] = true
+		}
+
+		return nil
+	}); err != nil {
+		return FlowSummary{}, err
+	}
+
+	return s, nil
+}
+
+// match checks whether the packet belongs to the flow
+// and returns true for reverse if it has been sent by the destination.
+func (f FiveTuple) match(p gopacket.Packet) (reverse bool, ok bool) {
+	var src, dst net.IP
+	var proto layers.IPProtocol
+
+	switch ip := p.NetworkLayer().(type) {
+	case *layers.IPv4:
+		src, dst, proto = ip.SrcIP, ip.DstIP, ip.Protocol
+	case *layers.IPv6:
+		src, dst, proto = ip.SrcIP, ip.DstIP, ip.NextHeader
+	default:
+		return false, false
+	}
+
+	if proto != f.Protocol {
+		return false, false
+	}
+
+	var sport, dport uint16
+	switch l := p.TransportLayer().(type) {
+	case *layers.TCP:
+		sport, dport = uint16(l.SrcPort), uint16(l.DstPort)
+	case *layers.UDP:
+		sport, dport = uint16(l.SrcPort), uint16(l.DstPort)
+	case *layers.SCTP:
+		sport, dport = uint16(l.SrcPort), uint16(l.DstPort)
+	default:
+		return false, false
+	}
+
+	switch {
+	case src.Equal(f.Src) && dst.Equal(f.Dst) && sport == f.SrcPort && dport == f.DstPort:
+		return false, true
+	case src.Equal(f.Dst) && dst.Equal(f.Src) && sport == f.DstPort && dport == f.SrcPort:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// decodePacket decodes packet data captured with the link-layer type lt
+func decodePacket(data []byte, lt layers.LinkType) gopacket.Packet {
+	switch lt {
+	case layers.LinkTypeRaw, layers.LinkTypeIPv4, layers.LinkTypeIPv6:
+		if len(data) > 0 && data[0]>>4 == 6 {
+			return gopacket.NewPacket(data, layers.LayerTypeIPv6, gopacket.Lazy)
+		}
+
+		return gopacket.NewPacket(data, layers.LayerTypeIPv4, gopacket.Lazy)
+
+	default:
+		return gopacket.NewPacket(data, lt, gopacket.Lazy)
+	}
+}
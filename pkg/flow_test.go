@@ -0,0 +1,195 @@
+package gont_test
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+// capturePackets writes all packets received on the packet socket fd
+// to a PCAP file at path until the returned function is called
+func capturePackets(t *testing.T, fd int, path string) func() {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file: %s", err)
+	}
+
+	w := pcapgo.NewWriter(f)
+	if err := w.WriteFileHeader(65536, layers.LinkTypeEthernet); err != nil {
+		t.Fatalf("Failed to write file header: %s", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		buf := make([]byte, 1<<16)
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			n, _, err := unix.Recvfrom(fd, buf, 0)
+			if err != nil {
+				continue
+			}
+
+			w.WritePacket(gopacket.CaptureInfo{
+				Timestamp:     time.Now(),
+				CaptureLength: n,
+				Length:        n,
+			}, buf[:n])
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+		f.Close()
+	}
+}
+
+//  h1 <-> h2
+func TestAnalyzeFlow(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	var l net.Listener
+	if err := h2.RunFunc(func() (err error) {
+		l, err = net.Listen("tcp", "10.0.0.2:8080")
+		return
+	}); err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		io.Copy(conn, conn)
+	}()
+
+	path := filepath.Join(t.TempDir(), "flow.pcap")
+
+	fd := listenPacket(t, h2.BaseNode, "veth0")
+	defer unix.Close(fd)
+
+	stop := capturePackets(t, fd, path)
+
+	conn, err := h1.Dial("tcp", "10.0.0.2:8080")
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+
+	msg := []byte("hello")
+	if _, err := conn.Write(msg); err != nil {
+		t.Fatalf("Failed to write: %s", err)
+	}
+
+	if _, err := io.ReadFull(conn, make([]byte, len(msg))); err != nil {
+		t.Fatalf("Failed to read: %s", err)
+	}
+
+	local := conn.LocalAddr().(*net.TCPAddr)
+
+	// Closing the write side first lets the echo server close its side
+	// after having read all data which avoids a reset
+	if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+		t.Fatalf("Failed to close connection: %s", err)
+	}
+
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		t.Fatalf("Failed to read: %s", err)
+	}
+
+	conn.Close()
+
+	// Wait for the final ACK
+	time.Sleep(200 * time.Millisecond)
+	stop()
+
+	s, err := g.AnalyzeFlow(path, g.FiveTuple{
+		Protocol: layers.IPProtocolTCP,
+		Src:      local.IP,
+		Dst:      net.IPv4(10, 0, 0, 2),
+		SrcPort:  uint16(local.Port),
+		DstPort:  8080,
+	})
+	if err != nil {
+		t.Fatalf("Failed to analyze flow: %s", err)
+	}
+
+	if !s.HandshakeCompleted() {
+		t.Errorf("Handshake not completed: %+v", s)
+	}
+
+	if !s.ClosedCleanly() {
+		t.Errorf("Connection not closed cleanly: %+v", s)
+	}
+
+	if s.Retransmits != 0 {
+		t.Errorf("Unexpected retransmits: %d", s.Retransmits)
+	}
+
+	if s.PacketsForward == 0 || s.PacketsReverse == 0 {
+		t.Errorf("Missing packets in one direction: %+v", s)
+	}
+
+	// Other flows are not matched
+	s, err = g.AnalyzeFlow(path, g.FiveTuple{
+		Protocol: layers.IPProtocolUDP,
+		Src:      local.IP,
+		Dst:      net.IPv4(10, 0, 0, 2),
+		SrcPort:  uint16(local.Port),
+		DstPort:  8080,
+	})
+	if err != nil {
+		t.Fatalf("Failed to analyze flow: %s", err)
+	} else if s.Packets != 0 {
+		t.Errorf("Matched %d packets of other flow", s.Packets)
+	}
+}
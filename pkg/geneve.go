@@ -0,0 +1,64 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	nl "github.com/vishvananda/netlink"
+)
+
+// GenevePort is the IANA assigned UDP port for Geneve
+const GenevePort = 6081
+
+// AddGeneve adds a Geneve tunnel interface to a running node which
+// encapsulates Ethernet frames in UDP datagrams towards remote using
+// the virtual network identifier (VNI) vni.
+//
+// If port is zero, the default GenevePort is used. Interface and link
+// options like addresses are applied like for other interfaces.
+// The tunnel is removed together with the node.
+//
+// Geneve option TLVs are not supported as the kernel only exposes them
+// for flow-based tunnels via lightweight tunnel metadata.
+func (n *BaseNode) AddGeneve(name string, vni uint32, remote net.IP, port uint16, opts ...Option) (*Interface, error) {
+	if vni >= 1<<24 {
+		return nil, fmt.Errorf("invalid Geneve VNI: %d", vni)
+	}
+
+	if remote == nil {
+		return nil, errors.New("missing remote address for Geneve tunnel")
+	}
+
+	if port == 0 {
+		port = GenevePort
+	}
+
+	i := &Interface{
+		Name: name,
+	}
+
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case InterfaceOption:
+			opt.Apply(i)
+		case LinkOption:
+			opt.Apply(&i.LinkAttrs)
+		}
+	}
+
+	geneve := &nl.Geneve{
+		LinkAttrs: nl.LinkAttrs{
+			Name: name,
+		},
+		ID:     vni,
+		Remote: remote,
+		Dport:  port,
+	}
+
+	if err := n.addInterfaceLive(i, geneve); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
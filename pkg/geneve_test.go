@@ -0,0 +1,86 @@
+package gont_test
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+// TestGeneve builds a Geneve tunnel between two hosts
+// and pings over it.
+//
+//  h1 (gnv0) <-> h2 (gnv0)
+func TestGeneve(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if _, err := h1.AddGeneve("gnv1", 1<<24, net.IPv4(10, 0, 0, 2), 0); err == nil {
+		t.Error("Created Geneve tunnel with invalid VNI")
+	}
+
+	if _, err := h1.AddGeneve("gnv1", 42, nil, 0); err == nil {
+		t.Error("Created Geneve tunnel without remote")
+	}
+
+	gnv, err := h1.AddGeneve("gnv0", 42, net.IPv4(10, 0, 0, 2), 0,
+		o.AddressIPv4(10, 1, 0, 1, 24))
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		t.Skip("Kernel lacks support for Geneve")
+	} else if err != nil {
+		t.Fatalf("Failed to add Geneve tunnel: %s", err)
+	}
+
+	if _, err := h2.AddGeneve("gnv0", 42, net.IPv4(10, 0, 0, 1), g.GenevePort,
+		o.AddressIPv4(10, 1, 0, 2, 24)); err != nil {
+		t.Fatalf("Failed to add Geneve tunnel: %s", err)
+	}
+
+	if h1.Interface("gnv0") != gnv {
+		t.Error("Tunnel is not registered as interface")
+	}
+
+	out, _, err := h1.Run("ip", "-d", "link", "show", "gnv0")
+	if err != nil {
+		t.Fatalf("Failed to show link: %s", err)
+	}
+
+	if !strings.Contains(string(out), "geneve id 42 remote 10.0.0.2") {
+		t.Errorf("VNI is not shown by ip link: %s", out)
+	}
+
+	// Ping the address of the tunnel rather than the host name
+	// to avoid a resolution to the address of the underlay
+	if out, _, err := h1.Run("ping", "-c", "3", "-i", "0.1", "-W", "3", "10.1.0.2"); err != nil {
+		t.Errorf("Failed to ping over tunnel: %s: %s", err, out)
+	}
+}
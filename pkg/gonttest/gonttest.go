@@ -0,0 +1,45 @@
+// Package gonttest provides helpers for using Gont networks in Go tests.
+//
+// It is kept separate from the main package to avoid importing
+// the testing package into non-test binaries.
+package gonttest
+
+import (
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+)
+
+// RegisterCleanup tears down the network when the test and all its subtests complete.
+//
+// Networks created with KeepOnFailure are kept if the test failed.
+func RegisterCleanup(t testing.TB, n *g.Network) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		if n.KeepOnFailure && t.Failed() {
+			n.Keep()
+			t.Logf("Keeping network %s at %s for inspection", n, n.BasePath)
+			return
+		}
+
+		if err := n.Close(); err != nil {
+			t.Errorf("Failed to close network: %s", err)
+		}
+	})
+}
+
+// NewNetwork creates a new network which is torn down automatically
+// at the end of the test. The test fails if the network can not be created.
+func NewNetwork(t testing.TB, name string, opts ...g.Option) *g.Network {
+	t.Helper()
+
+	n, err := g.NewNetwork(name, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+
+	RegisterCleanup(t, n)
+
+	return n
+}
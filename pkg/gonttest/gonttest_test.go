@@ -0,0 +1,38 @@
+package gonttest_test
+
+import (
+	"os"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	"github.com/stv0g/gont/pkg/gonttest"
+)
+
+func TestRegisterCleanup(t *testing.T) {
+	var n *g.Network
+	closed := false
+
+	t.Run("network", func(t *testing.T) {
+		n = gonttest.NewNetwork(t, "")
+
+		if _, err := n.AddHost("h1"); err != nil {
+			t.Fatalf("Failed to create host: %s", err)
+		}
+
+		// Registered cleanups run in last-in-first-out order
+		t.Cleanup(func() {
+			if _, err := os.Stat(n.BasePath); err != nil {
+				t.Errorf("Network has been closed before the end of the test")
+			}
+			closed = true
+		})
+	})
+
+	if !closed {
+		t.Fatal("Cleanup did not run")
+	}
+
+	if _, err := os.Stat(n.BasePath); !os.IsNotExist(err) {
+		t.Errorf("Network has not been closed after the test: %v", err)
+	}
+}
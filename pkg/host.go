@@ -26,7 +26,15 @@ func (h *Host) Apply(i *Interface) {
 }
 
 func (n *Network) AddHost(name string, opts ...Option) (*Host, error) {
-	node, err := n.AddNode(name, opts...)
+	if err := checkOptions(opts, targetNode, targetHost); err != nil {
+		return nil, err
+	}
+
+	return n.addHost(name, opts)
+}
+
+func (n *Network) addHost(name string, opts []Option) (*Host, error) {
+	node, err := n.addNode(name, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create node: %s", err)
 	}
@@ -60,10 +68,8 @@ func (n *Network) AddHost(name string, opts ...Option) (*Host, error) {
 	}
 
 	// Configure host
-	for _, r := range host.Routes {
-		if err := host.AddRoute(r); err != nil {
-			return nil, fmt.Errorf("failed to add route: %w", err)
-		}
+	if err := host.AddRoutes(host.Routes...); err != nil {
+		return nil, fmt.Errorf("failed to add routes: %w", err)
 	}
 
 	if host.Forwarding {
@@ -115,13 +121,25 @@ func (h *Host) ConfigureInterface(i *Interface) error {
 	// Disable duplicate address detection (DAD) before adding addresses
 	// so we dont end up with tentative addresses and slow test executions
 	if !i.EnableDAD {
-		fn := filepath.Join("/proc/sys/net/ipv6/conf", i.Name, "accept_dad")
-		if err := h.WriteProcFS(fn, "0"); err != nil {
-			return fmt.Errorf("failed to enabled IPv6 forwarding: %s", err)
+		for _, param := range []string{"accept_dad", "dad_transmits"} {
+			fn := filepath.Join("/proc/sys/net/ipv6/conf", i.Name, param)
+			if err := h.WriteProcFS(fn, "0"); err != nil {
+				return fmt.Errorf("failed to disable IPv6 duplicate address detection: %s", err)
+			}
 		}
 	}
 
 	for _, addr := range i.Addresses {
+		isIPv4 := addr.IP.To4() != nil
+		if (isIPv4 && h.network.DisableIPv4) || (!isIPv4 && h.network.DisableIPv6) {
+			// Skip the default addresses of the loopback interface
+			if i.IsLoopback() {
+				continue
+			}
+
+			return fmt.Errorf("address %s belongs to a disabled address family", addr.String())
+		}
+
 		if err := h.LinkAddAddress(i.Name, addr); err != nil {
 			return fmt.Errorf("failed to add link address: %s", err)
 		}
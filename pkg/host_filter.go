@@ -1,7 +1,10 @@
 package gont
 
 import (
+	"fmt"
+
 	nft "github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
 	"github.com/google/nftables/expr"
 )
 
@@ -11,6 +14,8 @@ const (
 	FilterInput FilterHook = iota
 	FilterOutput
 	FilterForward
+	FilterManglePrerouting
+	FilterMangleOutput
 )
 
 type FilterRule struct {
@@ -32,6 +37,9 @@ type Filter struct {
 	Input   *nft.Chain
 	Output  *nft.Chain
 	Forward *nft.Chain
+
+	ManglePrerouting *nft.Chain
+	MangleOutput     *nft.Chain
 }
 
 func NewFilter(c *nft.Conn) (*Filter, error) {
@@ -80,6 +88,24 @@ func NewFilter(c *nft.Conn) (*Filter, error) {
 		Priority: nft.ChainPriorityFilter,
 	})
 
+	flt.ManglePrerouting = c.AddChain(&nft.Chain{
+		Name:     "mangle-prerouting",
+		Table:    flt.Table,
+		Type:     nft.ChainTypeFilter,
+		Hooknum:  nft.ChainHookPrerouting,
+		Priority: nft.ChainPriorityMangle,
+	})
+
+	// Chains of type route re-route locally generated packets
+	// if their mark has been changed
+	flt.MangleOutput = c.AddChain(&nft.Chain{
+		Name:     "mangle-output",
+		Table:    flt.Table,
+		Type:     nft.ChainTypeRoute,
+		Hooknum:  nft.ChainHookOutput,
+		Priority: nft.ChainPriorityMangle,
+	})
+
 	return flt, c.Flush()
 }
 
@@ -92,6 +118,10 @@ func (f *Filter) AddRule(h FilterHook, exprs ...expr.Any) {
 		chain = f.Input
 	case FilterOutput:
 		chain = f.Output
+	case FilterManglePrerouting:
+		chain = f.ManglePrerouting
+	case FilterMangleOutput:
+		chain = f.MangleOutput
 	}
 
 	f.conn.AddRule(&nft.Rule{
@@ -104,3 +134,32 @@ func (f *Filter) AddRule(h FilterHook, exprs ...expr.Any) {
 func (f *Filter) Flush() error {
 	return f.conn.Flush()
 }
+
+// AddMangleMark sets the firewall mark of all packets which are matched
+// by the expressions in match. The mark is applied to forwarded as well as
+// locally generated packets and can be used by qdisc filters to steer
+// packets into traffic classes (see BaseNode.AddFwmarkFilter).
+func (h *Host) AddMangleMark(match []expr.Any, mark uint32) error {
+	exprs := append([]expr.Any{}, match...)
+	exprs = append(exprs,
+		&expr.Immediate{
+			Register: 1,
+			Data:     binaryutil.NativeEndian.PutUint32(mark),
+		},
+		&expr.Meta{
+			Key:            expr.MetaKeyMARK,
+			SourceRegister: true,
+			Register:       1,
+		},
+	)
+
+	for _, hook := range []FilterHook{FilterManglePrerouting, FilterMangleOutput} {
+		h.Filter.AddRule(hook, exprs...)
+	}
+
+	if err := h.Filter.Flush(); err != nil {
+		return fmt.Errorf("failed to add mangle rule: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package gont_test
+
+import (
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// TestHostNode links a node with the default network namespace
+// of the host and performs a ping test from the host.
+//
+//  host <-> h1
+func TestHostNode(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	baseNs, err := netns.Get()
+	if err != nil {
+		t.Fatalf("Failed to get current namespace: %s", err)
+	}
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if !n.HostNode.IsHostNode() || h1.IsHostNode() {
+		t.Fatal("Failed to identify host node")
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth-gont-h1", n.HostNode,
+			o.AddressIP("fc:ff::1/64")),
+		o.Interface("veth0", h1,
+			o.AddressIP("fc:ff::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to connect host: %s", err)
+	}
+
+	if _, err := n.HostNode.PingWithNetwork(h1, "ip6"); err != nil {
+		t.Errorf("Failed to ping node from host: %s", err)
+	}
+
+	if err := n.Teardown(); err != nil {
+		t.Fatalf("Failed to teardown network: %s", err)
+	}
+
+	// The deferred Close() tears down the network again
+	if err := n.Teardown(); err != nil {
+		t.Errorf("Failed to teardown network twice: %s", err)
+	}
+
+	if _, err := nl.LinkByName("veth-gont-h1"); err == nil {
+		t.Error("Interface has not been removed from host namespace")
+	}
+
+	curNs, err := netns.Get()
+	if err != nil {
+		t.Fatalf("Failed to get current namespace: %s", err)
+	}
+
+	if !curNs.Equal(baseNs) {
+		t.Error("Host namespace has been changed")
+	}
+
+	if _, err := n.HostNode.NetlinkHandle().LinkByName("lo"); err != nil {
+		t.Errorf("Host namespace is not usable anymore: %s", err)
+	}
+}
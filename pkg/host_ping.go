@@ -1,6 +1,7 @@
 package gont
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -18,6 +19,13 @@ func (h *Host) PingWithNetwork(o *Host, net string) (*ping.Statistics, error) {
 }
 
 func (h *Host) PingWithOptions(o *Host, net string, count int, timeout time.Duration, intv time.Duration, output bool) (*ping.Statistics, error) {
+	return h.PingContext(context.Background(), o, net, count, timeout, intv, output)
+}
+
+// PingContext is like PingWithOptions but stops pinging as soon as the context
+// is canceled. In this case, the statistics gathered so far are returned
+// together with the error of the context.
+func (h *Host) PingContext(ctx context.Context, o *Host, net string, count int, timeout time.Duration, intv time.Duration, output bool) (*ping.Statistics, error) {
 	var err error
 
 	p := ping.New(o.Name())
@@ -64,6 +72,17 @@ func (h *Host) PingWithOptions(o *Host, net string, count int, timeout time.Dura
 		}
 	}
 
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.Stop()
+		case <-stop:
+		}
+	}()
+
 	if err = h.RunFunc(func() error {
 		if output {
 			fmt.Fprintf(wlog, "PING %s(%s) %d data bytes\n",
@@ -78,6 +97,10 @@ func (h *Host) PingWithOptions(o *Host, net string, count int, timeout time.Dura
 		return nil, err
 	}
 
+	if ctx.Err() != nil {
+		return p.Statistics(), ctx.Err()
+	}
+
 	lost := p.PacketsSent - p.PacketsRecv
 	if lost > 0 {
 		err = fmt.Errorf("lost %d packets", lost)
@@ -0,0 +1,130 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+const (
+	initUnshare = "init"
+	initPIDFile = "init.pid"
+)
+
+// runInit is the main loop of the pause-style init process.
+// It does nothing but holding the namespaces open until it gets terminated.
+func runInit() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+	<-sigs
+}
+
+// startInit spawns a pause-style init process in the namespace of the node.
+//
+// The init process runs in its own session so that it outlives the process
+// which created the network and keeps the namespace alive until it is stopped.
+func (n *BaseNode) startInit() error {
+	c := exec.Command("/proc/self/exe")
+	c.Env = append(os.Environ(), "GONT_UNSHARE="+initUnshare)
+	c.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true,
+	}
+
+	// The forked process inherits the namespace of the calling thread
+	if err := n.RunFunc(c.Start); err != nil {
+		return fmt.Errorf("failed to start init process: %w", err)
+	}
+
+	fn := filepath.Join(n.BasePath, initPIDFile)
+	if err := os.WriteFile(fn, []byte(strconv.Itoa(c.Process.Pid)), 0644); err != nil {
+		_ = c.Process.Kill()
+		_ = c.Wait()
+
+		return fmt.Errorf("failed to write pid file: %w", err)
+	}
+
+	n.initCmd = c
+
+	n.logger.Info("Started init process",
+		zap.Int("pid", c.Process.Pid))
+
+	return nil
+}
+
+// stopInit signals the init process to exit and waits for its termination.
+func (n *BaseNode) stopInit() error {
+	if n.initCmd == nil {
+		return nil
+	}
+
+	pid := n.initCmd.Process.Pid
+
+	if err := n.initCmd.Process.Signal(syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to signal init process: %w", err)
+	}
+
+	// The init process might get terminated before it installed its signal handler
+	if err := n.initCmd.Wait(); err != nil && !terminatedBy(err, syscall.SIGTERM) {
+		return fmt.Errorf("failed to wait for init process: %w", err)
+	}
+
+	n.initCmd = nil
+
+	n.logger.Info("Stopped init process",
+		zap.Int("pid", pid))
+
+	return nil
+}
+
+// InitPID returns the process ID of the init process which
+// holds the namespace of the node or 0 if there is none.
+func (n *BaseNode) InitPID() int {
+	if n.initCmd == nil {
+		return 0
+	}
+
+	return n.initCmd.Process.Pid
+}
+
+// stopInitFromPIDFile terminates an init process of a node
+// which has been started by another process.
+func stopInitFromPIDFile(nodeDir string) error {
+	buf, err := os.ReadFile(filepath.Join(nodeDir, initPIDFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	if err != nil {
+		return fmt.Errorf("invalid pid file: %w", err)
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("failed to signal init process: %w", err)
+	}
+
+	return nil
+}
+
+func terminatedBy(err error, sig syscall.Signal) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+
+	ws, ok := exitErr.Sys().(syscall.WaitStatus)
+
+	return ok && ws.Signaled() && ws.Signal() == sig
+}
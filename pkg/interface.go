@@ -1,10 +1,17 @@
 package gont
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
 
+	"github.com/google/gopacket/layers"
 	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 const (
@@ -26,6 +33,9 @@ var loopbackInterface = Interface{
 	},
 }
 
+// InterfaceHook is a function which is invoked during the configuration of an interface
+type InterfaceHook func(i *Interface) error
+
 type Interface struct {
 	Name string
 	Node Node
@@ -35,11 +45,24 @@ type Interface struct {
 	Flags int
 
 	// Options
-	Netem     nl.NetemQdiscAttrs
-	Tbf       nl.Tbf
-	EnableDAD bool
-	LinkAttrs nl.LinkAttrs
+	Netem      nl.NetemQdiscAttrs
+	NetemAttrs nl.QdiscAttrs // handle and parent of the Netem qdisc
+	NetemSlot  *NetemSlot
+	Tbf        nl.Tbf
+	EnableDAD  bool
+	LinkAttrs  nl.LinkAttrs
+
+	// DisableAutoAddress skips the interface when assigning
+	// addresses from the IPv6 prefix of the network
+	DisableAutoAddress bool
+
 	Addresses []net.IPNet
+	PreUp     []InterfaceHook
+	PostUp    []InterfaceHook
+
+	meta map[string]string
+
+	statsBaseline *nl.LinkStatistics
 }
 
 // Options
@@ -56,6 +79,22 @@ func (i Interface) String() string {
 	return i.Name
 }
 
+// SetMeta attaches an arbitrary key/value pair to the interface.
+// The metadata is not interpreted by Gont itself.
+func (i *Interface) SetMeta(key, value string) {
+	if i.meta == nil {
+		i.meta = map[string]string{}
+	}
+
+	i.meta[key] = value
+}
+
+// Meta returns the metadata value stored for key
+// or an empty string if it has not been set.
+func (i *Interface) Meta(key string) string {
+	return i.meta[key]
+}
+
 func (i Interface) IsLoopback() bool {
 	return i.Name == loopbackInterfaceName
 }
@@ -63,3 +102,436 @@ func (i Interface) IsLoopback() bool {
 func (i *Interface) Configure() error {
 	return i.Node.ConfigureInterface(i)
 }
+
+// Carrier returns true if the interface has detected a carrier.
+//
+// For veth interfaces the carrier is lost when the peer interface is down.
+func (i *Interface) Carrier() (bool, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return false, err
+	}
+
+	return link.Attrs().RawFlags&unix.IFF_LOWER_UP != 0, nil
+}
+
+// LinkFlags returns the current flags of the interface as reported by the kernel.
+func (i *Interface) LinkFlags() (net.Flags, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return 0, err
+	}
+
+	return link.Attrs().Flags, nil
+}
+
+// SetUp sets the administrative state of the interface to up
+func (i *Interface) SetUp() error {
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	if err := i.Node.NetlinkHandle().LinkSetUp(link); err != nil {
+		return fmt.Errorf("failed to set interface up: %w", err)
+	}
+
+	return nil
+}
+
+// SetDown sets the administrative state of the interface to down
+func (i *Interface) SetDown() error {
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	if err := i.Node.NetlinkHandle().LinkSetDown(link); err != nil {
+		return fmt.Errorf("failed to set interface down: %w", err)
+	}
+
+	return nil
+}
+
+// SetAllMulticast enables or disables the reception of all multicast
+// packets by the interface regardless of the joined groups.
+func (i *Interface) SetAllMulticast(enabled bool) error {
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	h := i.Node.NetlinkHandle()
+	if enabled {
+		err = h.LinkSetAllmulticastOn(link)
+	} else {
+		err = h.LinkSetAllmulticastOff(link)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set all-multicast mode: %w", err)
+	}
+
+	return nil
+}
+
+// Flap alternately sets the interface down and up for count times.
+// The interface stays in each state for the given interval.
+//
+// If the context is canceled, flapping is aborted and the interface is set up again.
+func (i *Interface) Flap(ctx context.Context, count int, interval time.Duration) error {
+	wait := func() error {
+		select {
+		case <-ctx.Done():
+			if err := i.SetUp(); err != nil {
+				return err
+			}
+
+			return ctx.Err()
+		case <-time.After(interval):
+			return nil
+		}
+	}
+
+	for j := 0; j < count; j++ {
+		if err := i.SetDown(); err != nil {
+			return err
+		}
+
+		if err := wait(); err != nil {
+			return err
+		}
+
+		if err := i.SetUp(); err != nil {
+			return err
+		}
+
+		// No need to wait after the last flap
+		if j < count-1 {
+			if err := wait(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetTxQLen changes the transmit queue length of the running interface
+func (i *Interface) SetTxQLen(qlen int) error {
+	if qlen < 0 {
+		return fmt.Errorf("invalid transmit queue length: %d", qlen)
+	}
+
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	if err := i.Node.NetlinkHandle().LinkSetTxQLen(link, qlen); err != nil {
+		return fmt.Errorf("failed to set transmit queue length: %w", err)
+	}
+
+	i.LinkAttrs.TxQLen = qlen
+
+	return nil
+}
+
+// SetAlias sets the alias (IFLA_IFALIAS) of the running interface
+// which is shown by tools like "ip link".
+func (i *Interface) SetAlias(alias string) error {
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	if err := i.Node.NetlinkHandle().LinkSetAlias(link, alias); err != nil {
+		return fmt.Errorf("failed to set alias: %w", err)
+	}
+
+	i.LinkAttrs.Alias = alias
+
+	return nil
+}
+
+// Alias returns the current alias of the interface as reported by the kernel.
+func (i *Interface) Alias() (string, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return "", err
+	}
+
+	return link.Attrs().Alias, nil
+}
+
+// LinkType returns the link-layer type of the frames which are sent
+// and received on the interface as seen by packet sockets.
+//
+// An error is returned for hardware types (ARPHRD) without
+// a corresponding link-layer type.
+func (i *Interface) LinkType() (layers.LinkType, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return 0, err
+	}
+
+	switch typ := link.Attrs().EncapType; typ {
+	case "ether", "loopback":
+		return layers.LinkTypeEthernet, nil
+
+	// Tunnels without link-layer header carry raw IP packets.
+	// ip6tnl devices report "tunnel6", ip6gre devices (ARPHRD_IP6GRE)
+	// have no name in netlink and are reported by their number.
+	case "none", "ipip", "tunnel6", "sit", "gre", "unknown823":
+		return layers.LinkTypeRaw, nil
+
+	case "ieee802.11":
+		return layers.LinkTypeIEEE802_11, nil
+
+	case "ieee802.11/radiotap":
+		return layers.LinkTypeIEEE80211Radio, nil
+
+	default:
+		return 0, fmt.Errorf("unsupported hardware type of interface %s: %s", i.Name, typ)
+	}
+}
+
+// SetHairpin enables or disables the hairpin mode (reflective relay) of a bridge port.
+// In hairpin mode, the bridge forwards frames back out of the port on which they have
+// been received.
+func (i *Interface) SetHairpin(enabled bool) error {
+	link, err := i.bridgePort()
+	if err != nil {
+		return err
+	}
+
+	if err := i.Node.NetlinkHandle().LinkSetHairpin(link, enabled); err != nil {
+		return fmt.Errorf("failed to set hairpin mode: %w", err)
+	}
+
+	return nil
+}
+
+// Hairpin returns true if the hairpin mode of the bridge port is enabled.
+func (i *Interface) Hairpin() (bool, error) {
+	link, err := i.bridgePort()
+	if err != nil {
+		return false, err
+	}
+
+	pi, err := i.Node.NetlinkHandle().LinkGetProtinfo(link)
+	if err != nil {
+		return false, fmt.Errorf("failed to get bridge port information: %w", err)
+	}
+
+	return pi.Hairpin, nil
+}
+
+// bridgePort returns the current link of the interface if it is attached to a bridge
+func (i *Interface) bridgePort() (nl.Link, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return nil, err
+	}
+
+	if idx := link.Attrs().MasterIndex; idx != 0 {
+		if master, err := i.Node.NetlinkHandle().LinkByIndex(idx); err != nil {
+			return nil, fmt.Errorf("failed to find master of interface %s: %w", i.Name, err)
+		} else if _, ok := master.(*nl.Bridge); ok {
+			return link, nil
+		}
+	}
+
+	return nil, fmt.Errorf("interface %s is not a bridge port", i.Name)
+}
+
+// SetRPS configures Receive Packet Steering for all receive queues of
+// the interface so that received packets are processed by the given CPUs.
+// An empty list of CPUs disables RPS.
+func (i *Interface) SetRPS(cpus []int) error {
+	mask, err := cpuMask(cpus)
+	if err != nil {
+		return err
+	}
+
+	if i.Node == nil {
+		return fmt.Errorf("interface %s does not belong to a node", i.Name)
+	}
+
+	return runWithSysFS(i.Node.NetNSHandle(), func() error {
+		queues, err := filepath.Glob(filepath.Join(sysfsPath, "class/net", i.Name, "queues/rx-*"))
+		if err != nil {
+			return err
+		} else if len(queues) == 0 {
+			return fmt.Errorf("interface %s has no receive queues", i.Name)
+		}
+
+		for _, queue := range queues {
+			if err := os.WriteFile(filepath.Join(queue, "rps_cpus"), []byte(mask), 0); err != nil {
+				return fmt.Errorf("failed to set RPS CPUs: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// SetXPS configures Transmit Packet Steering for the transmit queue
+// of the interface so that it is used by the given CPUs.
+func (i *Interface) SetXPS(queue int, cpus []int) error {
+	mask, err := cpuMask(cpus)
+	if err != nil {
+		return err
+	}
+
+	if i.Node == nil {
+		return fmt.Errorf("interface %s does not belong to a node", i.Name)
+	}
+
+	return runWithSysFS(i.Node.NetNSHandle(), func() error {
+		fn := filepath.Join(sysfsPath, "class/net", i.Name, "queues", fmt.Sprintf("tx-%d", queue), "xps_cpus")
+		if err := os.WriteFile(fn, []byte(mask), 0); err != nil {
+			return fmt.Errorf("failed to set XPS CPUs: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Stats returns the traffic and error counters of the interface.
+// The counters are relative to the last call of ResetStats().
+func (i *Interface) Stats() (nl.LinkStatistics, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return nl.LinkStatistics{}, err
+	}
+
+	stats := link.Attrs().Statistics
+	if stats == nil {
+		return nl.LinkStatistics{}, fmt.Errorf("interface %s has no statistics", i.Name)
+	}
+
+	cur := *stats
+	if i.statsBaseline != nil {
+		cv := reflect.ValueOf(&cur).Elem()
+		bv := reflect.ValueOf(i.statsBaseline).Elem()
+
+		for j := 0; j < cv.NumField(); j++ {
+			cv.Field(j).SetUint(cv.Field(j).Uint() - bv.Field(j).Uint())
+		}
+	}
+
+	return cur, nil
+}
+
+// ResetStats establishes a new baseline for the counters returned by Stats().
+//
+// The kernel does not support clearing the counters of network devices
+// in general and veth devices in particular. Hence, the counters are
+// only reset from the perspective of Stats() by snapshotting their current values.
+// Tools like "ip -s link" still show the absolute counters.
+func (i *Interface) ResetStats() error {
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	stats := link.Attrs().Statistics
+	if stats == nil {
+		return fmt.Errorf("interface %s has no statistics", i.Name)
+	}
+
+	i.statsBaseline = stats
+
+	return nil
+}
+
+// GlobalAddresses returns the addresses of the interface with global scope.
+//
+// Link-local addresses as well as IPv6 addresses which are still tentative
+// or failed the duplicate address detection (DAD) are omitted.
+func (i *Interface) GlobalAddresses() ([]net.IPNet, error) {
+	return i.globalAddresses(nl.FAMILY_ALL)
+}
+
+// WaitForGlobalAddress blocks until the interface has an address of
+// the family (unix.AF_INET or unix.AF_INET6) with global scope, e.g. by
+// stateless address autoconfiguration (SLAAC), and returns it.
+//
+// The timeout is controlled by the deadline of the context.
+func (i *Interface) WaitForGlobalAddress(ctx context.Context, family int) (net.IPNet, error) {
+	if family != unix.AF_INET && family != unix.AF_INET6 {
+		return net.IPNet{}, fmt.Errorf("unsupported address family: %d", family)
+	}
+
+	t := time.NewTicker(10 * time.Millisecond)
+	defer t.Stop()
+
+	for {
+		addrs, err := i.globalAddresses(family)
+		if err != nil {
+			return net.IPNet{}, err
+		} else if len(addrs) > 0 {
+			return addrs[0], nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return net.IPNet{}, fmt.Errorf("interface %s has no global address: %w", i, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// Broadcast returns the broadcast address of the IPv4 address ip of the interface.
+// The returned address is nil if the address has no broadcast address.
+func (i *Interface) Broadcast(ip net.IP) (net.IP, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := i.Node.NetlinkHandle().AddrList(link, nl.FAMILY_V4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if addr.IP.Equal(ip) {
+			return addr.Broadcast, nil
+		}
+	}
+
+	return nil, fmt.Errorf("interface %s has no address %s", i, ip)
+}
+
+func (i *Interface) globalAddresses(family int) ([]net.IPNet, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := i.Node.NetlinkHandle().AddrList(link, family)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+
+	global := []net.IPNet{}
+	for _, addr := range addrs {
+		if addr.Scope != unix.RT_SCOPE_UNIVERSE || addr.Flags&(unix.IFA_F_TENTATIVE|unix.IFA_F_DADFAILED) != 0 {
+			continue
+		}
+
+		global = append(global, *addr.IPNet)
+	}
+
+	return global, nil
+}
+
+// currentLink queries the current state of the link from the namespace of the node
+func (i *Interface) currentLink() (nl.Link, error) {
+	if i.Node == nil {
+		return nil, fmt.Errorf("interface %s does not belong to a node", i.Name)
+	}
+
+	return i.Node.NetlinkHandle().LinkByName(i.Name)
+}
@@ -0,0 +1,63 @@
+package gont
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// SetProxyARP enables or disables answering ARP requests on the interface
+// for addresses which are reachable via other interfaces of the node.
+func (i *Interface) SetProxyARP(enabled bool) error {
+	return i.setIPv4Conf("proxy_arp", sysctlBool(enabled))
+}
+
+// SetProxyARPPVLAN enables or disables answering ARP requests on the interface
+// for addresses which are reachable via the same interface. This is required
+// for private VLANs in which hosts of the same segment can not talk directly.
+func (i *Interface) SetProxyARPPVLAN(enabled bool) error {
+	return i.setIPv4Conf("proxy_arp_pvlan", sysctlBool(enabled))
+}
+
+// SetARPIgnore controls for which target addresses the interface answers ARP requests.
+//
+// Valid levels are:
+//   - 0: reply for any local address configured on any interface (default)
+//   - 1: reply only if the target address is configured on the receiving interface
+//   - 2: like 1 and the sender must be in the subnet of the target address
+//   - 3: do not reply for local addresses configured with scope host
+//   - 8: do not reply at all
+func (i *Interface) SetARPIgnore(level int) error {
+	if (level < 0 || level > 3) && level != 8 {
+		return fmt.Errorf("invalid ARP ignore level: %d", level)
+	}
+
+	return i.setIPv4Conf("arp_ignore", strconv.Itoa(level))
+}
+
+// setIPv4Conf writes the IPv4 parameter param of the interface
+// located at /proc/sys/net/ipv4/conf/<interface>
+func (i *Interface) setIPv4Conf(param, value string) error {
+	if _, err := i.currentLink(); err != nil {
+		return fmt.Errorf("failed to find interface %s: %w", i.Name, err)
+	}
+
+	if i.Node.Network().DisableIPv4 {
+		return fmt.Errorf("failed to set %s: IPv4 is disabled", param)
+	}
+
+	fn := filepath.Join("/proc/sys/net/ipv4/conf", i.Name, param)
+	if err := i.Node.base().WriteProcFS(fn, value); err != nil {
+		return fmt.Errorf("failed to set %s: %w", param, err)
+	}
+
+	return nil
+}
+
+func sysctlBool(b bool) string {
+	if b {
+		return "1"
+	}
+
+	return "0"
+}
@@ -0,0 +1,129 @@
+package gont_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+//  h1 <-> r1 <-> h2
+func TestProxyARP(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		r1     *g.Router
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if r1, err = n.AddRouter("r1"); err != nil {
+		t.Fatalf("Failed to create router: %s", err)
+	}
+
+	// The wide prefix makes h1 resolve addresses behind r1 via ARP
+	if h1, err = n.AddHost("h1",
+		o.Interface("veth0", r1,
+			o.AddressIPv4(10, 0, 0, 2, 16)),
+	); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth-h2", r1,
+			o.AddressIPv4(10, 0, 1, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 1, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect router: %s", err)
+	}
+
+	if err := (&g.Interface{Name: "veth9", Node: r1}).SetProxyARP(true); err == nil {
+		t.Error("Enabled proxy ARP on non-existing interface")
+	}
+
+	if err := r1.Interface("veth-h2").SetARPIgnore(5); err == nil {
+		t.Error("Accepted invalid ARP ignore level")
+	}
+
+	i := r1.Interface("veth-h1")
+
+	fd := listenPacket(t, h1.BaseNode, "veth0")
+	defer unix.Close(fd)
+
+	srcMAC := h1.Interface("veth0").Link.Attrs().HardwareAddr
+
+	eth := &layers.Ethernet{
+		SrcMAC:       srcMAC,
+		DstMAC:       layers.EthernetBroadcast,
+		EthernetType: layers.EthernetTypeARP,
+	}
+
+	arp := &layers.ARP{
+		AddrType:          layers.LinkTypeEthernet,
+		Protocol:          layers.EthernetTypeIPv4,
+		HwAddressSize:     6,
+		ProtAddressSize:   4,
+		Operation:         layers.ARPRequest,
+		SourceHwAddress:   srcMAC,
+		SourceProtAddress: net.IPv4(10, 0, 0, 2).To4(),
+		DstHwAddress:      make([]byte, 6),
+		DstProtAddress:    net.IPv4(10, 0, 1, 2).To4(),
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{}, eth, arp); err != nil {
+		t.Fatalf("Failed to serialize packet: %s", err)
+	}
+
+	request := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	reply := func(p gopacket.Packet) bool {
+		arp, ok := p.Layer(layers.LayerTypeARP).(*layers.ARP)
+		return ok && arp.Operation == layers.ARPReply && bytes.Equal(arp.SourceProtAddress, net.IPv4(10, 0, 1, 2).To4())
+	}
+
+	if err := h1.SendPacket("veth0", request); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	if p := receivePacket(fd, 300*time.Millisecond, reply); p != nil {
+		t.Error("Received ARP reply without proxy ARP")
+	}
+
+	// Proxied replies are delayed randomly by default
+	if err := r1.WriteProcFS("/proc/sys/net/ipv4/neigh/"+i.Name+"/proxy_delay", "0"); err != nil {
+		t.Fatalf("Failed to disable proxy delay: %s", err)
+	}
+
+	if err := i.SetProxyARP(true); err != nil {
+		t.Fatalf("Failed to enable proxy ARP: %s", err)
+	}
+
+	if err := h1.SendPacket("veth0", request); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	p := receivePacket(fd, 2*time.Second, reply)
+	if p == nil {
+		t.Fatal("Did not receive proxied ARP reply")
+	}
+
+	// The router answers with its own address
+	if arp := p.Layer(layers.LayerTypeARP).(*layers.ARP); !bytes.Equal(arp.SourceHwAddress, i.Link.Attrs().HardwareAddr) {
+		t.Errorf("ARP reply does not contain address of router: %s", net.HardwareAddr(arp.SourceHwAddress))
+	}
+}
@@ -0,0 +1,131 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// Channels describes the number of RX, TX and combined channels (queues)
+// of an interface as well as their maxima supported by the driver.
+//
+// Like the speed and offloads, the channels are accessed by the ethtool
+// ioctl rather than the ethtool netlink family. The latter would require
+// a generic netlink implementation of its own and is unavailable in
+// kernels built without CONFIG_ETHTOOL_NETLINK.
+type Channels struct {
+	RX       int
+	TX       int
+	Other    int
+	Combined int
+
+	MaxRX       int
+	MaxTX       int
+	MaxOther    int
+	MaxCombined int
+}
+
+// ethtoolChannels corresponds to struct ethtool_channels
+type ethtoolChannels struct {
+	cmd           uint32
+	maxRx         uint32
+	maxTx         uint32
+	maxOther      uint32
+	maxCombined   uint32
+	rxCount       uint32
+	txCount       uint32
+	otherCount    uint32
+	combinedCount uint32
+}
+
+// Channels returns the current channel configuration of the interface
+// as reported by "ethtool -l".
+func (i *Interface) Channels() (Channels, error) {
+	c, err := i.ethtoolChannels()
+	if err != nil {
+		return Channels{}, err
+	}
+
+	return Channels{
+		RX:          int(c.rxCount),
+		TX:          int(c.txCount),
+		Other:       int(c.otherCount),
+		Combined:    int(c.combinedCount),
+		MaxRX:       int(c.maxRx),
+		MaxTX:       int(c.maxTx),
+		MaxOther:    int(c.maxOther),
+		MaxCombined: int(c.maxCombined),
+	}, nil
+}
+
+// SetChannels changes the number of RX, TX and combined channels
+// of the interface like "ethtool -L".
+//
+// The counts must not exceed the maxima reported by Channels().
+// Drivers which do not support changing their channels return
+// an error wrapping unix.EOPNOTSUPP.
+func (i *Interface) SetChannels(rx, tx, combined int) error {
+	c, err := i.ethtoolChannels()
+	if err != nil {
+		return err
+	}
+
+	for _, ch := range []struct {
+		name       string
+		count, max int
+	}{
+		{"RX", rx, int(c.maxRx)},
+		{"TX", tx, int(c.maxTx)},
+		{"combined", combined, int(c.maxCombined)},
+	} {
+		if ch.count < 0 || ch.count > ch.max {
+			return fmt.Errorf("invalid number of %s channels: %d (max %d)", ch.name, ch.count, ch.max)
+		}
+	}
+
+	i.Node.base().logger.Info("Setting interface channels",
+		zap.String("intf", i.Name),
+		zap.Int("rx", rx),
+		zap.Int("tx", tx),
+		zap.Int("combined", combined))
+
+	c.cmd = unix.ETHTOOL_SCHANNELS
+	c.rxCount = uint32(rx)
+	c.txCount = uint32(tx)
+	c.combinedCount = uint32(combined)
+
+	if err := i.ethtoolIoctl(unsafe.Pointer(&c)); err != nil {
+		return i.channelsError("failed to set channels", err)
+	}
+
+	return nil
+}
+
+func (i *Interface) ethtoolChannels() (ethtoolChannels, error) {
+	c := ethtoolChannels{
+		cmd: unix.ETHTOOL_GCHANNELS,
+	}
+
+	if err := i.ethtoolIoctl(unsafe.Pointer(&c)); err != nil {
+		return c, i.channelsError("failed to get channels", err)
+	}
+
+	return c, nil
+}
+
+// channelsError names the type of the interface if its driver does not support channels
+func (i *Interface) channelsError(msg string, err error) error {
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		typ := "unknown"
+		if link, err := i.currentLink(); err == nil {
+			typ = link.Type()
+		}
+
+		return fmt.Errorf("%s: channels are not supported by %s interfaces: %w", msg, typ, err)
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
@@ -0,0 +1,329 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// gatePriority is the priority of the filter which redirects held packets.
+// It is evaluated after filters with the default priority like those of MirrorTo().
+const gatePriority = 0xfff0
+
+// Gate holds the packets sent by an interface until they are released
+// one by one. See Interface.Gate().
+type Gate struct {
+	iface *Interface
+	node  *BaseNode
+
+	// tap is the device to which held packets are redirected
+	tap     *os.File
+	tapName string
+
+	// fd is a packet socket which transmits released packets
+	// bypassing the qdiscs and filters of the interface
+	fd int
+	sa *unix.SockaddrLinklayer
+
+	lock    sync.Mutex
+	holding bool
+	held    [][]byte
+	credit  int
+
+	done chan struct{}
+}
+
+// Gate returns a controller for holding the packets sent by the interface
+// and releasing them on demand. This enables tests to control the order and
+// timing in which packets leave a node.
+//
+// Held packets are redirected to a tap device by a tc filter on the egress
+// of the interface and buffered in the calling process. Released packets are
+// transmitted unmodified out of the interface without passing its qdiscs.
+// Packets received by the interface are not affected.
+//
+// The gate is initially open. It is closed together with the node.
+func (i *Interface) Gate() (*Gate, error) {
+	n := i.Node.base()
+
+	link, err := i.currentLink()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gate{
+		iface:   i,
+		node:    n,
+		tapName: fmt.Sprintf("gate%d", link.Attrs().Index),
+		done:    make(chan struct{}),
+	}
+
+	if g.tap, err = n.openTap(g.tapName); err != nil {
+		return nil, fmt.Errorf("failed to create tap device: %w", err)
+	}
+
+	if err := g.setupTap(link); err != nil {
+		g.tap.Close()
+		return nil, err
+	}
+
+	if g.fd, g.sa, err = n.openPacketSocket(i.Name); err != nil {
+		g.tap.Close()
+		return nil, err
+	}
+
+	if err := unix.SetsockoptInt(g.fd, unix.SOL_PACKET, unix.PACKET_QDISC_BYPASS, 1); err != nil {
+		g.tap.Close()
+		unix.Close(g.fd)
+		return nil, fmt.Errorf("failed to bypass qdiscs: %w", err)
+	}
+
+	go g.run()
+
+	n.gates = append(n.gates, g)
+
+	return g, nil
+}
+
+// Hold closes the gate. Subsequent packets are held until they are released.
+func (g *Gate) Hold() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.holding {
+		return nil
+	}
+
+	link, err := g.iface.currentLink()
+	if err != nil {
+		return err
+	}
+
+	tap, err := g.node.nlHandle.LinkByName(g.tapName)
+	if err != nil {
+		return fmt.Errorf("failed to find tap device: %w", err)
+	}
+
+	if err := g.iface.addClsact(link); err != nil {
+		return err
+	}
+
+	a := nl.NewMirredAction(tap.Attrs().Index)
+	a.MirredAction = nl.TCA_EGRESS_REDIR
+	a.Attrs().Action = nl.TC_ACT_STOLEN
+
+	f := &U32Filter{
+		TCFilterAttrs: TCFilterAttrs{
+			Parent:   nl.HANDLE_MIN_EGRESS,
+			Priority: gatePriority,
+			Actions:  []nl.Action{a},
+		},
+	}
+
+	if err := f.add(g.iface, link); err != nil {
+		return fmt.Errorf("failed to add gate filter: %w", err)
+	}
+
+	g.node.logger.Debug("Holding packets",
+		zap.String("intf", g.iface.Name))
+
+	g.holding = true
+	g.credit = 0
+
+	return nil
+}
+
+// Release lets the next n packets pass the gate.
+//
+// Held packets are released first in the order in which they have been sent.
+// If less than n packets are held, subsequent packets pass the gate
+// until n packets have been released in total.
+func (g *Gate) Release(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid number of packets: %d", n)
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.credit += n
+
+	return g.flush()
+}
+
+// Open removes the gate and releases all held packets.
+// A subsequent call to Hold() closes the gate again.
+func (g *Gate) Open() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if !g.holding {
+		return nil
+	}
+
+	link, err := g.iface.currentLink()
+	if err != nil {
+		return err
+	}
+
+	if err := g.node.nlHandle.FilterDel(&nl.U32{
+		FilterAttrs: nl.FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    nl.HANDLE_MIN_EGRESS,
+			Priority:  gatePriority,
+			Protocol:  unix.ETH_P_ALL,
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to delete gate filter: %w", err)
+	}
+
+	g.holding = false
+	g.credit = len(g.held)
+
+	return g.flush()
+}
+
+// Held returns the number of packets which are currently held
+func (g *Gate) Held() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return len(g.held)
+}
+
+// Close opens the gate and removes its tap device.
+func (g *Gate) Close() error {
+	for i, h := range g.node.gates {
+		if h == g {
+			g.node.gates = append(g.node.gates[:i], g.node.gates[i+1:]...)
+			break
+		}
+	}
+
+	err := g.Open()
+
+	// Closing the tap device also removes it
+	if cerr := g.tap.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	<-g.done
+
+	unix.Close(g.fd)
+
+	return err
+}
+
+// run reads the packets which have been redirected to the tap device
+func (g *Gate) run() {
+	defer close(g.done)
+
+	buf := make([]byte, 1<<16)
+	for {
+		n, err := g.tap.Read(buf)
+		if err != nil {
+			return
+		}
+
+		frame := make([]byte, n)
+		copy(frame, buf[:n])
+
+		g.lock.Lock()
+
+		g.held = append(g.held, frame)
+		if !g.holding {
+			g.credit++
+		}
+
+		if err := g.flush(); err != nil {
+			g.node.logger.Warn("Failed to release packet", zap.Error(err))
+		}
+
+		g.lock.Unlock()
+	}
+}
+
+// flush transmits held packets while there is credit left.
+// The lock must be held by the caller.
+func (g *Gate) flush() error {
+	for g.credit > 0 && len(g.held) > 0 {
+		frame := g.held[0]
+		g.held = g.held[1:]
+		g.credit--
+
+		if err := unix.Sendto(g.fd, frame, 0, g.sa); err != nil {
+			return fmt.Errorf("failed to send packet: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// setupTap brings the tap device up with the MTU of the link
+// and disables IPv6 to keep it from sending packets on its own.
+func (g *Gate) setupTap(link nl.Link) error {
+	tap, err := g.node.nlHandle.LinkByName(g.tapName)
+	if err != nil {
+		return fmt.Errorf("failed to find tap device: %w", err)
+	}
+
+	fn := filepath.Join("/proc/sys/net/ipv6/conf", g.tapName, "disable_ipv6")
+	if err := g.node.WriteProcFS(fn, "1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to disable IPv6: %w", err)
+	}
+
+	if err := g.node.nlHandle.LinkSetMTU(tap, link.Attrs().MTU); err != nil {
+		return fmt.Errorf("failed to set MTU: %w", err)
+	}
+
+	if err := g.node.nlHandle.LinkSetUp(tap); err != nil {
+		return fmt.Errorf("failed to set tap device up: %w", err)
+	}
+
+	return nil
+}
+
+// openTap creates a non-persistent tap device in the namespace of the node
+func (n *BaseNode) openTap(name string) (*os.File, error) {
+	var fd int
+	if err := n.RunFunc(func() (err error) {
+		if fd, err = unix.Open("/dev/net/tun", unix.O_RDWR|unix.O_CLOEXEC|unix.O_NONBLOCK, 0); err != nil {
+			return err
+		}
+
+		ifr, err := unix.NewIfreq(name)
+		if err != nil {
+			unix.Close(fd)
+			return err
+		}
+
+		ifr.SetUint16(unix.IFF_TAP | unix.IFF_NO_PI)
+
+		if err := unix.IoctlIfreq(fd, unix.TUNSETIFF, ifr); err != nil {
+			unix.Close(fd)
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return os.NewFile(uintptr(fd), "/dev/net/tun"), nil
+}
+
+func (n *BaseNode) closeGates() error {
+	for len(n.gates) > 0 {
+		if err := n.gates[0].Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
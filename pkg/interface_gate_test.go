@@ -0,0 +1,126 @@
+package gont_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+//  h1 (gate) -> h2
+func TestGate(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	// Avoid unsolicited IPv6 packets passing the gate
+	if err := h1.DisableIPv6(); err != nil {
+		t.Fatalf("Failed to disable IPv6: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	src, dst := h1.Interface("veth0"), h2.Interface("veth0")
+
+	gate, err := src.Gate()
+	if err != nil {
+		t.Fatalf("Failed to create gate: %s", err)
+	}
+
+	fd := listenPacket(t, h2.BaseNode, "veth0")
+	defer unix.Close(fd)
+
+	// receive returns the payloads of the frames received by h2 within the timeout
+	receive := func(timeout time.Duration) []string {
+		payloads := []string{}
+		receivePacket(fd, timeout, func(p gopacket.Packet) bool {
+			// Skip ICMP errors of h2 which quote our packets
+			if udp, ok := p.TransportLayer().(*layers.UDP); ok && p.Layer(layers.LayerTypeICMPv4) == nil {
+				payloads = append(payloads, string(udp.Payload))
+			}
+			return false
+		})
+		return payloads
+	}
+
+	send := func(payload string) {
+		t.Helper()
+
+		if err := h1.SendPacket("veth0", udpFrame(t, src, dst, net.IPv4(10, 0, 0, 1), net.IPv4(10, 0, 0, 2), payload)); err != nil {
+			t.Fatalf("Failed to send packet: %s", err)
+		}
+	}
+
+	// The gate is initially open
+	send("open")
+	if p := receive(200 * time.Millisecond); len(p) != 1 || p[0] != "open" {
+		t.Errorf("Packet did not pass open gate: %v", p)
+	}
+
+	if err := gate.Hold(); err != nil {
+		t.Fatalf("Failed to hold: %s", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		send(fmt.Sprint(i))
+	}
+
+	if p := receive(200 * time.Millisecond); len(p) != 0 {
+		t.Errorf("Packets passed closed gate: %v", p)
+	}
+
+	if held := gate.Held(); held != 5 {
+		t.Errorf("Invalid number of held packets: %d", held)
+	}
+
+	if err := gate.Release(2); err != nil {
+		t.Fatalf("Failed to release: %s", err)
+	}
+
+	if p := receive(200 * time.Millisecond); len(p) != 2 || p[0] != "0" || p[1] != "1" {
+		t.Errorf("Invalid released packets: %v", p)
+	}
+
+	// Opening the gate releases the remaining packets
+	if err := gate.Open(); err != nil {
+		t.Fatalf("Failed to open: %s", err)
+	}
+
+	send("5")
+
+	if p := receive(200 * time.Millisecond); len(p) != 4 || p[0] != "2" || p[3] != "5" {
+		t.Errorf("Invalid released packets: %v", p)
+	}
+
+	if err := gate.Close(); err != nil {
+		t.Errorf("Failed to close gate: %s", err)
+	}
+}
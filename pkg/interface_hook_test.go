@@ -0,0 +1,94 @@
+package gont_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+)
+
+// TestInterfaceHooks adds a custom address from within a post-up hook
+//
+//  h1 <-> h2
+func TestInterfaceHooks(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	wasUp := true
+	addr := &nl.Addr{
+		IPNet: &net.IPNet{
+			IP:   net.IPv4(10, 0, 1, 1),
+			Mask: net.CIDRMask(24, 32),
+		},
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.WithPreUp(func(i *g.Interface) error {
+				// The hook runs within the namespace of the node
+				link, err := nl.LinkByName(i.Name)
+				if err != nil {
+					return err
+				}
+
+				wasUp = link.Attrs().Flags&net.FlagUp != 0
+
+				return nil
+			}),
+			o.WithPostUp(func(i *g.Interface) error {
+				link, err := nl.LinkByName(i.Name)
+				if err != nil {
+					return err
+				}
+
+				return nl.AddrAdd(link, addr)
+			})),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if wasUp {
+		t.Error("Interface was up before pre-up hook")
+	}
+
+	addrs, err := h1.NetlinkHandle().AddrList(h1.Interface("veth0").Link, nl.FAMILY_V4)
+	if err != nil {
+		t.Fatalf("Failed to list addresses: %s", err)
+	}
+
+	if len(addrs) != 1 || !addrs[0].IP.Equal(addr.IP) {
+		t.Errorf("Post-up hook did not add address: %v", addrs)
+	}
+
+	// Hook errors abort the configuration
+	errHook := errors.New("hook failed")
+	if err := n.AddLink(
+		o.Interface("veth1", h1,
+			o.WithPostUp(func(i *g.Interface) error {
+				return errHook
+			})),
+		o.Interface("veth1", h2),
+	); !errors.Is(err, errHook) {
+		t.Errorf("Hook error has not been propagated: %v", err)
+	}
+}
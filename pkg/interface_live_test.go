@@ -0,0 +1,649 @@
+package gont_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// TestAddInterfaceLive adds a veth pair to two running hosts
+// and immediately pings over it.
+//
+//  h1 <-> h2
+func TestAddInterfaceLive(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	veth := &nl.Veth{
+		LinkAttrs: nl.LinkAttrs{
+			Name: "gont-live-a",
+		},
+		PeerName: "gont-live-b",
+	}
+
+	if err := nl.LinkAdd(veth); err != nil {
+		t.Fatalf("Failed to add veth pair: %s", err)
+	}
+
+	peer, err := nl.LinkByName("gont-live-b")
+	if err != nil {
+		t.Fatalf("Failed to find veth peer: %s", err)
+	}
+
+	i1 := o.Interface("veth0",
+		o.AddressIPv4(10, 0, 0, 1, 24))
+	i1.Link = veth
+
+	if err := h1.AddInterfaceLive(i1); err != nil {
+		t.Fatalf("Failed to add interface: %s", err)
+	}
+
+	i2 := o.Interface("veth0",
+		o.AddressIPv4(10, 0, 0, 2, 24))
+	i2.Link = peer
+
+	if err := h2.AddInterfaceLive(i2); err != nil {
+		t.Fatalf("Failed to add interface: %s", err)
+	}
+
+	if h1.Interface("veth0") == nil {
+		t.Fatal("Interface is missing from the node")
+	}
+
+	if _, err := h1.Ping(h2); err != nil {
+		t.Errorf("Failed to ping: %s", err)
+	}
+
+	// Name collisions
+	if err := h1.AddInterfaceLive(o.Interface("veth0")); err == nil {
+		t.Error("Added interface with duplicate name")
+	}
+}
+
+// TestAddInterfaceLiveRollback adds an interface with an invalid MTU
+// and checks that its link is deleted again.
+func TestAddInterfaceLiveRollback(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	veth := &nl.Veth{
+		LinkAttrs: nl.LinkAttrs{
+			Name: "gont-live-a",
+		},
+		PeerName: "gont-live-b",
+	}
+
+	if err := nl.LinkAdd(veth); err != nil {
+		t.Fatalf("Failed to add veth pair: %s", err)
+	}
+	defer func() {
+		if link, err := nl.LinkByName("gont-live-b"); err == nil {
+			nl.LinkDel(link)
+		}
+	}()
+
+	i := o.Interface("veth0",
+		o.MTU(1<<20))
+	i.Link = veth
+
+	if err := h1.AddInterfaceLive(i); err == nil {
+		t.Fatal("Added interface with invalid MTU")
+	}
+
+	if h1.Interface("veth0") != nil {
+		t.Error("Interface has not been removed from the node")
+	}
+
+	if _, err := h1.NetlinkHandle().LinkByName("veth0"); err == nil {
+		t.Error("Link has not been deleted from the node")
+	}
+
+	// Deleting one end also removes its peer
+	if _, err := nl.LinkByName("gont-live-b"); err == nil {
+		t.Error("Peer of link has not been deleted")
+	}
+}
+
+// TestDelInterface removes an interface from a running host
+//
+//  h1 <-> h2
+func TestDelInterface(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if err := h1.AddRoute(&nl.Route{
+		Dst: &net.IPNet{
+			IP:   net.IPv4(10, 1, 0, 0),
+			Mask: net.CIDRMask(16, 32),
+		},
+		Gw: net.IPv4(10, 0, 0, 2),
+	}); err != nil {
+		t.Fatalf("Failed to add route: %s", err)
+	}
+
+	if err := h1.DelInterface("veth0"); err != nil {
+		t.Fatalf("Failed to delete interface: %s", err)
+	}
+
+	if h1.Interface("veth0") != nil {
+		t.Error("Interface is still listed by the node")
+	}
+
+	if _, err := h1.NetlinkHandle().LinkByName("veth0"); err == nil {
+		t.Error("Interface still exists in namespace")
+	}
+
+	routes, err := h1.NetlinkHandle().RouteList(nil, nl.FAMILY_V4)
+	if err != nil {
+		t.Fatalf("Failed to list routes: %s", err)
+	}
+
+	if len(routes) > 0 {
+		t.Errorf("Routes via deleted interface remain: %v", routes)
+	}
+
+	if err := h1.DelInterface("veth0"); err == nil {
+		t.Error("Deleted non-existing interface")
+	}
+
+	// The peer has been removed by the kernel
+	if err := h2.DelInterface("veth0"); err != nil {
+		t.Errorf("Failed to delete peer interface: %s", err)
+	}
+}
+
+// TestAdoptInterface moves an interface of the host into a node and back
+func TestAdoptInterface(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	// A veth pair stands in for a physical interface
+	veth := &nl.Veth{
+		LinkAttrs: nl.LinkAttrs{
+			Name: "gont-adopt",
+		},
+		PeerName: "gont-adopt-p",
+	}
+
+	if err := nl.LinkAdd(veth); err != nil {
+		t.Fatalf("Failed to add veth pair: %s", err)
+	}
+	defer nl.LinkDel(veth)
+
+	if _, err := h1.AdoptInterface("gont-adopt",
+		o.AddressIPv4(10, 0, 0, 1, 24)); err != nil {
+		t.Fatalf("Failed to adopt interface: %s", err)
+	}
+
+	if _, err := nl.LinkByName("gont-adopt"); err == nil {
+		t.Error("Interface is still present in host namespace")
+	}
+
+	if _, err := h1.NetlinkHandle().LinkByName("gont-adopt"); err != nil {
+		t.Errorf("Interface is missing in node namespace: %s", err)
+	}
+
+	if err := n.Teardown(); err != nil {
+		t.Fatalf("Failed to teardown network: %s", err)
+	}
+
+	if _, err := nl.LinkByName("gont-adopt"); err != nil {
+		t.Errorf("Interface has not been restored: %s", err)
+	}
+}
+
+// TestAdoptLink manages links which have been created out-of-band
+func TestAdoptLink(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	// A veth pair stands in for the tap device of a virtual machine
+	for _, node := range []*g.BaseNode{h1.BaseNode, n.HostNode.BaseNode} {
+		veth := &nl.Veth{
+			LinkAttrs: nl.LinkAttrs{
+				Name: "gont-vm",
+			},
+			PeerName: "gont-vm-p",
+		}
+
+		if err := node.NetlinkHandle().LinkAdd(veth); err != nil {
+			t.Fatalf("Failed to add veth pair: %s", err)
+		}
+
+		for _, name := range []string{"gont-vm", "gont-vm-p"} {
+			link, err := node.NetlinkHandle().LinkByName(name)
+			if err != nil {
+				t.Fatalf("Failed to find link: %s", err)
+			}
+
+			if err := node.NetlinkHandle().LinkSetUp(link); err != nil {
+				t.Fatalf("Failed to set link up: %s", err)
+			}
+		}
+	}
+	defer nl.LinkDel(&nl.Veth{LinkAttrs: nl.LinkAttrs{Name: "gont-vm"}})
+
+	i, err := h1.AdoptLink("gont-vm")
+	if err != nil {
+		t.Fatalf("Failed to adopt link: %s", err)
+	}
+
+	if h1.Interface("gont-vm") != i || i.Node != h1 {
+		t.Fatal("Adopted link is not an interface of the node")
+	}
+
+	if _, err := h1.AdoptLink("gont-vm"); err == nil {
+		t.Error("Adopting the link twice succeeded")
+	}
+
+	if _, err := h1.AdoptLink("gont-missing"); err == nil {
+		t.Error("Adopting a missing link succeeded")
+	}
+
+	fd := listenPacket(t, h1.BaseNode, "gont-vm")
+	defer unix.Close(fd)
+
+	if err := h1.SendPacket("gont-vm", udpFrame(t, i, i,
+		net.IPv4(10, 0, 0, 1), net.IPv4(10, 0, 0, 2), "adopted")); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	if p := receivePacket(fd, time.Second, func(p gopacket.Packet) bool {
+		return p.ApplicationLayer() != nil && bytes.Equal(p.ApplicationLayer().Payload(), []byte("adopted"))
+	}); p == nil {
+		t.Error("Failed to capture packet on adopted link")
+	}
+
+	// The namespace of the host persists the teardown
+	if _, err := n.HostNode.AdoptLink("gont-vm"); err != nil {
+		t.Fatalf("Failed to adopt link: %s", err)
+	}
+
+	if err := n.Teardown(); err != nil {
+		t.Fatalf("Failed to teardown network: %s", err)
+	}
+
+	if _, err := nl.LinkByName("gont-vm"); err == nil {
+		t.Error("Adopted link has not been deleted")
+	}
+}
+
+func TestInterfaceLinkType(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	// TUN devices are created in the namespace of the calling thread
+	if err := h1.RunFunc(func() error {
+		return nl.LinkAdd(&nl.Tuntap{
+			LinkAttrs: nl.LinkAttrs{
+				Name: "tun0",
+			},
+			Mode:  nl.TUNTAP_MODE_TUN,
+			Flags: nl.TUNTAP_DEFAULTS,
+		})
+	}); err != nil {
+		t.Fatalf("Failed to add TUN device: %s", err)
+	}
+
+	expected := map[string]layers.LinkType{
+		"lo":    layers.LinkTypeEthernet,
+		"veth0": layers.LinkTypeEthernet,
+		"tun0":  layers.LinkTypeRaw,
+	}
+
+	// GRE tunnels are only checked if the kernel supports them
+	for _, link := range []nl.Link{
+		&nl.Gretun{
+			LinkAttrs: nl.LinkAttrs{Name: "gre0"},
+			Local:     net.IPv4(10, 0, 0, 1),
+			Remote:    net.IPv4(10, 0, 0, 2),
+		},
+		&nl.Gretun{
+			LinkAttrs: nl.LinkAttrs{Name: "ip6gre0"},
+			Local:     net.ParseIP("fc::1"),
+			Remote:    net.ParseIP("fc::2"),
+		},
+		&nl.Ip6tnl{
+			LinkAttrs: nl.LinkAttrs{Name: "ip6tnl0"},
+			Local:     net.ParseIP("fc::1"),
+			Remote:    net.ParseIP("fc::2"),
+		},
+	} {
+		if err := h1.NetlinkHandle().LinkAdd(link); errors.Is(err, unix.EOPNOTSUPP) {
+			t.Logf("Kernel lacks support for %s tunnels", link.Type())
+			continue
+		} else if err != nil {
+			t.Fatalf("Failed to add %s tunnel: %s", link.Type(), err)
+		}
+
+		expected[link.Attrs().Name] = layers.LinkTypeRaw
+	}
+
+	for name, expected := range expected {
+		i := &g.Interface{
+			Name: name,
+			Node: h1,
+		}
+
+		if lt, err := i.LinkType(); err != nil {
+			t.Errorf("Failed to get link type of %s: %s", name, err)
+		} else if lt != expected {
+			t.Errorf("Unexpected link type of %s: %s != %s", name, lt, expected)
+		}
+	}
+}
+
+func TestInterfaceBroadcast(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if _, err := n.AddHost("h2",
+		o.Interface("veth0", h1)); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	i := h1.Interface("veth-h2")
+
+	if err := h1.LinkAddAddress(i.Name, net.IPNet(o.AddressIP("fc::1/64")),
+		o.WithBroadcast(net.IPv4(10, 0, 0, 255))); err == nil {
+		t.Error("Accepted broadcast address for IPv6 address")
+	}
+
+	for _, tc := range []struct {
+		addr      string
+		opts      []g.Option
+		broadcast net.IP
+	}{
+		{"10.0.0.1/24", nil, net.IPv4(10, 0, 0, 255)},
+		{"10.0.1.1/24", []g.Option{o.WithBroadcast(net.IPv4(10, 0, 1, 127))}, net.IPv4(10, 0, 1, 127)},
+		{"10.0.2.1/31", nil, nil},
+	} {
+		addr := net.IPNet(o.AddressIP(tc.addr))
+
+		if err := h1.LinkAddAddress(i.Name, addr, tc.opts...); err != nil {
+			t.Fatalf("Failed to add address %s: %s", tc.addr, err)
+		}
+
+		brd, err := i.Broadcast(addr.IP)
+		if err != nil {
+			t.Fatalf("Failed to get broadcast address: %s", err)
+		}
+
+		if !brd.Equal(tc.broadcast) {
+			t.Errorf("Invalid broadcast address for %s: %s != %s", tc.addr, brd, tc.broadcast)
+		}
+	}
+
+	out, _, err := h1.Run("ip", "-4", "addr", "show", "dev", i.Name)
+	if err != nil {
+		t.Fatalf("Failed to show addresses: %s", err)
+	}
+
+	if !strings.Contains(string(out), "inet 10.0.1.1/24 brd 10.0.1.127") {
+		t.Errorf("Broadcast address is missing: %s", out)
+	}
+}
+
+func TestInterfaceSpeed(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	veth := h1.Interface("veth0")
+
+	speed, err := veth.Speed()
+	if err != nil {
+		t.Fatalf("Failed to get speed: %s", err)
+	}
+
+	if err := veth.SetSpeed(100); !errors.Is(err, unix.EOPNOTSUPP) {
+		t.Errorf("Unexpected error for setting speed of veth: %v", err)
+	}
+
+	if s, err := veth.Speed(); err != nil || s != speed {
+		t.Errorf("Speed of veth changed: %d != %d (%v)", s, speed, err)
+	}
+
+	// TUN devices are created in the namespace of the calling thread
+	if err := h1.RunFunc(func() error {
+		return nl.LinkAdd(&nl.Tuntap{
+			LinkAttrs: nl.LinkAttrs{
+				Name: "tun0",
+			},
+			Mode:  nl.TUNTAP_MODE_TUN,
+			Flags: nl.TUNTAP_DEFAULTS,
+		})
+	}); err != nil {
+		t.Fatalf("Failed to add TUN device: %s", err)
+	}
+
+	tun := &g.Interface{
+		Name: "tun0",
+		Node: h1,
+	}
+
+	if err := tun.SetSpeed(0); err == nil {
+		t.Error("Accepted invalid speed")
+	}
+
+	if err := tun.SetSpeed(2500); err != nil {
+		t.Fatalf("Failed to set speed: %s", err)
+	}
+
+	if s, err := tun.Speed(); err != nil || s != 2500 {
+		t.Errorf("Invalid speed: %d (%v)", s, err)
+	}
+}
+
+func TestInterfaceChannels(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.NumRxQueues(4),
+			o.NumTxQueues(4)),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	veth := h1.Interface("veth0")
+
+	ch, err := veth.Channels()
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		t.Skip("Kernel lacks support for channels of veth interfaces")
+	} else if err != nil {
+		t.Fatalf("Failed to get channels: %s", err)
+	}
+
+	t.Logf("Channels: %+v", ch)
+
+	if ch.MaxRX != 4 || ch.MaxTX != 4 {
+		t.Fatalf("Unexpected maximum number of channels: %+v", ch)
+	}
+
+	if err := veth.SetChannels(ch.MaxRX+1, 1, 0); err == nil {
+		t.Error("Accepted too many channels")
+	}
+
+	if err := veth.SetChannels(2, 3, 0); err != nil {
+		t.Fatalf("Failed to set channels: %s", err)
+	}
+
+	if ch, err := veth.Channels(); err != nil {
+		t.Fatalf("Failed to get channels: %s", err)
+	} else if ch.RX != 2 || ch.TX != 3 {
+		t.Errorf("Channels have not been changed: %+v", ch)
+	}
+
+	// The loopback driver lacks support for channels
+	lo := &g.Interface{
+		Name: "lo",
+		Node: h1,
+	}
+
+	if _, err := lo.Channels(); !errors.Is(err, unix.EOPNOTSUPP) {
+		t.Errorf("Unexpected error for channels of loopback interface: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// MirrorDirection selects the packets of an interface which are mirrored
+// or redirected by Interface.MirrorTo() and Interface.RedirectTo().
+type MirrorDirection int
+
+const (
+	MirrorIngress MirrorDirection = 1 << iota
+	MirrorEgress
+
+	MirrorBoth = MirrorIngress | MirrorEgress
+)
+
+// MirrorTo sends copies of the packets received (ingress) and/or sent (egress)
+// by the interface out of the interface dst like a switch with a SPAN port.
+// The original packets are processed as usual.
+//
+// Both interfaces must belong to the same node.
+// A clsact qdisc is added to the interface if it does not exist yet.
+func (i *Interface) MirrorTo(dst *Interface, dir MirrorDirection) error {
+	return i.mirred(dst, dir, nl.TCA_EGRESS_MIRROR, nl.TC_ACT_PIPE)
+}
+
+// RedirectTo sends the packets received (ingress) and/or sent (egress)
+// by the interface out of the interface dst instead of processing them.
+//
+// Both interfaces must belong to the same node.
+// A clsact qdisc is added to the interface if it does not exist yet.
+func (i *Interface) RedirectTo(dst *Interface, dir MirrorDirection) error {
+	return i.mirred(dst, dir, nl.TCA_EGRESS_REDIR, nl.TC_ACT_STOLEN)
+}
+
+func (i *Interface) mirred(dst *Interface, dir MirrorDirection, act nl.MirredAct, verdict nl.TcAct) error {
+	if dir&MirrorBoth == 0 || dir&^MirrorBoth != 0 {
+		return fmt.Errorf("invalid direction: %d", dir)
+	}
+
+	if dst == nil || dst.Node != i.Node {
+		return errors.New("destination interface must belong to the same node")
+	}
+
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	dstLink, err := dst.currentLink()
+	if err != nil {
+		return err
+	}
+
+	if err := i.addClsact(link); err != nil {
+		return err
+	}
+
+	for _, d := range []struct {
+		dir    MirrorDirection
+		parent uint32
+	}{
+		{MirrorIngress, nl.HANDLE_MIN_INGRESS},
+		{MirrorEgress, nl.HANDLE_MIN_EGRESS},
+	} {
+		if dir&d.dir == 0 {
+			continue
+		}
+
+		a := nl.NewMirredAction(dstLink.Attrs().Index)
+		a.MirredAction = act
+		a.Attrs().Action = verdict
+
+		f := &U32Filter{
+			TCFilterAttrs: TCFilterAttrs{
+				Parent:  d.parent,
+				Actions: []nl.Action{a},
+			},
+		}
+
+		if err := f.add(i, link); err != nil {
+			return fmt.Errorf("failed to add mirred filter: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// addClsact adds a clsact qdisc to the interface unless it already exists
+func (i *Interface) addClsact(link nl.Link) error {
+	q := &nl.GenericQdisc{
+		QdiscAttrs: nl.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    nl.MakeHandle(0xffff, 0),
+			Parent:    nl.HANDLE_CLSACT,
+		},
+		QdiscType: "clsact",
+	}
+
+	if err := i.Node.NetlinkHandle().QdiscAdd(q); err != nil && !errors.Is(err, unix.EEXIST) {
+		return fmt.Errorf("failed to add clsact qdisc: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package gont_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+// udpFrame builds a UDP datagram to port 5000 which is sent from src to dst
+func udpFrame(t *testing.T, src, dst *g.Interface, srcIP, dstIP net.IP, payload string) gopacket.Packet {
+	eth := &layers.Ethernet{
+		SrcMAC:       src.Link.Attrs().HardwareAddr,
+		DstMAC:       dst.Link.Attrs().HardwareAddr,
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolUDP,
+		SrcIP:    srcIP.To4(),
+		DstIP:    dstIP.To4(),
+	}
+
+	udp := &layers.UDP{
+		SrcPort: 5000,
+		DstPort: 5000,
+	}
+
+	if err := udp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatalf("Failed to set network layer: %s", err)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{
+		ComputeChecksums: true,
+		FixLengths:       true,
+	}, eth, ip, udp, gopacket.Payload(payload)); err != nil {
+		t.Fatalf("Failed to serialize packet: %s", err)
+	}
+
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+}
+
+//  h1 <-> r1 <-> h2
+//         ^
+//         |
+//         v
+//         m1
+func TestMirror(t *testing.T) {
+	var (
+		err        error
+		n          *g.Network
+		r1         *g.Router
+		h1, h2, m1 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if r1, err = n.AddRouter("r1"); err != nil {
+		t.Fatalf("Failed to create router: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if m1, err = n.AddHost("m1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth-h1", r1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect router: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth-h2", r1,
+			o.AddressIPv4(10, 0, 1, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 1, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect router: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("mon0", r1),
+		o.Interface("veth0", m1),
+	); err != nil {
+		t.Fatalf("Failed to connect monitor: %s", err)
+	}
+
+	mon := r1.Interface("mon0")
+
+	if err := r1.Interface("veth-h1").MirrorTo(h1.Interface("veth0"), g.MirrorIngress); err == nil {
+		t.Error("Mirrored to interface of other node")
+	}
+
+	if err := r1.Interface("veth-h1").MirrorTo(mon, 0); err == nil {
+		t.Error("Accepted invalid direction")
+	}
+
+	var conn net.PacketConn
+	if err := r1.RunFunc(func() (err error) {
+		conn, err = net.ListenPacket("udp", ":5000")
+		return err
+	}); err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer conn.Close()
+
+	received := func() string {
+		buf := make([]byte, 1500)
+
+		conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return ""
+		}
+
+		return string(buf[:n])
+	}
+
+	fd := listenPacket(t, m1.BaseNode, "veth0")
+	defer unix.Close(fd)
+
+	copied := func(payload string) bool {
+		return receivePacket(fd, time.Second, func(p gopacket.Packet) bool {
+			return p.ApplicationLayer() != nil && bytes.Equal(p.ApplicationLayer().Payload(), []byte(payload))
+		}) != nil
+	}
+
+	if err := r1.Interface("veth-h1").MirrorTo(mon, g.MirrorBoth); err != nil {
+		t.Fatalf("Failed to mirror interface: %s", err)
+	}
+
+	// Ingress
+	if err := h1.SendPacket("veth0", udpFrame(t, h1.Interface("veth0"), r1.Interface("veth-h1"),
+		net.IPv4(10, 0, 0, 2), net.IPv4(10, 0, 0, 1), "ingress")); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	if !copied("ingress") {
+		t.Error("Monitor did not receive copy of ingress packet")
+	}
+
+	if payload := received(); payload != "ingress" {
+		t.Errorf("Router did not receive mirrored packet: '%s'", payload)
+	}
+
+	// Egress
+	if err := r1.SendPacket("veth-h1", udpFrame(t, r1.Interface("veth-h1"), h1.Interface("veth0"),
+		net.IPv4(10, 0, 0, 1), net.IPv4(10, 0, 0, 2), "egress")); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	if !copied("egress") {
+		t.Error("Monitor did not receive copy of egress packet")
+	}
+
+	// Redirect
+	if err := r1.Interface("veth-h2").RedirectTo(mon, g.MirrorIngress); err != nil {
+		t.Fatalf("Failed to redirect interface: %s", err)
+	}
+
+	if err := h2.SendPacket("veth0", udpFrame(t, h2.Interface("veth0"), r1.Interface("veth-h2"),
+		net.IPv4(10, 0, 1, 2), net.IPv4(10, 0, 1, 1), "redirect")); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	if !copied("redirect") {
+		t.Error("Monitor did not receive redirected packet")
+	}
+
+	if payload := received(); payload != "" {
+		t.Errorf("Router received redirected packet: '%s'", payload)
+	}
+}
@@ -0,0 +1,117 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	speedUnknown  = 0xffffffff // SPEED_UNKNOWN
+	duplexFull    = 0x01       // DUPLEX_FULL
+	duplexUnknown = 0xff       // DUPLEX_UNKNOWN
+)
+
+// ethtoolCmd corresponds to the legacy struct ethtool_cmd
+type ethtoolCmd struct {
+	cmd           uint32
+	supported     uint32
+	advertising   uint32
+	speed         uint16
+	duplex        uint8
+	port          uint8
+	phyAddress    uint8
+	transceiver   uint8
+	autoneg       uint8
+	mdioSupport   uint8
+	maxtxpkt      uint32
+	maxrxpkt      uint32
+	speedHi       uint16
+	ethTpMdix     uint8
+	ethTpMdixCtrl uint8
+	lpAdvertising uint32
+	reserved      [2]uint32
+}
+
+func (c *ethtoolCmd) getSpeed() uint32 {
+	return uint32(c.speedHi)<<16 | uint32(c.speed)
+}
+
+func (c *ethtoolCmd) setSpeed(s uint32) {
+	c.speed = uint16(s)
+	c.speedHi = uint16(s >> 16)
+}
+
+// Speed returns the link speed of the interface in Mbit/s as reported
+// to ethtool and /sys/class/net/<interface>/speed.
+//
+// Zero is returned if the speed is unknown, e.g. because the link is down.
+func (i *Interface) Speed() (int, error) {
+	var c ethtoolCmd
+	if err := i.ethtoolCmd(unix.ETHTOOL_GSET, &c); err != nil {
+		return 0, fmt.Errorf("failed to get link settings: %w", err)
+	}
+
+	if s := c.getSpeed(); s != speedUnknown {
+		return int(s), nil
+	}
+
+	return 0, nil
+}
+
+// SetSpeed advertises a full-duplex link speed of mbps Mbit/s for the interface
+// as reported to ethtool and /sys/class/net/<interface>/speed.
+// The speed is metadata for applications only and does not limit the throughput.
+// See the WithTbf() option for rate limiting.
+//
+// Only some drivers like tun allow changing the link speed.
+// Others like veth report a fixed speed instead and return
+// an error wrapping unix.EOPNOTSUPP.
+func (i *Interface) SetSpeed(mbps int) error {
+	if mbps <= 0 || uint32(mbps) >= speedUnknown {
+		return fmt.Errorf("invalid speed: %d", mbps)
+	}
+
+	var c ethtoolCmd
+	if err := i.ethtoolCmd(unix.ETHTOOL_GSET, &c); err != nil {
+		return fmt.Errorf("failed to get link settings: %w", err)
+	}
+
+	c.setSpeed(uint32(mbps))
+	if c.duplex == duplexUnknown {
+		c.duplex = duplexFull
+	}
+
+	if err := i.ethtoolCmd(unix.ETHTOOL_SSET, &c); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			typ := "unknown"
+			if link, err := i.currentLink(); err == nil {
+				typ = link.Type()
+			}
+
+			return fmt.Errorf("changing the link speed is not supported by %s interfaces: %w", typ, err)
+		}
+
+		return fmt.Errorf("failed to set link settings: %w", err)
+	}
+
+	return nil
+}
+
+func (i *Interface) ethtoolCmd(cmd uint32, c *ethtoolCmd) error {
+	c.cmd = cmd
+
+	return i.ethtoolIoctl(unsafe.Pointer(c))
+}
+
+// ethtoolIoctl issues an ethtool command for the interface
+// within the namespace of its node. See BaseNode.ethtoolIoctl().
+func (i *Interface) ethtoolIoctl(data unsafe.Pointer) error {
+	if i.Node == nil {
+		return fmt.Errorf("interface %s does not belong to a node", i.Name)
+	}
+
+	return i.Node.base().ethtoolIoctl(i.Name, data)
+}
@@ -0,0 +1,255 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+)
+
+var errNoSubnetAvailable = errors.New("no subnet available")
+
+// AllocSubnet allocates a free IPv4 subnet with the given prefix length
+// from the subnet pools of the network.
+//
+// The subnet remains allocated until it is released by FreeSubnet().
+func (n *Network) AllocSubnet(prefixLen int) (net.IPNet, error) {
+	return n.allocSubnet(prefixLen, 8*net.IPv4len)
+}
+
+// AllocSubnet6 allocates a free IPv6 subnet with the given prefix length
+// from the subnet pools of the network.
+//
+// The subnet remains allocated until it is released by FreeSubnet().
+func (n *Network) AllocSubnet6(prefixLen int) (net.IPNet, error) {
+	return n.allocSubnet(prefixLen, 8*net.IPv6len)
+}
+
+// FreeSubnet releases a subnet which has been allocated by AllocSubnet()
+// or AllocSubnet6() so that it can be allocated again.
+func (n *Network) FreeSubnet(subnet net.IPNet) error {
+	n.subnetsLock.Lock()
+	defer n.subnetsLock.Unlock()
+
+	for i, s := range n.subnets {
+		if s.IP.Equal(subnet.IP) && s.Mask.String() == subnet.Mask.String() {
+			n.subnets = append(n.subnets[:i], n.subnets[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("subnet %s is not allocated", subnet.String())
+}
+
+func (n *Network) allocSubnet(prefixLen, bits int) (net.IPNet, error) {
+	n.subnetsLock.Lock()
+	defer n.subnetsLock.Unlock()
+
+	found := false
+	for _, pool := range n.SubnetPools {
+		ones, poolBits := pool.Mask.Size()
+		if poolBits != bits {
+			continue
+		}
+
+		found = true
+
+		if prefixLen < ones || prefixLen > bits {
+			continue
+		}
+
+		if subnet, err := n.allocSubnetFromPool(pool, prefixLen); err == nil {
+			return subnet, nil
+		}
+	}
+
+	if !found {
+		family := "IPv4"
+		if bits == 8*net.IPv6len {
+			family = "IPv6"
+		}
+
+		return net.IPNet{}, fmt.Errorf("no %s subnet pool configured", family)
+	}
+
+	return net.IPNet{}, fmt.Errorf("failed to allocate /%d subnet: %w", prefixLen, errNoSubnetAvailable)
+}
+
+func (n *Network) allocSubnetFromPool(pool net.IPNet, prefixLen int) (net.IPNet, error) {
+	ones, bits := pool.Mask.Size()
+
+	ip := pool.IP.Mask(pool.Mask)
+	if bits == 8*net.IPv4len {
+		ip = ip.To4()
+	}
+
+	start := new(big.Int).SetBytes(ip)
+	step := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+	count := new(big.Int).Lsh(big.NewInt(1), uint(prefixLen-ones))
+
+	cur := new(big.Int).Set(start)
+	for i := new(big.Int); i.Cmp(count) < 0; i.Add(i, big.NewInt(1)) {
+		candidate := net.IPNet{
+			IP:   bigIntToIP(cur, len(ip)),
+			Mask: net.CIDRMask(prefixLen, bits),
+		}
+
+		if !n.subnetOverlaps(candidate) {
+			n.subnets = append(n.subnets, candidate)
+			return candidate, nil
+		}
+
+		cur.Add(cur, step)
+	}
+
+	return net.IPNet{}, errNoSubnetAvailable
+}
+
+func (n *Network) subnetOverlaps(subnet net.IPNet) bool {
+	for _, s := range n.subnets {
+		if s.Contains(subnet.IP) || subnet.Contains(s.IP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func bigIntToIP(i *big.Int, length int) net.IP {
+	b := i.Bytes()
+
+	ip := make(net.IP, length)
+	copy(ip[length-len(b):], b)
+
+	return ip
+}
+
+// autoAddressIPv6 assigns addresses from the IPv6 prefix of the network to
+// both ends of a link. Ends connected to a switch share the subnet of the switch.
+func (n *Network) autoAddressIPv6(l, r *Interface) error {
+	if l.Node == r.Node {
+		return nil
+	}
+
+	lSw, lIsSwitch := l.Node.(*Switch)
+	rSw, rIsSwitch := r.Node.(*Switch)
+
+	var ends []*Interface
+	var segment string
+	switch {
+	case lIsSwitch && rIsSwitch:
+		return nil
+	case lIsSwitch:
+		ends, segment = []*Interface{r}, lSw.Name()
+	case rIsSwitch:
+		ends, segment = []*Interface{l}, rSw.Name()
+	default:
+		ends = []*Interface{l, r}
+	}
+
+	needed := []*Interface{}
+	for _, i := range ends {
+		if i.needsAutoAddress() {
+			needed = append(needed, i)
+		}
+	}
+
+	if len(needed) == 0 {
+		return nil
+	}
+
+	subnet, err := n.allocSegment(segment)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range needed {
+		mac := i.LinkAttrs.HardwareAddr
+		if mac == nil {
+			mac = i.Link.Attrs().HardwareAddr
+		}
+
+		ip, err := eui64(subnet, mac)
+		if err != nil {
+			return err
+		}
+
+		i.Addresses = append(i.Addresses, net.IPNet{
+			IP:   ip,
+			Mask: subnet.Mask,
+		})
+	}
+
+	return nil
+}
+
+// allocSegment allocates a /64 subnet from the IPv6 prefix of the network.
+// Subnets of switches are only allocated once. An empty name allocates
+// a new subnet for a point-to-point link.
+func (n *Network) allocSegment(name string) (net.IPNet, error) {
+	n.subnetsLock.Lock()
+	defer n.subnetsLock.Unlock()
+
+	if subnet, ok := n.segments[name]; ok && name != "" {
+		return subnet, nil
+	}
+
+	ones, bits := n.IPv6Prefix.Mask.Size()
+	if bits != 8*net.IPv6len || n.IPv6Prefix.IP.To4() != nil {
+		return net.IPNet{}, fmt.Errorf("invalid IPv6 prefix: %s", n.IPv6Prefix)
+	} else if ones > 64 {
+		return net.IPNet{}, fmt.Errorf("IPv6 prefix %s must be /64 or shorter", n.IPv6Prefix)
+	}
+
+	subnet, err := n.allocSubnetFromPool(*n.IPv6Prefix, 64)
+	if err != nil {
+		return net.IPNet{}, fmt.Errorf("failed to allocate subnet from %s: %w", n.IPv6Prefix, err)
+	}
+
+	if name != "" {
+		if n.segments == nil {
+			n.segments = map[string]net.IPNet{}
+		}
+
+		n.segments[name] = subnet
+	}
+
+	return subnet, nil
+}
+
+// needsAutoAddress returns true if the interface has neither
+// disabled auto-addressing nor an IPv6 address configured
+func (i *Interface) needsAutoAddress() bool {
+	if i.DisableAutoAddress {
+		return false
+	}
+
+	for _, addr := range i.Addresses {
+		if addr.IP.To4() == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// eui64 derives an address of the /64 subnet from the MAC address (RFC 4291 appendix A)
+func eui64(subnet net.IPNet, mac net.HardwareAddr) (net.IP, error) {
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address: %s", mac)
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, subnet.IP.To16()[:8])
+
+	ip[8] = mac[0] ^ 0x02
+	ip[9] = mac[1]
+	ip[10] = mac[2]
+	ip[11] = 0xff
+	ip[12] = 0xfe
+	ip[13] = mac[3]
+	ip[14] = mac[4]
+	ip[15] = mac[5]
+
+	return ip, nil
+}
@@ -0,0 +1,191 @@
+package gont_test
+
+import (
+	"net"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+func testAllocSubnets(t *testing.T, alloc func(int) (net.IPNet, error), prefixLen, num int) []net.IPNet {
+	subnets := []net.IPNet{}
+
+	for i := 0; i < num; i++ {
+		s, err := alloc(prefixLen)
+		if err != nil {
+			t.Fatalf("Failed to allocate subnet: %s", err)
+		}
+
+		for _, other := range subnets {
+			if other.Contains(s.IP) || s.Contains(other.IP) {
+				t.Fatalf("Subnet %s overlaps with %s", s.String(), other.String())
+			}
+		}
+
+		subnets = append(subnets, s)
+	}
+
+	if s, err := alloc(prefixLen); err == nil {
+		t.Fatalf("Allocated subnet %s from exhausted pool", s.String())
+	}
+
+	return subnets
+}
+
+func TestAllocSubnet(t *testing.T) {
+	n, err := g.NewNetwork(*nname, append(opts,
+		o.SubnetPoolIP("10.0.0.0/28"),
+		o.SubnetPoolIP("fc00::/120"),
+	)...)
+	if err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	for _, tc := range []struct {
+		name      string
+		alloc     func(int) (net.IPNet, error)
+		prefixLen int
+		num       int
+	}{
+		{"ipv4", n.AllocSubnet, 30, 4},
+		{"ipv6", n.AllocSubnet6, 122, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			subnets := testAllocSubnets(t, tc.alloc, tc.prefixLen, tc.num)
+
+			freed := subnets[1]
+			if err := n.FreeSubnet(freed); err != nil {
+				t.Fatalf("Failed to free subnet: %s", err)
+			}
+
+			if err := n.FreeSubnet(freed); err == nil {
+				t.Fatal("Freed subnet twice")
+			}
+
+			s, err := tc.alloc(tc.prefixLen)
+			if err != nil {
+				t.Fatalf("Failed to reallocate subnet: %s", err)
+			}
+
+			if !s.IP.Equal(freed.IP) {
+				t.Fatalf("Reallocated subnet %s, expected %s", s.String(), freed.String())
+			}
+		})
+	}
+
+	if _, err := n.AllocSubnet(24); err == nil {
+		t.Fatal("Allocated subnet larger than pool")
+	}
+}
+
+// h1 -+
+//
+//	+- sw1
+//
+// h2 -+
+//
+// h3 <-> h4
+func TestIPv6Prefix(t *testing.T) {
+	var (
+		err            error
+		n              *g.Network
+		sw1            *g.Switch
+		h1, h2, h3, h4 *g.Host
+	)
+
+	_, prefix, _ := net.ParseCIDR("fd00:1234::/48")
+
+	if n, err = g.NewNetwork(*nname, append(opts,
+		o.WithIPv6Prefix(prefix.String()),
+	)...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if sw1, err = n.AddSwitch("sw1"); err != nil {
+		t.Fatalf("Failed to create switch: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1", o.Interface("veth0", sw1)); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2", o.Interface("veth0", sw1)); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h3, err = n.AddHost("h3"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h4, err = n.AddHost("h4"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h3),
+		o.Interface("veth0", h4),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	// Interfaces with explicit addresses or disabled auto-addressing are skipped
+	if err := n.AddLink(
+		o.Interface("veth1", h3,
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth1", h4,
+			o.WithAutoAddress(false)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	addrs := map[string]net.IPNet{}
+	for _, h := range []*g.Host{h1, h2, h3, h4} {
+		i := h.Interface("veth0")
+		if len(i.Addresses) != 1 {
+			t.Fatalf("Invalid addresses of %s: %v", i, i.Addresses)
+		}
+
+		addr := i.Addresses[0]
+		if ones, _ := addr.Mask.Size(); ones != 64 || !prefix.Contains(addr.IP) {
+			t.Errorf("Address %s of %s is not part of a /64 of %s", addr.String(), i, prefix)
+		}
+
+		for name, other := range addrs {
+			if other.IP.Equal(addr.IP) {
+				t.Errorf("Address %s of %s is already assigned to %s", addr.String(), i, name)
+			}
+		}
+
+		addrs[h.Name()] = addr
+	}
+
+	sameSubnet := func(a, b string) bool {
+		subnet := addrs[a]
+		return subnet.Contains(addrs[b].IP)
+	}
+
+	if !sameSubnet("h1", "h2") || !sameSubnet("h3", "h4") {
+		t.Errorf("Ends of a link have different subnets: %v", addrs)
+	}
+
+	if sameSubnet("h1", "h3") {
+		t.Errorf("Links share a subnet: %v", addrs)
+	}
+
+	if a := h3.Interface("veth1").Addresses; len(a) != 1 || a[0].String() != "fc::1/64" {
+		t.Errorf("Explicit address has been modified: %v", a)
+	}
+
+	if a := h4.Interface("veth1").Addresses; len(a) != 0 {
+		t.Errorf("Address has been assigned despite disabled auto-addressing: %v", a)
+	}
+
+	for _, hs := range [][2]*g.Host{{h1, h2}, {h3, h4}} {
+		if _, err := hs[0].PingWithNetwork(hs[1], "ip6"); err != nil {
+			t.Errorf("Failed to ping %s: %s", hs[1], err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package gont
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// KernelLogError is returned by CaptureKernelLog and contains the messages
+// which have been logged by the kernel during the failed operation.
+type KernelLogError struct {
+	Err      error
+	Messages []string
+}
+
+func (e *KernelLogError) Error() string {
+	return fmt.Sprintf("%s (kernel log: %s)", e.Err, strings.Join(e.Messages, "; "))
+}
+
+func (e *KernelLogError) Unwrap() error {
+	return e.Err
+}
+
+// CaptureKernelLog invokes fn and attaches the messages which have been
+// logged by the kernel in the meantime to the error returned by fn.
+// The error is wrapped in a KernelLogError if there are any messages.
+//
+// Capturing is best-effort: the kernel log is read from /dev/kmsg which
+// requires CAP_SYSLOG if kernel.dmesg_restrict is set. If it can not be read,
+// the error is returned unchanged. As the kernel log is shared by all
+// namespaces, messages of concurrent operations might be included.
+func CaptureKernelLog(fn func() error) error {
+	fd, err := unix.Open("/dev/kmsg", unix.O_RDONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fn()
+	}
+	defer unix.Close(fd)
+
+	// Skip all previous messages
+	if _, err := unix.Seek(fd, 0, unix.SEEK_END); err != nil {
+		return fn()
+	}
+
+	if err := fn(); err != nil {
+		if msgs := readKernelLog(fd); len(msgs) > 0 {
+			return &KernelLogError{
+				Err:      err,
+				Messages: msgs,
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// readKernelLog returns the messages of the records available from /dev/kmsg
+func readKernelLog(fd int) []string {
+	msgs := []string{}
+
+	// Each read returns a single record like "6,2031,2877742811,-;message\n"
+	buf := make([]byte, 8192)
+	for {
+		n, err := unix.Read(fd, buf)
+		if errors.Is(err, unix.EPIPE) {
+			// Records have been overwritten while reading
+			continue
+		} else if err != nil || n <= 0 {
+			break
+		}
+
+		rec := buf[:n]
+
+		idx := bytes.IndexByte(rec, ';')
+		if idx < 0 {
+			continue
+		}
+
+		msg := rec[idx+1:]
+		if end := bytes.IndexByte(msg, '\n'); end >= 0 {
+			msg = msg[:end]
+		}
+
+		msgs = append(msgs, string(msg))
+	}
+
+	return msgs
+}
+
+// captureKernelLog invokes fn via CaptureKernelLog if enabled by WithKernelLog()
+func (n *Network) captureKernelLog(fn func() error) error {
+	if !n.KernelLog {
+		return fn()
+	}
+
+	return CaptureKernelLog(fn)
+}
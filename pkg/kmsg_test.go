@@ -0,0 +1,50 @@
+package gont_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	"golang.org/x/sys/unix"
+)
+
+func TestCaptureKernelLog(t *testing.T) {
+	kmsg, err := os.OpenFile("/dev/kmsg", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("Kernel log is not accessible: %s", err)
+	}
+	defer kmsg.Close()
+
+	marker := fmt.Sprintf("gont: test marker %d", time.Now().UnixNano())
+
+	err = g.CaptureKernelLog(func() error {
+		if _, err := kmsg.WriteString("<6>" + marker + "\n"); err != nil {
+			t.Fatalf("Failed to write to kernel log: %s", err)
+		}
+
+		return unix.EOPNOTSUPP
+	})
+
+	if !errors.Is(err, unix.EOPNOTSUPP) {
+		t.Fatalf("Expected original error but got: %v", err)
+	}
+
+	var klErr *g.KernelLogError
+	if !errors.As(err, &klErr) {
+		t.Fatalf("Expected kernel log error but got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), marker) {
+		t.Fatalf("Error does not contain kernel log message: %s", err)
+	}
+
+	if err := g.CaptureKernelLog(func() error {
+		return nil
+	}); err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+}
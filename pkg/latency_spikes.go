@@ -0,0 +1,138 @@
+package gont
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+)
+
+// LatencySpikes periodically raises the latency of the Netem qdisc
+// of an interface. See Interface.InjectLatencySpikes().
+type LatencySpikes struct {
+	iface *Interface
+	node  *BaseNode
+	netem nl.NetemQdiscAttrs // copy of the parameters at the start
+
+	baseline time.Duration
+	spike    time.Duration
+	interval time.Duration
+	duration time.Duration
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// InjectLatencySpikes starts a background goroutine which raises the latency
+// of the Netem qdisc of the interface from baseline to spike every interval.
+// Each spike lasts for duration before the latency returns to the baseline.
+//
+// All other Netem parameters remain unchanged. The qdisc must have been
+// configured by the WithNetem() option.
+//
+// The spikes are stopped by LatencySpikes.Stop(), the cancellation of the
+// context or when the node is torn down. Afterwards the latency is reset
+// to the baseline.
+func (i *Interface) InjectLatencySpikes(ctx context.Context, baseline, spike, interval, duration time.Duration) (*LatencySpikes, error) {
+	if baseline < 0 || spike < 0 {
+		return nil, errors.New("latencies must not be negative")
+	} else if duration <= 0 || duration >= interval {
+		return nil, errors.New("duration of spikes must be positive and shorter than their interval")
+	}
+
+	s := &LatencySpikes{
+		iface:    i,
+		node:     i.Node.base(),
+		netem:    i.Netem,
+		baseline: baseline,
+		spike:    spike,
+		interval: interval,
+		duration: duration,
+		stop:     make(chan struct{}),
+	}
+
+	// Apply the baseline first to detect failures early
+	if err := s.setLatency(baseline); err != nil {
+		return nil, err
+	}
+
+	s.node.latencySpikes = append(s.node.latencySpikes, s)
+
+	s.wg.Add(1)
+	go s.run(ctx)
+
+	return s, nil
+}
+
+// Stop stops the spikes and waits until the latency has been reset to the baseline.
+func (s *LatencySpikes) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+
+	s.wg.Wait()
+}
+
+func (s *LatencySpikes) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	defer func() {
+		if err := s.setLatency(s.baseline); err != nil {
+			s.node.logger.Warn("Failed to reset latency", zap.Error(err))
+		}
+	}()
+
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+
+	// wait returns false if the spikes have been stopped
+	wait := func(c <-chan time.Time) bool {
+		select {
+		case <-s.stop:
+			return false
+		case <-ctx.Done():
+			return false
+		case <-c:
+			return true
+		}
+	}
+
+	for wait(t.C) {
+		if err := s.setLatency(s.spike); err != nil {
+			s.node.logger.Warn("Failed to inject latency spike", zap.Error(err))
+			continue
+		}
+
+		if !wait(time.After(s.duration)) {
+			return
+		}
+
+		if err := s.setLatency(s.baseline); err != nil {
+			s.node.logger.Warn("Failed to reset latency", zap.Error(err))
+		}
+	}
+}
+
+func (s *LatencySpikes) setLatency(latency time.Duration) error {
+	// Interface.Netem is updated by UpdateNetem() and must not be read here
+	attrs := s.netem
+	attrs.Latency = uint32(latency / time.Microsecond)
+
+	s.node.logger.Debug("Updating latency",
+		zap.String("intf", s.iface.Name),
+		zap.Duration("latency", latency))
+
+	return s.iface.UpdateNetem(attrs)
+}
+
+func (n *BaseNode) stopLatencySpikes() {
+	for _, s := range n.latencySpikes {
+		s.Stop()
+	}
+
+	n.latencySpikes = nil
+}
@@ -0,0 +1,201 @@
+package gont
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+const netnsMountDir = "/run/netns"
+
+// LeakReport summarizes the resources of a network which
+// are held by the calling process. See Network.CheckLeaks().
+type LeakReport struct {
+	// Nodes is the number of nodes with their own namespace handle
+	Nodes int
+
+	// NetNSFDs is the number of open file descriptors
+	// referring to the network namespaces of the nodes
+	NetNSFDs int
+
+	// Mounts is the number of bind mounts of network namespaces
+	// below the base path of the network and /run/netns
+	Mounts int
+
+	// Discrepancies describes all deviations from the expected counts
+	Discrepancies []string
+}
+
+// Leaked returns true if any discrepancies have been found.
+func (r *LeakReport) Leaked() bool {
+	return len(r.Discrepancies) > 0
+}
+
+func (r *LeakReport) addf(format string, args ...any) {
+	r.Discrepancies = append(r.Discrepancies, fmt.Sprintf(format, args...))
+}
+
+// CheckLeaks counts the open network namespace file descriptors and
+// bind mounts of the calling process which are attributable to the network
+// and reports discrepancies against the expected counts of its nodes.
+//
+// Each node is expected to hold exactly one file descriptor for its namespace
+// and a bind mount at BaseNode.NetNSPath(). Nodes with a namespace created by Gont
+// are additionally expected to have a bind mount at /run/netns/<namespace>.
+//
+// This is a debug helper for long running test suites which churn many topologies.
+func (n *Network) CheckLeaks() (*LeakReport, error) {
+	r := &LeakReport{}
+
+	n.NodesLock.RLock()
+	defer n.NodesLock.RUnlock()
+
+	fds, err := netnsFDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespace file descriptors: %w", err)
+	}
+
+	mounts, err := mountPoints()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mounts: %w", err)
+	}
+
+	expectedMounts := map[string]string{}
+
+	names := []string{}
+	for name := range n.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		b := n.Nodes[name].base()
+		if b.Namespace == nil || b.IsHostNode() {
+			continue
+		}
+
+		r.Nodes++
+
+		var stat syscall.Stat_t
+		if err := syscall.Fstat(int(b.NsHandle), &stat); err != nil {
+			r.addf("node %s: namespace handle is invalid: %s", name, err)
+			continue
+		}
+
+		if cnt := fds[stat.Ino]; cnt != 1 {
+			r.addf("node %s: %d open file descriptors for namespace, expected 1", name, cnt)
+		}
+
+		r.NetNSFDs += fds[stat.Ino]
+
+		expectedMounts[realPath(b.NetNSPath())] = name
+		if b.ExistingNamespace == "" && b.ExistingDockerContainer == "" {
+			expectedMounts[filepath.Join(netnsMountDir, b.Namespace.Name)] = name
+		}
+	}
+
+	// Namespaces of other live networks might share our prefix
+	foreign := map[string]bool{}
+	for _, m := range Networks() {
+		if m == n {
+			continue
+		}
+
+		m.NodesLock.RLock()
+		for _, node := range m.Nodes {
+			if node.base().Namespace != nil {
+				foreign[filepath.Join(netnsMountDir, node.base().Namespace.Name)] = true
+			}
+		}
+		m.NodesLock.RUnlock()
+	}
+
+	nsPrefix := filepath.Join(netnsMountDir, n.NSPrefix+n.Name+"-")
+	basePath := realPath(n.BasePath)
+
+	for _, mnt := range mounts {
+		if strings.HasPrefix(mnt, basePath+"/") || (strings.HasPrefix(mnt, nsPrefix) && !foreign[mnt]) {
+			r.Mounts++
+
+			if _, ok := expectedMounts[mnt]; !ok {
+				r.addf("stale mount: %s", mnt)
+			}
+		}
+	}
+
+	mounted := map[string]bool{}
+	for _, mnt := range mounts {
+		mounted[mnt] = true
+	}
+
+	missing := []string{}
+	for mnt := range expectedMounts {
+		if !mounted[mnt] {
+			missing = append(missing, mnt)
+		}
+	}
+	sort.Strings(missing)
+
+	for _, mnt := range missing {
+		r.addf("node %s: missing mount: %s", expectedMounts[mnt], mnt)
+	}
+
+	return r, nil
+}
+
+// realPath resolves symlinks like /var/run -> /run
+// in order to compare a path with mount points.
+func realPath(path string) string {
+	if p, err := filepath.EvalSymlinks(path); err == nil {
+		return p
+	}
+
+	return path
+}
+
+// netnsFDs returns the number of open file descriptors
+// of the calling process per network namespace inode.
+func netnsFDs() (map[uint64]int, error) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return nil, err
+	}
+
+	fds := map[uint64]int{}
+	for _, e := range entries {
+		tgt, err := os.Readlink(filepath.Join("/proc/self/fd", e.Name()))
+		if err != nil {
+			continue // The fd might have been closed in the meantime
+		}
+
+		var ino uint64
+		if _, err := fmt.Sscanf(tgt, "net:[%d]", &ino); err == nil {
+			fds[ino]++
+		}
+	}
+
+	return fds, nil
+}
+
+// mountPoints returns the mount points of the mount namespace of the calling process.
+func mountPoints() ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mounts := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 4 {
+			mounts = append(mounts, fields[4])
+		}
+	}
+
+	return mounts, scanner.Err()
+}
@@ -51,6 +51,21 @@ func (n *Network) AddLink(l, r *Interface, opts ...Option) error {
 		PeerName: r.Name,
 	}
 
+	// Both ends of a veth pair share the same number of queues
+	for _, la := range []nl.LinkAttrs{l.LinkAttrs, r.LinkAttrs} {
+		if la.NumTxQueues < 0 || la.NumRxQueues < 0 {
+			return errors.New("number of queues must be positive")
+		}
+
+		if la.NumTxQueues > veth.NumTxQueues {
+			veth.NumTxQueues = la.NumTxQueues
+		}
+
+		if la.NumRxQueues > veth.NumRxQueues {
+			veth.NumRxQueues = la.NumRxQueues
+		}
+	}
+
 	// Apply options
 	for _, opt := range opts {
 		switch opt := opt.(type) {
@@ -60,10 +75,11 @@ func (n *Network) AddLink(l, r *Interface, opts ...Option) error {
 	}
 
 	lHandle := l.Node.NetlinkHandle()
-	rHandle := r.Node.NetlinkHandle()
 
 	// Create veth pair
-	if err = lHandle.LinkAdd(veth); err != nil {
+	if err = n.captureKernelLog(func() error {
+		return lHandle.LinkAdd(veth)
+	}); err != nil {
 		return fmt.Errorf("failed to add link: %w", err)
 	}
 
@@ -82,18 +98,184 @@ func (n *Network) AddLink(l, r *Interface, opts ...Option) error {
 		return fmt.Errorf("failed to rename interface: %w", err)
 	}
 
-	if l.Link, err = lHandle.LinkByName(l.Name); err != nil {
+	return n.configureVeth(l, r)
+}
+
+// AddVeth creates a veth pair whose ends are created directly
+// in the namespaces of the left and right nodes.
+//
+// In contrast to AddLink(), the interfaces are never moved between
+// namespaces and therefore never live in the namespace of the host.
+// Both ends are added to the interfaces of their nodes and configured.
+func (n *Network) AddVeth(left, right *BaseNode, leftName, rightName string, opts ...Option) error {
+	if len(leftName) > syscall.IFNAMSIZ-1 || len(rightName) > syscall.IFNAMSIZ-1 {
+		return fmt.Errorf("interface names are too long. max_len=%d", syscall.IFNAMSIZ-1)
+	}
+
+	if left == right {
+		return errors.New("failed to link the node with itself")
+	}
+
+	if left.network != n || right.network != n {
+		return errors.New("nodes are belonging to different networks")
+	}
+
+	l := &Interface{
+		Name: leftName,
+		Node: left.node(),
+	}
+
+	r := &Interface{
+		Name: rightName,
+		Node: right.node(),
+	}
+
+	if l.Node == nil || r.Node == nil {
+		return errors.New("cant establish link between unregistered nodes")
+	}
+
+	n.logger.Info("Adding new veth pair",
+		zap.Any("left", l),
+		zap.Any("right", r),
+	)
+
+	veth := &nl.Veth{
+		LinkAttrs: nl.LinkAttrs{
+			Name:   leftName,
+			TxQLen: -1,
+		},
+		PeerName:      rightName,
+		PeerNamespace: nl.NsFd(right.NsHandle),
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case VethOption:
+			opt.Apply(veth)
+		}
+	}
+
+	if err := n.captureKernelLog(func() error {
+		return left.nlHandle.LinkAdd(veth)
+	}); err != nil {
+		return fmt.Errorf("failed to add link: %w", err)
+	}
+
+	return n.configureVeth(l, r)
+}
+
+// AddInternalVeth creates a veth pair whose ends are both kept in the
+// namespace of the node.
+//
+// Frames sent out of one end are received by the other end within the
+// same node. Both ends are added to the interfaces of the node and
+// configured. They are removed together with the node.
+func (n *BaseNode) AddInternalVeth(name1, name2 string, opts ...Option) error {
+	if len(name1) > syscall.IFNAMSIZ-1 || len(name2) > syscall.IFNAMSIZ-1 {
+		return fmt.Errorf("interface names are too long. max_len=%d", syscall.IFNAMSIZ-1)
+	}
+
+	if name1 == name2 {
+		return errors.New("both ends must have different names")
+	}
+
+	node := n.node()
+	if node == nil {
+		return fmt.Errorf("failed to find node %s", n.name)
+	}
+
+	for _, name := range []string{name1, name2} {
+		if n.Interface(name) != nil {
+			return fmt.Errorf("interface %s already exists", name)
+		}
+	}
+
+	l := &Interface{
+		Name: name1,
+		Node: node,
+	}
+
+	r := &Interface{
+		Name: name2,
+		Node: node,
+	}
+
+	n.logger.Info("Adding new internal veth pair",
+		zap.Any("left", l),
+		zap.Any("right", r),
+	)
+
+	veth := &nl.Veth{
+		LinkAttrs: nl.LinkAttrs{
+			Name:   name1,
+			TxQLen: -1,
+		},
+		PeerName: name2,
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case VethOption:
+			opt.Apply(veth)
+		}
+	}
+
+	if err := n.network.captureKernelLog(func() error {
+		return n.nlHandle.LinkAdd(veth)
+	}); err != nil {
+		return fmt.Errorf("failed to add link: %w", err)
+	}
+
+	return n.network.configureVeth(l, r)
+}
+
+// configureVeth configures both ends of a veth pair (link attributes,
+// link state, attaching to bridge, adding addresses).
+//
+// The link is only kept if both ends have been configured successfully.
+// Otherwise, the veth pair is deleted again.
+func (n *Network) configureVeth(l, r *Interface) error {
+	var err error
+
+	if err = n.configureVethEnds(l, r); err != nil {
+		// Deleting one end also removes its peer
+		if link, err := l.Node.NetlinkHandle().LinkByName(l.Name); err == nil {
+			l.Node.NetlinkHandle().LinkDel(link)
+		}
+
+		// Forget about already configured ends
+		for _, i := range []*Interface{l, r} {
+			if j := i.Node.Interface(i.Name); j == i {
+				i.Node.base().DelInterface(i.Name)
+			}
+		}
+	}
+
+	return err
+}
+
+func (n *Network) configureVethEnds(l, r *Interface) error {
+	var err error
+
+	if l.Link, err = l.Node.NetlinkHandle().LinkByName(l.Name); err != nil {
 		return fmt.Errorf("failed to find interface %s: %w", l.Name, err)
 	}
 
-	if r.Link, err = rHandle.LinkByName(r.Name); err != nil {
+	if r.Link, err = r.Node.NetlinkHandle().LinkByName(r.Name); err != nil {
 		return fmt.Errorf("failed to find interface %s: %w", r.Name, err)
 	}
 
-	// Configure interface (link state, attaching to bridge, adding addresses)
+	if n.IPv6Prefix != nil && !n.DisableIPv6 {
+		if err := n.autoAddressIPv6(l, r); err != nil {
+			return fmt.Errorf("failed to assign IPv6 addresses: %w", err)
+		}
+	}
+
 	for _, i := range []*Interface{l, r} {
 		if err := i.Configure(); err != nil {
-			return fmt.Errorf("failed to configure endpoint: %w", err)
+			return fmt.Errorf("failed to configure endpoint %s: %w", i, err)
 		}
 	}
 
@@ -2,6 +2,8 @@ package gont_test
 
 import (
 	"bytes"
+	"strconv"
+	"strings"
 	"testing"
 
 	g "github.com/stv0g/gont/pkg"
@@ -79,3 +81,256 @@ func TestLinkAttrs(t *testing.T) {
 		t.Errorf("Mismatching MAC address")
 	}
 }
+
+//  h1 <-> h2
+func TestLinkMTUMismatch(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.MTU(1500),
+			o.AddressMAC("02:00:00:00:00:01")),
+		o.Interface("veth0", h2,
+			o.MTU(9000),
+			o.AddressMAC("02:00:00:00:00:02")),
+	); err != nil {
+		t.Fatalf("Failed to setup link: %s", err)
+	}
+
+	for _, c := range []struct {
+		host *g.Host
+		mtu  int
+		mac  string
+	}{
+		{h1, 1500, "02:00:00:00:00:01"},
+		{h2, 9000, "02:00:00:00:00:02"},
+	} {
+		link, err := c.host.NetlinkHandle().LinkByName("veth0")
+		if err != nil {
+			t.Fatalf("Failed to get link details: %s", err)
+		}
+
+		if link.Attrs().MTU != c.mtu {
+			t.Errorf("Mismatching MTU on %s: %d != %d", c.host, link.Attrs().MTU, c.mtu)
+		}
+
+		if mac := link.Attrs().HardwareAddr.String(); mac != c.mac {
+			t.Errorf("Mismatching MAC address on %s: %s != %s", c.host, mac, c.mac)
+		}
+	}
+
+	// The MTU of veth devices is limited to 64KiB
+	if err := n.AddLink(
+		o.Interface("veth1", h1,
+			o.MTU(1500)),
+		o.Interface("veth1", h2,
+			o.MTU(70000)),
+	); err == nil {
+		t.Fatal("Created link with invalid MTU")
+	}
+
+	// The already configured end must be removed again
+	if h1.Interface("veth1") != nil {
+		t.Error("Interface of failed link has been kept")
+	}
+
+	if _, err := h1.NetlinkHandle().LinkByName("veth1"); err == nil {
+		t.Error("Link has not been deleted")
+	}
+}
+
+func TestLinkQueues(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth1", h1, o.NumTxQueues(-1)),
+		o.Interface("veth1", h2),
+	); err == nil {
+		t.Error("Created link with negative number of queues")
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.NumTxQueues(4),
+			o.NumRxQueues(2)),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to setup link: %s", err)
+	}
+
+	// Mount sysfs of the node namespace to list the queues
+	out, _, err := h1.Run("sh", "-c", "mount -t sysfs sysfs /sys && ls /sys/class/net/veth0/queues")
+	if err != nil {
+		t.Fatalf("Failed to list queues: %s", err)
+	}
+
+	queues := strings.Fields(string(out))
+	expected := []string{"rx-0", "rx-1", "tx-0", "tx-1", "tx-2", "tx-3"}
+	if strings.Join(queues, " ") != strings.Join(expected, " ") {
+		t.Errorf("Unexpected queues: %v", queues)
+	}
+
+	i := h1.Interface("veth0")
+	if err := i.SetTxQLen(-1); err == nil {
+		t.Error("Set negative transmit queue length")
+	}
+
+	if err := i.SetTxQLen(42); err != nil {
+		t.Fatalf("Failed to set transmit queue length: %s", err)
+	}
+
+	link, err := h1.NetlinkHandle().LinkByName("veth0")
+	if err != nil {
+		t.Fatalf("Failed to get link details: %s", err)
+	}
+
+	if link.Attrs().TxQLen != 42 {
+		t.Errorf("Mismatching transmit queue length: %d", link.Attrs().TxQLen)
+	}
+}
+
+func TestLinkPacketSteering(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.NumTxQueues(2),
+			o.NumRxQueues(2)),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to setup link: %s", err)
+	}
+
+	i := h1.Interface("veth0")
+	// Negative or beyond the maximum number of CPUs of the kernel
+	for _, cpu := range []int{-1, 1 << 16} {
+		if err := i.SetRPS([]int{cpu}); err == nil {
+			t.Errorf("Set RPS with invalid CPU index %d", cpu)
+		}
+	}
+
+	if err := i.SetRPS([]int{0}); err != nil {
+		t.Fatalf("Failed to set RPS: %s", err)
+	}
+
+	if err := i.SetXPS(1, []int{0}); err != nil {
+		t.Fatalf("Failed to set XPS: %s", err)
+	}
+
+	// Mount sysfs of the node namespace to read back the masks
+	out, _, err := h1.Run("sh", "-c", "mount -t sysfs sysfs /sys && cd /sys/class/net/veth0/queues && cat rx-0/rps_cpus rx-1/rps_cpus tx-1/xps_cpus")
+	if err != nil {
+		t.Fatalf("Failed to read masks: %s", err)
+	}
+
+	for _, mask := range strings.Fields(string(out)) {
+		if v, err := strconv.ParseUint(strings.ReplaceAll(mask, ",", ""), 16, 64); err != nil || v != 1 {
+			t.Errorf("Unexpected CPU mask: %s", mask)
+		}
+	}
+}
+
+func TestLinkAlias(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1),
+		o.Interface("veth0", h2,
+			o.Alias("uplink to h1")),
+	); err != nil {
+		t.Fatalf("Failed to setup link: %s", err)
+	}
+
+	i := h1.Interface("veth0")
+	if err := i.SetAlias("uplink to h2"); err != nil {
+		t.Fatalf("Failed to set alias: %s", err)
+	}
+
+	for h, expected := range map[*g.Host]string{
+		h1: "uplink to h2",
+		h2: "uplink to h1",
+	} {
+		if alias, err := h.Interface("veth0").Alias(); err != nil {
+			t.Fatalf("Failed to get alias: %s", err)
+		} else if alias != expected {
+			t.Errorf("Unexpected alias of %s: %s", h, alias)
+		}
+
+		out, _, err := h.Run("ip", "-d", "link", "show", "veth0")
+		if err != nil {
+			t.Fatalf("Failed to show link: %s", err)
+		}
+
+		if !strings.Contains(string(out), "alias "+expected) {
+			t.Errorf("Alias is not shown by ip link: %s", out)
+		}
+	}
+}
@@ -1,10 +1,18 @@
 package gont_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
 	"testing"
+	"time"
 
+	"github.com/google/gopacket"
 	g "github.com/stv0g/gont/pkg"
 	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 func TestLink(t *testing.T) {
@@ -34,3 +42,338 @@ func TestLink(t *testing.T) {
 		t.Errorf("Failed to link nodes: %s", err)
 	}
 }
+
+func TestLinkCarrier(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err = n.AddLink(
+		o.Interface("veth0", h1),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to link nodes: %s", err)
+	}
+
+	i1 := h1.Interface("veth0")
+	i2 := h2.Interface("veth0")
+
+	if carrier, err := i1.Carrier(); err != nil {
+		t.Fatalf("Failed to get carrier: %s", err)
+	} else if !carrier {
+		t.Error("Interface has no carrier")
+	}
+
+	if flags, err := i1.LinkFlags(); err != nil {
+		t.Fatalf("Failed to get flags: %s", err)
+	} else if flags&net.FlagUp == 0 {
+		t.Errorf("Interface is not up: %s", flags)
+	}
+
+	if err := h2.NetlinkHandle().LinkSetDown(i2.Link); err != nil {
+		t.Fatalf("Failed to set interface down: %s", err)
+	}
+
+	if carrier, err := i1.Carrier(); err != nil {
+		t.Fatalf("Failed to get carrier: %s", err)
+	} else if carrier {
+		t.Error("Interface has carrier although its peer is down")
+	}
+
+	if flags, err := i2.LinkFlags(); err != nil {
+		t.Fatalf("Failed to get flags: %s", err)
+	} else if flags&net.FlagUp != 0 {
+		t.Errorf("Interface is still up: %s", flags)
+	}
+}
+
+// TestAddVeth creates a veth pair directly in the namespaces of two hosts
+//
+//  h1 <-> h2
+func TestAddVeth(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddVeth(h1.BaseNode, h2.BaseNode, "veth-left", "veth-right"); err != nil {
+		t.Fatalf("Failed to add veth pair: %s", err)
+	}
+
+	if _, err := h1.NetlinkHandle().LinkByName("veth-left"); err != nil {
+		t.Errorf("Left interface is missing: %s", err)
+	}
+
+	if _, err := h2.NetlinkHandle().LinkByName("veth-right"); err != nil {
+		t.Errorf("Right interface is missing: %s", err)
+	}
+
+	if h1.Interface("veth-left") == nil || h2.Interface("veth-right") == nil {
+		t.Error("Interfaces have not been added to the nodes")
+	}
+
+	// Name collision must not leave a partial link behind
+	if err := n.AddVeth(h1.BaseNode, h2.BaseNode, "veth-other", "veth-right"); err == nil {
+		t.Error("Created veth pair with duplicate name")
+	}
+
+	if _, err := h1.NetlinkHandle().LinkByName("veth-other"); err == nil {
+		t.Error("Partially created interface has not been removed")
+	}
+}
+
+func TestLinkFlap(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err = n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to link nodes: %s", err)
+	}
+
+	i := h1.Interface("veth0")
+
+	// Ping while flapping the link
+	done := make(chan struct{})
+	go func() {
+		_, _ = h1.PingWithOptions(h2, "ip", 50, time.Second, 10*time.Millisecond, false)
+		close(done)
+	}()
+
+	if err := i.Flap(context.Background(), 3, 50*time.Millisecond); err != nil {
+		t.Fatalf("Failed to flap link: %s", err)
+	}
+
+	<-done
+
+	if _, err := h1.Ping(h2); err != nil {
+		t.Errorf("Connectivity did not recover after flapping: %s", err)
+	}
+
+	// Abort flapping
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := i.Flap(ctx, 100, 100*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if flags, err := i.LinkFlags(); err != nil {
+		t.Fatalf("Failed to get flags: %s", err)
+	} else if flags&net.FlagUp == 0 {
+		t.Errorf("Interface is not up after aborting: %s", flags)
+	}
+}
+
+func TestLinkStats(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err = n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to link nodes: %s", err)
+	}
+
+	i1 := h1.Interface("veth0")
+	i2 := h2.Interface("veth0")
+
+	if _, err := h1.Ping(h2); err != nil {
+		t.Fatalf("Failed to ping: %s", err)
+	}
+
+	for _, i := range []*g.Interface{i1, i2} {
+		if err := i.ResetStats(); err != nil {
+			t.Fatalf("Failed to reset stats: %s", err)
+		}
+	}
+
+	const numPkts = 100
+
+	c, err := h1.Dial("udp", "10.0.0.2:5000")
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer c.Close()
+
+	for j := 0; j < numPkts; j++ {
+		// Writes might fail due to ICMP port unreachable errors of preceding packets
+		_, _ = c.Write([]byte("hello"))
+	}
+
+	s1, err := i1.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %s", err)
+	}
+
+	s2, err := i2.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %s", err)
+	}
+
+	if s1.TxPackets == 0 || s1.TxPackets > 2*numPkts {
+		t.Errorf("Unexpected number of transmitted packets: %d", s1.TxPackets)
+	}
+
+	if s2.RxPackets != s1.TxPackets {
+		t.Errorf("Mismatching number of received packets: %d != %d", s2.RxPackets, s1.TxPackets)
+	}
+
+	for _, s := range []nl.LinkStatistics{s1, s2} {
+		if s.RxErrors != 0 || s.TxErrors != 0 {
+			t.Errorf("Unexpected errors: rx=%d, tx=%d", s.RxErrors, s.TxErrors)
+		}
+	}
+}
+
+// TestAddInternalVeth creates a veth pair with both ends in router r1
+//
+//  r1 (veth-a <-> veth-b)
+func TestAddInternalVeth(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		r1  *g.Router
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if r1, err = n.AddRouter("r1"); err != nil {
+		t.Fatalf("Failed to create router: %s", err)
+	}
+
+	if err := r1.AddInternalVeth("veth-a", "veth-b"); err != nil {
+		t.Fatalf("Failed to add internal veth pair: %s", err)
+	}
+
+	a, b := r1.Interface("veth-a"), r1.Interface("veth-b")
+	if a == nil || b == nil {
+		t.Fatal("Interfaces have not been added to the node")
+	}
+
+	if a.Link.Attrs().ParentIndex != b.Link.Attrs().Index {
+		t.Errorf("Interfaces are not peers: %d != %d", a.Link.Attrs().ParentIndex, b.Link.Attrs().Index)
+	}
+
+	for _, tc := range []struct {
+		name string
+		addr net.IPNet
+	}{
+		{"veth-a", net.IPNet(o.AddressIPv4(10, 0, 1, 1, 24))},
+		{"veth-b", net.IPNet(o.AddressIPv4(10, 0, 2, 1, 24))},
+	} {
+		if err := r1.LinkAddAddress(tc.name, tc.addr); err != nil {
+			t.Fatalf("Failed to add address: %s", err)
+		}
+	}
+
+	// Frames sent out of one end are received by the other end
+	fd := listenPacket(t, r1.BaseNode, "veth-b")
+	defer unix.Close(fd)
+
+	if err := r1.SendPacket("veth-a", udpFrame(t, a, b, net.IPv4(10, 0, 1, 1), net.IPv4(10, 0, 2, 1), "internal")); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	if p := receivePacket(fd, time.Second, func(p gopacket.Packet) bool {
+		app := p.ApplicationLayer()
+		return app != nil && bytes.Equal(app.Payload(), []byte("internal"))
+	}); p == nil {
+		t.Error("Frame has not been received by peer")
+	}
+
+	// Each subnet is routed via its end of the pair
+	for _, tc := range []struct {
+		dst   net.IP
+		iface *g.Interface
+	}{
+		{net.IPv4(10, 0, 1, 2), a},
+		{net.IPv4(10, 0, 2, 2), b},
+	} {
+		routes, err := r1.NetlinkHandle().RouteGet(tc.dst)
+		if err != nil {
+			t.Errorf("Failed to get route for %s: %s", tc.dst, err)
+		} else if len(routes) != 1 || routes[0].LinkIndex != tc.iface.Link.Attrs().Index {
+			t.Errorf("Invalid route for %s: %v", tc.dst, routes)
+		}
+	}
+
+	if err := r1.AddInternalVeth("veth-c", "veth-b"); err == nil {
+		t.Error("Created veth pair with duplicate name")
+	}
+
+	if _, err := r1.NetlinkHandle().LinkByName("veth-c"); err == nil {
+		t.Error("Partially created interface has not been removed")
+	}
+}
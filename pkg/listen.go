@@ -0,0 +1,80 @@
+package gont
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	tcpStateListen = 0x0a
+	tcpStateClose  = 0x07 // used for unconnected UDP sockets
+)
+
+// WaitForListen blocks until a socket is listening on the port within
+// the network namespace of the node or the context is canceled.
+//
+// The protocol proto is one of "tcp", "tcp4", "tcp6", "udp", "udp4" or "udp6".
+// For UDP, all bound but unconnected sockets are considered as listening.
+func (n *BaseNode) WaitForListen(ctx context.Context, proto string, port int) error {
+	var files []string
+	var state uint64
+
+	switch proto {
+	case "tcp", "tcp4", "tcp6":
+		state = tcpStateListen
+	case "udp", "udp4", "udp6":
+		state = tcpStateClose
+	default:
+		return fmt.Errorf("unsupported protocol: %s", proto)
+	}
+
+	switch proto {
+	case "tcp", "udp":
+		files = []string{proto, proto + "6"}
+	case "tcp4", "udp4":
+		files = []string{proto[:3]}
+	default:
+		files = []string{proto}
+	}
+
+	t := time.NewTicker(10 * time.Millisecond)
+	defer t.Stop()
+
+	for {
+		var listening bool
+		if err := n.RunFunc(func() (err error) {
+			listening, err = isListening(files, state, port)
+			return
+		}); err != nil {
+			return fmt.Errorf("failed to check sockets: %w", err)
+		} else if listening {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no %s socket listening on port %d of node %s: %w", proto, port, n.name, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// isListening checks the socket tables in /proc/net for a socket
+// with the given state bound to the local port.
+func isListening(files []string, state uint64, port int) (bool, error) {
+	for _, file := range files {
+		socks, err := readSockets(file)
+		if err != nil {
+			return false, err
+		}
+
+		for _, sock := range socks {
+			if sock.LocalPort == port && uint64(sock.State) == state {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
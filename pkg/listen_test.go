@@ -0,0 +1,176 @@
+package gont_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+)
+
+func TestWaitForListen(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	const delay = 500 * time.Millisecond
+
+	// Start server with a delay
+	lst := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(delay)
+
+		if err := h1.RunFunc(func() error {
+			l, err := net.Listen("tcp", ":8080")
+			if err != nil {
+				return err
+			}
+
+			lst <- l
+			return nil
+		}); err != nil {
+			t.Errorf("Failed to listen: %s", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := h1.WaitForListen(ctx, "tcp", 8080); err != nil {
+		t.Fatalf("Failed to wait for listening socket: %s", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Returned before server has been started: %s", elapsed)
+	}
+
+	l := <-lst
+	defer l.Close()
+
+	if c, err := h1.Dial("tcp", "127.0.0.1:8080"); err != nil {
+		t.Errorf("Failed to connect: %s", err)
+	} else {
+		c.Close()
+	}
+
+	// UDP
+	var pc net.PacketConn
+	if err := h1.RunFunc(func() (err error) {
+		pc, err = net.ListenPacket("udp6", "[::1]:5353")
+		return
+	}); err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer pc.Close()
+
+	if err := h1.WaitForListen(ctx, "udp", 5353); err != nil {
+		t.Errorf("Failed to wait for listening socket: %s", err)
+	}
+
+	// Sockets of other nodes are not visible
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	if err := h2.WaitForListen(ctx, "tcp", 8080); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Did not return promptly after cancellation: %s", elapsed)
+	}
+}
+
+func TestListeningSockets(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	var l net.Listener
+	var pc net.PacketConn
+	if err := h1.RunFunc(func() (err error) {
+		if l, err = net.Listen("tcp4", "127.0.0.1:8080"); err != nil {
+			return err
+		}
+
+		pc, err = net.ListenPacket("udp6", "[::1]:5353")
+		return
+	}); err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer l.Close()
+	defer pc.Close()
+
+	socks, err := h1.ListeningSockets()
+	if err != nil {
+		t.Fatalf("Failed to list sockets: %s", err)
+	}
+
+	for _, expected := range []g.SocketInfo{
+		{Protocol: "tcp", LocalAddress: net.IPv4(127, 0, 0, 1), LocalPort: 8080, State: g.SocketListen},
+		{Protocol: "udp6", LocalAddress: net.IPv6loopback, LocalPort: 5353, State: g.SocketClose},
+	} {
+		found := false
+		for _, sock := range socks {
+			if sock.Protocol == expected.Protocol &&
+				sock.LocalAddress.Equal(expected.LocalAddress) &&
+				sock.LocalPort == expected.LocalPort &&
+				sock.State == expected.State {
+				found = true
+
+				if sock.Inode == 0 || sock.PID != os.Getpid() {
+					t.Errorf("Failed to resolve owner of socket: %+v", sock)
+				}
+			}
+		}
+
+		if !found {
+			t.Errorf("Missing %s socket on port %d: %+v", expected.Protocol, expected.LocalPort, socks)
+		}
+	}
+
+	if g.SocketListen.String() != "LISTEN" {
+		t.Errorf("Invalid state name: %s", g.SocketListen)
+	}
+
+	// Sockets of other nodes are not visible
+	if socks, err := h2.ListeningSockets(); err != nil {
+		t.Errorf("Failed to list sockets: %s", err)
+	} else if len(socks) != 0 {
+		t.Errorf("Unexpected sockets: %+v", socks)
+	}
+}
@@ -0,0 +1,75 @@
+package gont_test
+
+import (
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+
+	n, err := g.NewNetwork(*nname, append(opts, o.WithLogger(zap.New(core)))...)
+	if err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	h1, err := n.AddHost("h1")
+	if err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	h2, err := n.AddHost("h2", o.WithLogLevel(zap.WarnLevel))
+	if err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	h3, err := n.AddHost("h3", o.LogToDebug(false))
+	if err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	h4, err := n.AddHost("h4", o.LogToDebug(true), o.WithLogLevel(zap.WarnLevel))
+	if err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if !h1.LogToDebug || h2.LogToDebug || h3.LogToDebug || !h4.LogToDebug {
+		t.Errorf("Invalid LogToDebug: h1=%t, h2=%t, h3=%t, h4=%t", h1.LogToDebug, h2.LogToDebug, h3.LogToDebug, h4.LogToDebug)
+	}
+
+	// Only h1 and h3 log at info level
+	added := logs.FilterMessage("Adding new node")
+	if added.Len() != 2 {
+		t.Fatalf("Expected two log entries for added nodes, got %d", added.Len())
+	}
+
+	added = added.FilterField(zap.String("node", "h1"))
+	if added.Len() != 1 {
+		t.Fatalf("Expected one log entry for added node, got %d", added.Len())
+	}
+
+	entry := added.All()[0]
+	if entry.LoggerName != "node" {
+		t.Errorf("Unexpected logger name: %s", entry.LoggerName)
+	}
+
+	if node, ok := entry.ContextMap()["node"]; !ok || node != "h1" {
+		t.Errorf("Missing or invalid node field: %v", node)
+	}
+
+	if l := logs.FilterField(zap.String("node", "h2")).Filter(func(e observer.LoggedEntry) bool {
+		return e.Level < zapcore.WarnLevel
+	}).Len(); l > 0 {
+		t.Errorf("Node with restricted log level emitted %d entries", l)
+	}
+
+	if logs.FilterMessage("Created new network").Len() != 1 {
+		t.Error("Missing log entry for created network")
+	}
+}
@@ -0,0 +1,59 @@
+package gont_test
+
+import (
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+func TestMeta(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	i := o.Interface("veth0", h1)
+
+	if err := n.AddLink(i,
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if v := h1.Meta("record"); v != "" {
+		t.Errorf("Unexpected metadata for unset key: %s", v)
+	}
+
+	h1.SetMeta("record", "42")
+	i.SetMeta("cable", "A1")
+
+	if v := h1.Meta("record"); v != "42" {
+		t.Errorf("Got invalid node metadata: %s", v)
+	}
+
+	if v := h1.Interface("veth0").Meta("cable"); v != "A1" {
+		t.Errorf("Got invalid interface metadata: %s", v)
+	}
+
+	var node g.Node = h2
+	node.SetMeta("record", "43")
+
+	if v := node.Meta("record"); v != "43" {
+		t.Errorf("Got invalid node metadata: %s", v)
+	}
+}
@@ -0,0 +1,249 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	nlraw "github.com/vishvananda/netlink/nl"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// From: include/uapi/linux/mroute.h and include/uapi/linux/mroute6.h
+const (
+	mrtInit   = 200 // MRT_INIT and MRT6_INIT
+	mrtAddVif = 202 // MRT_ADD_VIF and MRT6_ADD_MIF
+	mrtAddMfc = 204 // MRT_ADD_MFC and MRT6_ADD_MFC
+
+	mrtMaxVifs      = 32  // MAXVIFS
+	mrt6MaxMifs     = 256 // IF_SETSIZE
+	vifFUseIfindex  = 0x8 // VIFF_USE_IFINDEX
+	vifDefaultTTL   = 1
+	sizeofVifctl    = 16
+	sizeofMfcctl    = 60
+	sizeofMif6ctl   = 12
+	sizeofMf6cctl   = 92
+	sizeofSockaddr6 = unix.SizeofSockaddrInet6
+)
+
+// multicastRouter holds an open multicast routing socket of a node.
+// The kernel removes all multicast routes as soon as the socket is closed.
+type multicastRouter struct {
+	fd     int
+	family int
+
+	// Mapping of interface indices to virtual (multicast) interface indices
+	vifs map[int]uint16
+}
+
+// EnableMulticastForwarding enables the multicast forwarding for IPv4 and IPv6 in the node.
+//
+// Multicast routes can be added afterwards via AddMulticastRoute.
+func (n *BaseNode) EnableMulticastForwarding() error {
+	for _, family := range []int{unix.AF_INET, unix.AF_INET6} {
+		if _, err := n.multicastRouter(family); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddMulticastRoute adds a source-specific (S,G) multicast route.
+//
+// Multicast traffic from source src towards group which is received on the
+// interface iif is forwarded to all interfaces in oifs.
+//
+// Multicast forwarding is enabled automatically if it has not been yet.
+func (n *BaseNode) AddMulticastRoute(src, group net.IP, iif string, oifs ...string) error {
+	if !group.IsMulticast() {
+		return fmt.Errorf("invalid multicast group: %s", group)
+	}
+
+	family := unix.AF_INET6
+	if group.To4() != nil {
+		family = unix.AF_INET
+
+		if src.To4() == nil {
+			return errors.New("mismatching address families of source and group")
+		}
+	} else if src.To4() != nil {
+		return errors.New("mismatching address families of source and group")
+	}
+
+	mr, err := n.multicastRouter(family)
+	if err != nil {
+		return err
+	}
+
+	iVif, err := n.multicastVif(mr, iif)
+	if err != nil {
+		return err
+	}
+
+	oVifs := []uint16{}
+	for _, oif := range oifs {
+		oVif, err := n.multicastVif(mr, oif)
+		if err != nil {
+			return err
+		}
+
+		oVifs = append(oVifs, oVif)
+	}
+
+	n.logger.Info("Add multicast route",
+		zap.Any("src", src),
+		zap.Any("group", group),
+		zap.String("iif", iif),
+		zap.Strings("oifs", oifs),
+	)
+
+	var b []byte
+	var level int
+	if family == unix.AF_INET {
+		level = unix.IPPROTO_IP
+
+		// struct mfcctl
+		b = make([]byte, sizeofMfcctl)
+		copy(b[0:4], src.To4())
+		copy(b[4:8], group.To4())
+		nlraw.NativeEndian().PutUint16(b[8:], iVif)
+		for _, oVif := range oVifs {
+			b[10+oVif] = vifDefaultTTL
+		}
+	} else {
+		level = unix.IPPROTO_IPV6
+
+		// struct mf6cctl
+		b = make([]byte, sizeofMf6cctl)
+		putSockaddr6(b[0:], src)
+		putSockaddr6(b[sizeofSockaddr6:], group)
+		nlraw.NativeEndian().PutUint16(b[2*sizeofSockaddr6:], iVif)
+		for _, oVif := range oVifs {
+			off := 2*sizeofSockaddr6 + 4 + 4*int(oVif/32)
+			bits := nlraw.NativeEndian().Uint32(b[off:])
+			nlraw.NativeEndian().PutUint32(b[off:], bits|1<<(oVif%32))
+		}
+	}
+
+	if err := unix.SetsockoptString(mr.fd, level, mrtAddMfc, string(b)); err != nil {
+		return fmt.Errorf("failed to add multicast route: %w", err)
+	}
+
+	return nil
+}
+
+func (n *BaseNode) multicastRouter(family int) (*multicastRouter, error) {
+	if mr, ok := n.multicastRouters[family]; ok {
+		return mr, nil
+	}
+
+	var proto, level int
+	if family == unix.AF_INET {
+		proto = unix.IPPROTO_IGMP
+		level = unix.IPPROTO_IP
+	} else {
+		proto = unix.IPPROTO_ICMPV6
+		level = unix.IPPROTO_IPV6
+	}
+
+	mr := &multicastRouter{
+		family: family,
+		vifs:   map[int]uint16{},
+	}
+
+	// The socket must be opened within the namespace of the node
+	if err := n.RunFunc(func() (err error) {
+		mr.fd, err = unix.Socket(family, unix.SOCK_RAW, proto)
+		return
+	}); err != nil {
+		return nil, fmt.Errorf("failed to open multicast routing socket: %w", err)
+	}
+
+	if err := unix.SetsockoptInt(mr.fd, level, mrtInit, 1); err != nil {
+		unix.Close(mr.fd)
+		return nil, fmt.Errorf("failed to enable multicast forwarding: %w", err)
+	}
+
+	n.logger.Info("Enabled multicast forwarding",
+		zap.Int("family", family),
+	)
+
+	if n.multicastRouters == nil {
+		n.multicastRouters = map[int]*multicastRouter{}
+	}
+
+	n.multicastRouters[family] = mr
+
+	return mr, nil
+}
+
+func (n *BaseNode) multicastVif(mr *multicastRouter, name string) (uint16, error) {
+	link, err := n.nlHandle.LinkByName(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find interface %s: %w", name, err)
+	}
+
+	idx := link.Attrs().Index
+	if vif, ok := mr.vifs[idx]; ok {
+		return vif, nil
+	}
+
+	vif := uint16(len(mr.vifs))
+
+	var b []byte
+	var level int
+	if mr.family == unix.AF_INET {
+		if vif >= mrtMaxVifs {
+			return 0, fmt.Errorf("exceeded maximum number of multicast interfaces: %d", mrtMaxVifs)
+		}
+
+		level = unix.IPPROTO_IP
+
+		// struct vifctl
+		b = make([]byte, sizeofVifctl)
+		nlraw.NativeEndian().PutUint16(b[0:], vif)
+		b[2] = vifFUseIfindex
+		b[3] = vifDefaultTTL
+		nlraw.NativeEndian().PutUint32(b[8:], uint32(idx))
+	} else {
+		if vif >= mrt6MaxMifs {
+			return 0, fmt.Errorf("exceeded maximum number of multicast interfaces: %d", mrt6MaxMifs)
+		}
+
+		level = unix.IPPROTO_IPV6
+
+		// struct mif6ctl
+		b = make([]byte, sizeofMif6ctl)
+		nlraw.NativeEndian().PutUint16(b[0:], vif)
+		b[3] = vifDefaultTTL
+		nlraw.NativeEndian().PutUint16(b[4:], uint16(idx))
+	}
+
+	if err := unix.SetsockoptString(mr.fd, level, mrtAddVif, string(b)); err != nil {
+		return 0, fmt.Errorf("failed to add multicast interface %s: %w", name, err)
+	}
+
+	mr.vifs[idx] = vif
+
+	return vif, nil
+}
+
+func (n *BaseNode) closeMulticastRouters() error {
+	for family, mr := range n.multicastRouters {
+		if err := unix.Close(mr.fd); err != nil {
+			return err
+		}
+
+		delete(n.multicastRouters, family)
+	}
+
+	return nil
+}
+
+// putSockaddr6 encodes an IPv6 address as struct sockaddr_in6
+func putSockaddr6(b []byte, ip net.IP) {
+	nlraw.NativeEndian().PutUint16(b[0:], unix.AF_INET6)
+	copy(b[8:24], ip.To16())
+}
@@ -0,0 +1,141 @@
+package gont_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+// TestMulticastRoute forwards multicast traffic from h1 via r1 to h2
+// using source-specific multicast routes
+//
+//  h1 <-> r1 <-> h2
+func TestMulticastRoute(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+		r1     *g.Router
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if r1, err = n.AddRouter("r1"); err != nil {
+		t.Fatalf("Failed to create router: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1",
+		o.DefaultGatewayIP("10.0.1.1"),
+		o.DefaultGatewayIP("fc:1::1"),
+		o.Interface("veth0", r1,
+			o.AddressIP("10.0.1.2/24"),
+			o.AddressIP("fc:1::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2",
+		o.Interface("veth0", r1,
+			o.AddressIP("10.0.2.2/24"),
+			o.AddressIP("fc:2::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	for _, addrs := range []struct {
+		Network string
+		Source  string
+		Group   string
+		Router  []string
+	}{
+		{"udp4", "10.0.1.2", "239.1.1.1", []string{"10.0.1.1/24", "10.0.2.1/24"}},
+		{"udp6", "fc:1::2", "ff0e::114", []string{"fc:1::1/64", "fc:2::1/64"}},
+	} {
+		for i, name := range []string{"veth-h1", "veth-h2"} {
+			if err := r1.LinkAddAddress(name, net.IPNet(o.AddressIP(addrs.Router[i]))); err != nil {
+				t.Fatalf("Failed to add address: %s", err)
+			}
+		}
+
+		group := net.ParseIP(addrs.Group)
+		src := net.ParseIP(addrs.Source)
+
+		if err := r1.AddMulticastRoute(src, group, "veth-h1", "veth-h2"); err != nil {
+			t.Fatalf("Failed to add multicast route: %s", err)
+		}
+
+		var rconn, sconn *net.UDPConn
+		if err := h2.RunFunc(func() error {
+			intf, err := net.InterfaceByName("veth0")
+			if err != nil {
+				return err
+			}
+
+			rconn, err = net.ListenMulticastUDP(addrs.Network, intf, &net.UDPAddr{IP: group, Port: 5000})
+			return err
+		}); err != nil {
+			t.Fatalf("Failed to join multicast group: %s", err)
+		}
+		defer rconn.Close()
+
+		if err := h1.RunFunc(func() error {
+			sconn, err = net.DialUDP(addrs.Network, nil, &net.UDPAddr{IP: group, Port: 5000})
+			return err
+		}); err != nil {
+			t.Fatalf("Failed to open socket: %s", err)
+		}
+		defer sconn.Close()
+
+		if err := setMulticastTTL(sconn, len(group.To4()) == net.IPv4len, 8); err != nil {
+			t.Fatalf("Failed to set multicast TTL: %s", err)
+		}
+
+		if err := rconn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatalf("Failed to set deadline: %s", err)
+		}
+
+		go func() {
+			for i := 0; i < 10; i++ {
+				sconn.Write([]byte("hello"))
+				time.Sleep(100 * time.Millisecond)
+			}
+		}()
+
+		buf := make([]byte, 128)
+		m, from, err := rconn.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatalf("Failed to receive multicast traffic for group %s: %s", group, err)
+		}
+
+		if string(buf[:m]) != "hello" || !from.IP.Equal(src) {
+			t.Errorf("Received invalid packet from %s: %s", from, buf[:m])
+		}
+	}
+}
+
+func setMulticastTTL(c *net.UDPConn, isV4 bool, ttl int) error {
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var serr error
+	if err := rc.Control(func(fd uintptr) {
+		if isV4 {
+			serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MULTICAST_TTL, ttl)
+		} else {
+			serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MULTICAST_HOPS, ttl)
+		}
+	}); err != nil {
+		return err
+	}
+
+	return serr
+}
@@ -0,0 +1,122 @@
+package gont
+
+import (
+	"fmt"
+	"net"
+
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// MulticastMembership is the membership of a node in a multicast group.
+// See BaseNode.JoinMulticast().
+type MulticastMembership struct {
+	Group     net.IP
+	Interface string
+
+	node *BaseNode
+
+	// fd is the socket which has joined the group.
+	// The kernel leaves the group as soon as it is closed.
+	fd int
+}
+
+// JoinMulticast joins the multicast group on the interface iface of the node.
+//
+// IPv4 groups are joined via IGMP and IPv6 groups via MLD. While joined,
+// multicast packets towards the group are accepted by the node and delivered
+// to all sockets which are bound to the destination port.
+// The node remains member of the group until the membership is left or the
+// node is torn down.
+func (n *BaseNode) JoinMulticast(iface string, group net.IP) (*MulticastMembership, error) {
+	if !group.IsMulticast() {
+		return nil, fmt.Errorf("invalid multicast group: %s", group)
+	}
+
+	link, err := n.nlHandle.LinkByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", iface, err)
+	}
+
+	family := unix.AF_INET6
+	if group.To4() != nil {
+		family = unix.AF_INET
+	}
+
+	m := &MulticastMembership{
+		Group:     group,
+		Interface: iface,
+		node:      n,
+	}
+
+	// The socket must be opened within the namespace of the node
+	if err := n.RunFunc(func() (err error) {
+		m.fd, err = unix.Socket(family, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+		return
+	}); err != nil {
+		return nil, fmt.Errorf("failed to open socket: %w", err)
+	}
+
+	if family == unix.AF_INET {
+		mreq := &unix.IPMreqn{
+			Ifindex: int32(link.Attrs().Index),
+		}
+		copy(mreq.Multiaddr[:], group.To4())
+
+		err = unix.SetsockoptIPMreqn(m.fd, unix.IPPROTO_IP, unix.IP_ADD_MEMBERSHIP, mreq)
+	} else {
+		mreq := &unix.IPv6Mreq{
+			Interface: uint32(link.Attrs().Index),
+		}
+		copy(mreq.Multiaddr[:], group.To16())
+
+		err = unix.SetsockoptIPv6Mreq(m.fd, unix.IPPROTO_IPV6, unix.IPV6_JOIN_GROUP, mreq)
+	}
+	if err != nil {
+		unix.Close(m.fd)
+		return nil, fmt.Errorf("failed to join multicast group %s: %w", group, err)
+	}
+
+	n.logger.Info("Joined multicast group",
+		zap.Any("group", group),
+		zap.String("intf", iface),
+	)
+
+	n.memberships = append(n.memberships, m)
+
+	return m, nil
+}
+
+// Leave leaves the multicast group.
+// Leaving a group which has been left already has no effect.
+func (m *MulticastMembership) Leave() error {
+	joined := false
+	for i, o := range m.node.memberships {
+		if o == m {
+			m.node.memberships = append(m.node.memberships[:i], m.node.memberships[i+1:]...)
+			joined = true
+			break
+		}
+	}
+
+	if !joined {
+		return nil
+	}
+
+	m.node.logger.Info("Leaving multicast group",
+		zap.Any("group", m.Group),
+		zap.String("intf", m.Interface),
+	)
+
+	return unix.Close(m.fd)
+}
+
+func (n *BaseNode) leaveMulticastGroups() error {
+	for len(n.memberships) > 0 {
+		if err := n.memberships[0].Leave(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package gont_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// TestJoinMulticast sends multicast traffic from h1 to h2
+// which only accepts it while being member of the group
+//
+//  h1 <-> h2
+func TestJoinMulticast(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIP("10.0.0.1/24"),
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth0", h2,
+			o.AddressIP("10.0.0.2/24"),
+			o.AddressIP("fc::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if err := h1.AddRoute(&nl.Route{
+		Dst:       &net.IPNet{IP: net.IPv4(224, 0, 0, 0), Mask: net.CIDRMask(4, 32)},
+		LinkIndex: h1.Interface("veth0").Link.Attrs().Index,
+	}); err != nil {
+		t.Fatalf("Failed to add multicast route: %s", err)
+	}
+
+	for _, tc := range []struct {
+		network string
+		group   string
+	}{
+		{"udp4", "239.1.1.1"},
+		{"udp6", "ff0e::114"},
+	} {
+		t.Run(tc.network, func(t *testing.T) {
+			group := net.ParseIP(tc.group)
+
+			// The socket is bound to the wildcard address and does not join the group itself
+			var rconn, sconn *net.UDPConn
+			if err := h2.RunFunc(func() (err error) {
+				rconn, err = net.ListenUDP(tc.network, &net.UDPAddr{Port: 5000})
+				return
+			}); err != nil {
+				t.Fatalf("Failed to open socket: %s", err)
+			}
+			defer rconn.Close()
+
+			if err := h1.RunFunc(func() (err error) {
+				sconn, err = net.DialUDP(tc.network, nil, &net.UDPAddr{IP: group, Port: 5000})
+				return
+			}); err != nil {
+				t.Fatalf("Failed to open socket: %s", err)
+			}
+			defer sconn.Close()
+
+			received := func() bool {
+				if err := rconn.SetReadDeadline(time.Now().Add(500 * time.Millisecond)); err != nil {
+					t.Fatalf("Failed to set deadline: %s", err)
+				}
+
+				if _, err := sconn.Write([]byte("hello")); err != nil {
+					t.Fatalf("Failed to send packet: %s", err)
+				}
+
+				buf := make([]byte, 128)
+				m, _, err := rconn.ReadFromUDP(buf)
+
+				return err == nil && string(buf[:m]) == "hello"
+			}
+
+			if received() {
+				t.Fatal("Received multicast traffic before joining the group")
+			}
+
+			m, err := h2.JoinMulticast("veth0", group)
+			if err != nil {
+				t.Fatalf("Failed to join multicast group: %s", err)
+			}
+
+			if !received() {
+				t.Fatal("Failed to receive multicast traffic after joining the group")
+			}
+
+			if err := m.Leave(); err != nil {
+				t.Fatalf("Failed to leave multicast group: %s", err)
+			}
+
+			if received() {
+				t.Fatal("Received multicast traffic after leaving the group")
+			}
+		})
+	}
+
+	if _, err := h2.JoinMulticast("veth0", net.ParseIP("10.0.0.1")); err == nil {
+		t.Error("Joined non-multicast address")
+	}
+
+	i := h2.Interface("veth0")
+	for _, enabled := range []bool{true, false} {
+		if err := i.SetAllMulticast(enabled); err != nil {
+			t.Fatalf("Failed to set all-multicast mode: %s", err)
+		}
+
+		link, err := h2.NetlinkHandle().LinkByName("veth0")
+		if err != nil {
+			t.Fatalf("Failed to find link: %s", err)
+		}
+
+		if allMulti := link.Attrs().RawFlags&unix.IFF_ALLMULTI != 0; allMulti != enabled {
+			t.Errorf("All-multicast mode is %t, expected %t", allMulti, enabled)
+		}
+	}
+}
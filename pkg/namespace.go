@@ -26,11 +26,15 @@ type Namespace struct {
 }
 
 func NewNamespace(name string) (*Namespace, error) {
+	return newNamespace(name, zap.L())
+}
+
+func newNamespace(name string, logger *zap.Logger) (*Namespace, error) {
 	var err error
 
 	ns := &Namespace{
 		Name:   name,
-		logger: zap.L().Named("namespace").With(zap.String("ns", name)),
+		logger: logger.Named("namespace").With(zap.String("ns", name)),
 	}
 
 	ns.logger.Info("Creating new namespace")
@@ -44,6 +48,7 @@ func NewNamespace(name string) (*Namespace, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer syscall.Close(curNetNs)
 
 	// Create new named namespace
 	if ns.NsHandle, err = netns.NewNamed(ns.Name); err != nil {
@@ -70,6 +75,12 @@ func (ns *Namespace) Close() error {
 			return err
 		}
 
+		if err := ns.NsHandle.Close(); err != nil {
+			return err
+		}
+
+		ns.NsHandle = netns.None()
+
 		ns.logger.Info("Deleted namespace")
 	}
 
@@ -77,7 +88,10 @@ func (ns *Namespace) Close() error {
 }
 
 func (ns *Namespace) RunFunc(cb Callback) error {
-	exit, _ := ns.Enter()
+	exit, err := ns.Enter()
+	if err != nil {
+		return err
+	}
 	defer exit()
 
 	errCb := cb()
@@ -85,24 +99,48 @@ func (ns *Namespace) RunFunc(cb Callback) error {
 	return errCb
 }
 
+// WithNamespace runs cb in the network namespace so that any networking
+// code in the callback uses the network stack of the namespace.
+//
+// The calling goroutine is locked to its OS thread for the duration of the call.
+// Calls can be nested and the previous namespace is restored even if cb panics.
+func (ns *Namespace) WithNamespace(cb func()) error {
+	exit, err := ns.Enter()
+	if err != nil {
+		return err
+	}
+	defer exit()
+
+	cb()
+
+	return nil
+}
+
 func (ns *Namespace) Enter() (func(), error) {
 	runtime.LockOSThread()
 
 	// Save fd to current network namespace
-	curNetNs, err := syscall.Open("/proc/self/ns/net", syscall.O_RDONLY, 0777)
+	curNetNs, err := syscall.Open("/proc/thread-self/ns/net", syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
 	if err != nil {
+		runtime.UnlockOSThread()
 		return nil, err
 	}
 
 	// Switch to network namespace
 	if err := unix.Setns(int(ns.NsHandle), syscall.CLONE_NEWNET); err != nil {
+		syscall.Close(curNetNs)
+		runtime.UnlockOSThread()
 		return nil, err
 	}
 
 	ns.logger.Debug("Entered namespace")
 
 	return func() {
-		// Restore original netns namespace
+		defer syscall.Close(curNetNs)
+
+		// Restore original netns namespace.
+		// The thread stays locked on failure so that the
+		// Go runtime terminates it with the goroutine.
 		if err := unix.Setns(curNetNs, syscall.CLONE_NEWNET); err != nil {
 			panic(err)
 		}
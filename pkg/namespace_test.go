@@ -1,9 +1,12 @@
 package gont_test
 
 import (
+	"net"
+	"runtime"
 	"testing"
 
 	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
 	"github.com/vishvananda/netns"
 )
 
@@ -32,3 +35,90 @@ func TestNamespace(t *testing.T) {
 		t.Errorf("Failed to run func: %s", err)
 	}
 }
+
+//  n1 <-> n2
+func TestWithNamespace(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		n1, n2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if n1, err = n.AddHost("n1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if n2, err = n.AddHost("n2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth1", n1),
+		o.Interface("veth2", n2),
+	); err != nil {
+		t.Fatalf("Failed to connect nodes: %s", err)
+	}
+
+	hasInterface := func(name string) bool {
+		_, err := net.InterfaceByName(name)
+		return err == nil
+	}
+
+	if err := n1.WithNamespace(func() {
+		if !hasInterface("veth1") {
+			t.Error("Interface of node is missing")
+		}
+
+		// Nested namespaces are restored
+		if err := n2.WithNamespace(func() {
+			if !hasInterface("veth2") || hasInterface("veth1") {
+				t.Error("Nested namespace has not been entered")
+			}
+		}); err != nil {
+			t.Errorf("Failed to enter nested namespace: %s", err)
+		}
+
+		if !hasInterface("veth1") {
+			t.Error("Namespace has not been restored after nested call")
+		}
+	}); err != nil {
+		t.Fatalf("Failed to enter namespace: %s", err)
+	}
+
+	// The namespace is restored after a panic
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	before, err := netns.Get()
+	if err != nil {
+		t.Fatalf("Failed to get namespace: %s", err)
+	}
+	defer before.Close()
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Panic has not been propagated")
+			}
+		}()
+
+		n1.WithNamespace(func() {
+			panic("simulated failure")
+		})
+	}()
+
+	after, err := netns.Get()
+	if err != nil {
+		t.Fatalf("Failed to get namespace: %s", err)
+	}
+	defer after.Close()
+
+	if !after.Equal(before) {
+		t.Error("Namespace has not been restored after panic")
+	}
+}
@@ -26,7 +26,11 @@ func (n *NAT) Apply(i *Interface) {
 }
 
 func (n *Network) AddNAT(name string, opts ...Option) (*NAT, error) {
-	rtr, err := n.AddRouter(name, opts...)
+	if err := checkOptions(opts, targetNode, targetHost, targetNAT); err != nil {
+		return nil, err
+	}
+
+	rtr, err := n.addRouter(name, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +54,10 @@ func (n *Network) AddNAT(name string, opts ...Option) (*NAT, error) {
 }
 
 func (n *Network) AddHostNAT(name string, opts ...Option) (*NAT, error) {
+	if err := checkOptions(opts, targetNode, targetNAT); err != nil {
+		return nil, err
+	}
+
 	host := n.HostNode
 
 	if err := host.EnableForwarding(); err != nil {
@@ -0,0 +1,172 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	nl "github.com/vishvananda/netlink"
+	nlraw "github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// From: include/uapi/linux/pkt_sched.h
+const (
+	tcaNetemSlot      = 12 // TCA_NETEM_SLOT
+	sizeofTcNetemSlot = 40
+)
+
+// NetemSlot configures Netem to release packets in bursts (slots)
+// like the schedulers of cellular or WiFi networks.
+//
+// Packets are held until the next slot starts. The start of the following
+// slot is delayed by a random duration between MinDelay and MaxDelay.
+// A slot ends once Packets packets or Bytes bytes have been released.
+// Zero values for Packets or Bytes do not limit the size of a slot.
+//
+// Requires Linux 4.19 or newer.
+type NetemSlot struct {
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	Packets  int
+	Bytes    int
+}
+
+// Validate checks for combinations of parameters which are rejected
+// or silently ignored by the kernel.
+func (s *NetemSlot) Validate() error {
+	switch {
+	case s.MinDelay < 0 || s.MaxDelay < 0:
+		return errors.New("slot delays must not be negative")
+	case s.MaxDelay == 0:
+		return errors.New("slot requires a delay") // disables slotting
+	case s.MinDelay > s.MaxDelay:
+		return errors.New("minimum slot delay exceeds maximum delay")
+	case s.Packets < 0 || s.Bytes < 0:
+		return errors.New("slot limits must not be negative")
+	case int64(s.Packets) > 1<<31-1 || int64(s.Bytes) > 1<<31-1:
+		return errors.New("slot limits exceed 32-bit range")
+	}
+
+	return nil
+}
+
+// serialize encodes the slot as struct tc_netem_slot
+func (s *NetemSlot) serialize() []byte {
+	b := make([]byte, sizeofTcNetemSlot)
+	native := nlraw.NativeEndian()
+
+	native.PutUint64(b[0:], uint64(s.MinDelay.Nanoseconds()))
+	native.PutUint64(b[8:], uint64(s.MaxDelay.Nanoseconds()))
+	native.PutUint32(b[16:], uint32(s.Packets))
+	native.PutUint32(b[20:], uint32(s.Bytes))
+
+	// Delay distribution and jitter are unused
+	return b
+}
+
+// UpdateNetem changes the parameters of the Netem qdisc of a running interface.
+//
+// The qdisc must have been configured by the WithNetem() option.
+// Its handle, parent and slot configuration remain unchanged.
+func (i *Interface) UpdateNetem(attrs nl.NetemQdiscAttrs) error {
+	if i.Flags&WithQdiscNetem == 0 {
+		return fmt.Errorf("interface %s has no Netem qdisc", i.Name)
+	}
+
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	if err := i.Node.NetlinkHandle().QdiscChange(nl.NewNetem(i.netemQdiscAttrs(link), attrs)); err != nil {
+		return fmt.Errorf("failed to change Netem qdisc: %w", err)
+	}
+
+	i.Netem = attrs
+
+	return nil
+}
+
+// netemQdiscAttrs returns the attributes of the Netem qdisc of the interface.
+// Unless specified explicitly, Netem uses the handle 1:0 at the root.
+func (i *Interface) netemQdiscAttrs(link nl.Link) nl.QdiscAttrs {
+	attrs := i.NetemAttrs
+	attrs.LinkIndex = link.Attrs().Index
+	if attrs.Handle == 0 {
+		attrs.Handle = nl.MakeHandle(1, 0)
+	}
+	if attrs.Parent == 0 {
+		attrs.Parent = nl.HANDLE_ROOT
+	}
+
+	return attrs
+}
+
+// addNetemQdisc adds a Netem qdisc with a slot configuration to the link.
+func (n *BaseNode) addNetemQdisc(q *nl.Netem, slot *NetemSlot) error {
+	// The netlink library lacks support for slots.
+	// Hence we construct the request ourself.
+	req := nlraw.NewNetlinkRequest(unix.RTM_NEWQDISC, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	req.AddData(&nlraw.TcMsg{
+		Family:  nlraw.FAMILY_ALL,
+		Ifindex: int32(q.LinkIndex),
+		Handle:  q.Handle,
+		Parent:  q.Parent,
+	})
+	req.AddData(nlraw.NewRtAttr(nlraw.TCA_KIND, nlraw.ZeroTerminated(q.Type())))
+
+	qopt := nlraw.TcNetemQopt{
+		Latency:   q.Latency,
+		Limit:     q.Limit,
+		Loss:      q.Loss,
+		Gap:       q.Gap,
+		Duplicate: q.Duplicate,
+		Jitter:    q.Jitter,
+	}
+
+	opts := nlraw.NewRtAttr(nlraw.TCA_OPTIONS, qopt.Serialize())
+
+	if q.DelayCorr > 0 || q.LossCorr > 0 || q.DuplicateCorr > 0 {
+		corr := nlraw.TcNetemCorr{
+			DelayCorr: q.DelayCorr,
+			LossCorr:  q.LossCorr,
+			DupCorr:   q.DuplicateCorr,
+		}
+		opts.AddRtAttr(nlraw.TCA_NETEM_CORR, corr.Serialize())
+	}
+
+	if q.CorruptProb > 0 {
+		corrupt := nlraw.TcNetemCorrupt{
+			Probability: q.CorruptProb,
+			Correlation: q.CorruptCorr,
+		}
+		opts.AddRtAttr(nlraw.TCA_NETEM_CORRUPT, corrupt.Serialize())
+	}
+
+	if q.ReorderProb > 0 {
+		reorder := nlraw.TcNetemReorder{
+			Probability: q.ReorderProb,
+			Correlation: q.ReorderCorr,
+		}
+		opts.AddRtAttr(nlraw.TCA_NETEM_REORDER, reorder.Serialize())
+	}
+
+	opts.AddRtAttr(tcaNetemSlot, slot.serialize())
+
+	req.AddData(opts)
+
+	s, err := nlraw.GetNetlinkSocketAt(n.NsHandle, netns.None(), unix.NETLINK_ROUTE)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	req.Sockets = map[int]*nlraw.SocketHandle{
+		unix.NETLINK_ROUTE: {Socket: s},
+	}
+
+	_, err = req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
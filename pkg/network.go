@@ -3,6 +3,7 @@ package gont
 import (
 	"errors"
 	"fmt"
+	"net"
 	"sync"
 	"syscall"
 
@@ -24,14 +25,46 @@ type Network struct {
 	HostNode *Host
 	BasePath string
 
-	Persistent bool
-	NSPrefix   string
+	Persistent  bool
+	NSPrefix    string
+	Nameservers []net.IP
+	Logger      *zap.Logger
+	SubnetPools []net.IPNet
+	IPv6Prefix  *net.IPNet
+	DisableIPv4 bool
+	DisableIPv6 bool
+	Init        bool
+
+	KeepOnFailure bool
+
+	// KernelLog attaches kernel log messages to errors of
+	// netlink operations. See CaptureKernelLog().
+	KernelLog bool
+
+	// UserNamespace requires the network to be created within
+	// the user namespace of ExecRootless().
+	UserNamespace bool
 
 	DefaultOptions Options
 
+	subnets     []net.IPNet
+	subnetsLock sync.Mutex
+
+	// segments are the IPv6 subnets allocated for switches by their names
+	segments map[string]net.IPNet
+
+	noAutoHosts bool
+	hostsStale  bool
+	hostsLock   sync.Mutex
+
 	logger *zap.Logger
 }
 
+// HostNode returns a node which represents the default network namespace of the host.
+//
+// Links can be established between the host node and other nodes of the network.
+// Tearing down the host node will only remove the interfaces which have been created
+// by Gont. The namespace itself is neither deleted nor unmounted.
 func HostNode(n *Network) *Host {
 	baseNs, err := netns.Get()
 	if err != nil {
@@ -51,19 +84,15 @@ func HostNode(n *Network) *Host {
 				NsHandle: baseNs,
 				nlHandle: baseHandle,
 				nftConn:  &nft.Conn{},
-				logger:   zap.L().Named("namespace"),
+				logger:   n.Logger.Named("namespace"),
 			},
 			network: n,
-			logger:  zap.L().Named("host"),
+			logger:  n.Logger.Named("host"),
 		},
 	}
 }
 
 func NewNetwork(name string, opts ...Option) (*Network, error) {
-	if err := CheckCaps(); err != nil {
-		return nil, err
-	}
-
 	if name == "" {
 		name = GenerateNetworkName()
 	}
@@ -77,7 +106,7 @@ func NewNetwork(name string, opts ...Option) (*Network, error) {
 		NodesLock:      sync.RWMutex{},
 		DefaultOptions: opts,
 		NSPrefix:       "gont-",
-		logger:         zap.L().Named("network").With(zap.String("network", name)),
+		Logger:         zap.L(),
 	}
 
 	// Apply network specific options
@@ -87,6 +116,16 @@ func NewNetwork(name string, opts ...Option) (*Network, error) {
 		}
 	}
 
+	if n.UserNamespace && !IsRootless() {
+		return nil, ErrNotRootless
+	}
+
+	if err := CheckCaps(); err != nil {
+		return nil, err
+	}
+
+	n.logger = n.Logger.Named("network").With(zap.String("network", name))
+
 	if stat, err := os.Stat(basePath); err == nil && stat.IsDir() {
 		return nil, syscall.EEXIST
 	}
@@ -111,6 +150,8 @@ func NewNetwork(name string, opts ...Option) (*Network, error) {
 		return nil, fmt.Errorf("failed to generate configuration files: %w", err)
 	}
 
+	registerNetwork(n)
+
 	n.logger.Info("Created new network")
 
 	return n, nil
@@ -163,21 +204,54 @@ func (n *Network) Teardown() error {
 	defer n.NodesLock.Unlock()
 
 	for name, node := range n.Nodes {
-		if err := node.Teardown(); err != nil {
-			return err
+		// The host node is registered by AddHostNAT()
+		// but torn down separately below
+		if node != Node(n.HostNode) {
+			if err := node.Teardown(); err != nil {
+				return err
+			}
 		}
 
 		delete(n.Nodes, name)
 	}
 
+	// Remove interfaces which have been added to the host namespace.
+	// The handle is already released if the network has been torn down before.
+	if n.HostNode != nil && n.HostNode.NsHandle >= 0 {
+		if err := n.HostNode.Teardown(); err != nil {
+			return err
+		}
+
+		// Release our handle to the host namespace
+		if err := n.HostNode.NsHandle.Close(); err != nil {
+			return err
+		}
+
+		n.HostNode.NsHandle = netns.None()
+	}
+
 	if n.BasePath != "" {
 		os.RemoveAll(n.BasePath)
 	}
 
+	unregisterNetwork(n)
+
 	return nil
 }
 
+// Close tears down the network unless it is persistent.
+//
+// If KeepOnFailure is set and Close is deferred by the caller,
+// a panic is recovered in order to keep the network for a post-mortem
+// inspection. The panic is re-raised afterwards.
 func (n *Network) Close() error {
+	if n.KeepOnFailure {
+		if r := recover(); r != nil {
+			n.Keep()
+			panic(r)
+		}
+	}
+
 	if !n.Persistent {
 		if err := n.Teardown(); err != nil {
 			return err
@@ -195,3 +269,22 @@ func (n *Network) Register(m Node) {
 
 	n.Nodes[m.Name()] = m
 }
+
+// Keep marks the network as persistent so that its namespaces and files
+// are not removed by Close and logs their paths for inspection.
+func (n *Network) Keep() {
+	n.Persistent = true
+
+	n.logger.Warn("Keeping network for inspection",
+		zap.String("path", n.BasePath))
+
+	n.NodesLock.RLock()
+	defer n.NodesLock.RUnlock()
+
+	for _, node := range n.Nodes {
+		n.logger.Warn("Keeping node for inspection",
+			zap.String("node", node.Name()),
+			zap.String("netns", node.NetNSPath()),
+			zap.String("cmd", node.DebugCommand()))
+	}
+}
@@ -1,6 +1,7 @@
 package gont
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -64,6 +65,10 @@ func (n *Network) GenerateHostsFile() error {
 				for _, a := range i.Addresses {
 					add(n.Name(), a.IP)
 					add(n.Name()+"-"+i.Name, a.IP)
+
+					if n.Hostname != "" {
+						add(n.Hostname, a.IP)
+					}
 				}
 			}
 		}
@@ -76,10 +81,137 @@ func (n *Network) GenerateHostsFile() error {
 	return f.Sync()
 }
 
+// SetAutoHosts enables or disables the regeneration of the hosts file
+// whenever interfaces are added to or removed from nodes.
+//
+// Disabling it avoids rewriting the file for every interface during
+// bulk setups. When enabled again, an outdated file is regenerated once.
+func (n *Network) SetAutoHosts(enabled bool) error {
+	n.hostsLock.Lock()
+	n.noAutoHosts = !enabled
+	stale := n.hostsStale
+	n.hostsLock.Unlock()
+
+	if enabled && stale {
+		return n.RegenerateHosts()
+	}
+
+	return nil
+}
+
+// RegenerateHosts writes the hosts file regardless of SetAutoHosts().
+func (n *Network) RegenerateHosts() error {
+	n.hostsLock.Lock()
+	defer n.hostsLock.Unlock()
+
+	if err := n.GenerateHostsFile(); err != nil {
+		return err
+	}
+
+	n.hostsStale = false
+
+	return nil
+}
+
+// updateHostsFile regenerates the hosts file after
+// a change of the interfaces unless disabled by SetAutoHosts()
+func (n *Network) updateHostsFile() error {
+	n.hostsLock.Lock()
+	noAuto := n.noAutoHosts
+	if noAuto {
+		n.hostsStale = true
+	}
+	n.hostsLock.Unlock()
+
+	if noAuto {
+		return nil
+	}
+
+	return n.RegenerateHosts()
+}
+
 func (n *Network) GenerateConfigFiles() error {
+	if err := n.GenerateResolvConf(); err != nil {
+		return err
+	}
+
 	return n.GenerateIProute2Files()
 }
 
+// GenerateResolvConf writes the configured nameservers of the network
+// into a file located at /run/gont/<network>/files/etc/resolv.conf
+//
+// Processes started via BaseNode.Run or BaseNode.Start, will see
+// this file bind mounted at /etc/resolv.conf
+func (n *Network) GenerateResolvConf() error {
+	if len(n.Nameservers) == 0 {
+		return nil
+	}
+
+	fn := filepath.Join(n.BasePath, "files", "etc", "resolv.conf")
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(fn, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, "# Autogenerated resolv.conf file by Gont")
+
+	for _, ns := range n.Nameservers {
+		fmt.Fprintf(f, "nameserver %s\n", ns)
+	}
+
+	return nil
+}
+
+// GenerateNSSwitchConf writes a copy of the host's /etc/nsswitch.conf
+// with the hosts database replaced by the sources of NSSwitchHosts
+// into a file located at /run/gont/<network>/nodes/<node>/files/etc/nsswitch.conf
+//
+// Processes started via BaseNode.Run or BaseNode.Start, will see
+// this file bind mounted at /etc/nsswitch.conf
+func (n *BaseNode) GenerateNSSwitchConf() error {
+	if len(n.NSSwitchHosts) == 0 {
+		return errors.New("no sources for hosts database")
+	}
+
+	for _, src := range n.NSSwitchHosts {
+		if src == "" || strings.ContainsAny(src, " \t\n") {
+			return fmt.Errorf("invalid source: '%s'", src)
+		}
+	}
+
+	fn := filepath.Join(n.BasePath, "files", "etc", "nsswitch.conf")
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(fn, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, "# Autogenerated nsswitch.conf file by Gont")
+
+	// Keep all other databases of the host
+	if contentsOrig, err := os.ReadFile("/etc/nsswitch.conf"); err == nil {
+		for _, line := range strings.Split(string(contentsOrig), "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "hosts:") {
+				fmt.Fprintln(f, line)
+			}
+		}
+	}
+
+	fmt.Fprintf(f, "hosts: %s\n", strings.Join(n.NSSwitchHosts, " "))
+
+	return f.Sync()
+}
+
 func (n *Network) GenerateIProute2Files() error {
 	fn := filepath.Join(n.BasePath, "files/etc/iproute2/group")
 	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
@@ -0,0 +1,81 @@
+package gont
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// ForEachNode concurrently invokes fn for every node of the network.
+//
+// It waits for all invocations to return and combines their errors.
+// The host node is not included. The closure is invoked within the
+// namespace of the node if it is wrapped by InNamespace().
+func (n *Network) ForEachNode(fn func(Node) error) error {
+	var errs error
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for _, node := range n.sortedNodes() {
+		wg.Add(1)
+		go func(node Node) {
+			defer wg.Done()
+
+			if err := fn(node); err != nil {
+				mu.Lock()
+				errs = multierr.Append(errs, fmt.Errorf("node %s: %w", node.Name(), err))
+				mu.Unlock()
+			}
+		}(node)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+// ForEachNodeSerial invokes fn for every node of the network one after another
+// in the order of their names. It stops at the first error.
+//
+// The host node is not included.
+func (n *Network) ForEachNodeSerial(fn func(Node) error) error {
+	for _, node := range n.sortedNodes() {
+		if err := fn(node); err != nil {
+			return fmt.Errorf("node %s: %w", node.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// InNamespace wraps fn to be invoked within the network namespace of the
+// node to which it is passed. See ForEachNode().
+func InNamespace(fn func(Node) error) func(Node) error {
+	return func(node Node) error {
+		return node.base().RunFunc(func() error {
+			return fn(node)
+		})
+	}
+}
+
+// sortedNodes returns the nodes of the network except
+// the host node sorted by their names
+func (n *Network) sortedNodes() []Node {
+	n.NodesLock.RLock()
+	nodes := []Node{}
+	for _, node := range n.Nodes {
+		// The host node is registered by AddHostNAT()
+		if node != Node(n.HostNode) {
+			nodes = append(nodes, node)
+		}
+	}
+	n.NodesLock.RUnlock()
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name() < nodes[j].Name()
+	})
+
+	return nodes
+}
@@ -0,0 +1,88 @@
+package gont
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NetworkState is a snapshot of the kernel state of all nodes of a network
+type NetworkState struct {
+	Name  string      `json:"name"`
+	Nodes []NodeState `json:"nodes"`
+}
+
+// NetworkStateDiff contains the differences between two network states
+type NetworkStateDiff struct {
+	AddedNodes   []string `json:"added_nodes"`
+	RemovedNodes []string `json:"removed_nodes"`
+
+	// Nodes are the differences of nodes which exist in both states.
+	// Nodes without differences are omitted.
+	Nodes map[string]NodeStateDiff `json:"nodes"`
+}
+
+// Empty returns true if there are no differences
+func (d NetworkStateDiff) Empty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.Nodes) == 0
+}
+
+// Snapshot returns the states of all nodes of the network as returned by
+// BaseNode.Dump(). The nodes are sorted by their names.
+// The host node is not included.
+func (n *Network) Snapshot() (NetworkState, error) {
+	s := NetworkState{
+		Name:  n.Name,
+		Nodes: []NodeState{},
+	}
+
+	for _, node := range n.sortedNodes() {
+		ns, err := node.base().Dump()
+		if err != nil {
+			return s, fmt.Errorf("failed to dump node %s: %w", node.Name(), err)
+		}
+
+		s.Nodes = append(s.Nodes, ns)
+	}
+
+	return s, nil
+}
+
+// DiffNetwork compares two network states returned by Network.Snapshot()
+// and reports the nodes which have been added or removed as well as the
+// differences of the remaining nodes as reported by Diff().
+func DiffNetwork(before, after NetworkState) NetworkStateDiff {
+	d := NetworkStateDiff{
+		Nodes: map[string]NodeStateDiff{},
+	}
+
+	beforeNodes := map[string]NodeState{}
+	for _, ns := range before.Nodes {
+		beforeNodes[ns.Name] = ns
+	}
+
+	afterNodes := map[string]NodeState{}
+	for _, ns := range after.Nodes {
+		afterNodes[ns.Name] = ns
+
+		b, ok := beforeNodes[ns.Name]
+		if !ok {
+			d.AddedNodes = append(d.AddedNodes, ns.Name)
+			continue
+		}
+
+		if nd := Diff(b, ns); !nd.Empty() {
+			d.Nodes[ns.Name] = nd
+		}
+	}
+
+	for _, ns := range before.Nodes {
+		if _, ok := afterNodes[ns.Name]; !ok {
+			d.RemovedNodes = append(d.RemovedNodes, ns.Name)
+		}
+	}
+
+	sort.Strings(d.AddedNodes)
+	sort.Strings(d.RemovedNodes)
+
+	return d
+}
@@ -1,12 +1,24 @@
 package gont_test
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
 
 	g "github.com/stv0g/gont/pkg"
 	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
 	"github.com/vishvananda/netns"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/sys/unix"
 )
 
 func hasNetwork(name string) bool {
@@ -116,3 +128,650 @@ func TestNetworkExists(t *testing.T) {
 		t.Fatalf("Failed to create network: %s", err)
 	}
 }
+
+// TestNetworkDisableIPv6 checks that interfaces of an IPv4-only network
+// do not get any IPv6 addresses
+//
+//  h1 <-> h2
+func TestNetworkDisableIPv6(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, append(opts, o.WithDisableIPv6())...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIP("10.0.0.1/24")),
+		o.Interface("veth0", h2,
+			o.AddressIP("10.0.0.2/24")),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	for _, h := range []*g.Host{h1, h2} {
+		for _, name := range []string{"lo", "veth0"} {
+			link, err := h.NetlinkHandle().LinkByName(name)
+			if err != nil {
+				t.Fatalf("Failed to get link: %s", err)
+			}
+
+			addrs, err := h.NetlinkHandle().AddrList(link, nl.FAMILY_V6)
+			if err != nil {
+				t.Fatalf("Failed to list addresses: %s", err)
+			}
+
+			if len(addrs) > 0 {
+				t.Errorf("Interface %s/%s has IPv6 addresses: %v", h, name, addrs)
+			}
+		}
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth1", h1,
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth1", h2),
+	); err == nil {
+		t.Errorf("Expected adding an IPv6 address to fail")
+	}
+}
+
+// TestNetworkDisableIPv4 checks that IPv4 addresses are rejected
+// in an IPv6-only network
+func TestNetworkDisableIPv4(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, append(opts, o.WithDisableIPv4())...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth0", h2,
+			o.AddressIP("fc::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth1", h1,
+			o.AddressIP("10.0.0.1/24")),
+		o.Interface("veth1", h2),
+	); err == nil {
+		t.Errorf("Expected adding an IPv4 address to fail")
+	}
+}
+
+func TestNetworkInit(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, append(opts, o.WithInit())...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	pid := h1.InitPID()
+	if pid == 0 {
+		n.Close()
+		t.Fatalf("No init process has been started")
+	}
+
+	ns, err := netns.GetFromPid(pid)
+	if err != nil {
+		n.Close()
+		t.Fatalf("Failed to get namespace of init process: %s", err)
+	}
+	defer ns.Close()
+
+	if !ns.Equal(h1.NsHandle) {
+		t.Errorf("Init process is not running in the namespace of the node")
+	}
+
+	if err := n.Close(); err != nil {
+		t.Fatalf("Failed to close network: %s", err)
+	}
+
+	if err := syscall.Kill(pid, 0); !errors.Is(err, syscall.ESRCH) {
+		t.Errorf("Init process is still running after teardown: %v", err)
+	}
+}
+
+func TestDebugCommand(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	path := filepath.Join(n.BasePath, "nodes", "h1", "ns", "net")
+	if h1.NetNSPath() != path {
+		t.Errorf("Unexpected namespace path: %s != %s", h1.NetNSPath(), path)
+	}
+
+	if cmd := h1.DebugCommand(); !strings.Contains(cmd, "--net="+path) {
+		t.Errorf("Debug command does not reference the namespace: %s", cmd)
+	}
+
+	for _, node := range []g.Node{h1, n.HostNode} {
+		ns, err := netns.GetFromPath(node.NetNSPath())
+		if err != nil {
+			t.Fatalf("Failed to open namespace: %s", err)
+		}
+
+		if !ns.Equal(node.NetNSHandle()) {
+			t.Errorf("Path %s does not refer to namespace of node %s", node.NetNSPath(), node)
+		}
+
+		ns.Close()
+	}
+}
+
+func TestNetworkKeepOnFailure(t *testing.T) {
+	// Successful tests still clean up
+	m, err := g.NewNetwork(*nname, append(opts, o.WithKeepOnFailure())...)
+	if err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Failed to close network: %s", err)
+	}
+
+	if _, err := os.Stat(m.BasePath); !os.IsNotExist(err) {
+		t.Errorf("Network has not been removed: %v", err)
+	}
+
+	core, logs := observer.New(zap.DebugLevel)
+
+	var n *g.Network
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Panic has not been re-raised")
+			}
+		}()
+
+		var err error
+		if n, err = g.NewNetwork(*nname, append(opts,
+			o.WithKeepOnFailure(),
+			o.WithLogger(zap.New(core)))...); err != nil {
+			t.Fatalf("Failed to create network: %s", err)
+		}
+		defer n.Close()
+
+		if _, err := n.AddHost("h1"); err != nil {
+			t.Fatalf("Failed to create host: %s", err)
+		}
+
+		panic("simulated failure")
+	}()
+
+	// Cleanup kept network
+	defer n.Teardown()
+
+	h1 := n.Nodes["h1"]
+	if _, err := os.Stat(h1.NetNSPath()); err != nil {
+		t.Errorf("Namespace of node has not been kept: %s", err)
+	}
+
+	if logs.FilterField(zap.String("netns", h1.NetNSPath())).Len() != 1 {
+		t.Errorf("Path of kept namespace has not been logged")
+	}
+}
+
+func TestNetworkAutoHosts(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		sw  *g.Switch
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if sw, err = n.AddSwitch("sw"); err != nil {
+		t.Fatalf("Failed to add switch: %s", err)
+	}
+
+	fn := filepath.Join(n.BasePath, "files", "etc", "hosts")
+
+	// Count how often the hosts file gets opened for writing
+	fd, err := unix.InotifyInit1(unix.IN_NONBLOCK)
+	if err != nil {
+		t.Fatalf("Failed to initialize inotify: %s", err)
+	}
+	defer unix.Close(fd)
+
+	if _, err := unix.InotifyAddWatch(fd, fn, unix.IN_OPEN); err != nil {
+		t.Fatalf("Failed to watch hosts file: %s", err)
+	}
+
+	writes := func() int {
+		cnt := 0
+		buf := make([]byte, 4096)
+
+		for {
+			l, err := unix.Read(fd, buf)
+			if err != nil || l <= 0 {
+				return cnt
+			}
+
+			cnt += l / unix.SizeofInotifyEvent
+		}
+	}
+
+	if err := n.SetAutoHosts(false); err != nil {
+		t.Fatalf("Failed to disable auto hosts: %s", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if _, err := n.AddHost(fmt.Sprintf("h%d", i),
+			o.Interface("veth0", sw,
+				o.AddressIPv4(10, 0, 0, byte(i), 24)),
+		); err != nil {
+			t.Fatalf("Failed to add host: %s", err)
+		}
+	}
+
+	if cnt := writes(); cnt != 0 {
+		t.Errorf("Hosts file has been written %d times while disabled", cnt)
+	}
+
+	if err := n.SetAutoHosts(true); err != nil {
+		t.Fatalf("Failed to enable auto hosts: %s", err)
+	}
+
+	if cnt := writes(); cnt != 1 {
+		t.Errorf("Hosts file has been written %d times", cnt)
+	}
+
+	hosts, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatalf("Failed to read hosts file: %s", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if entry := fmt.Sprintf("10.0.0.%d h%d", i, i); !strings.Contains(string(hosts), entry) {
+			t.Errorf("Missing hosts entry: %s", entry)
+		}
+	}
+
+	// Discard the event caused by reading the file
+	writes()
+
+	// The file is only regenerated after changes
+	if err := n.SetAutoHosts(true); err != nil {
+		t.Fatalf("Failed to enable auto hosts: %s", err)
+	}
+
+	if cnt := writes(); cnt != 0 {
+		t.Errorf("Hosts file has been written %d times without changes", cnt)
+	}
+
+	if err := n.RegenerateHosts(); err != nil {
+		t.Fatalf("Failed to regenerate hosts file: %s", err)
+	}
+
+	if cnt := writes(); cnt != 1 {
+		t.Errorf("Hosts file has been written %d times", cnt)
+	}
+}
+
+func hasLiveNetwork(n *g.Network) bool {
+	for _, m := range g.Networks() {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCleanupAll(t *testing.T) {
+	nets := []*g.Network{}
+
+	for i := 0; i < 2; i++ {
+		n, err := g.NewNetwork("", opts...)
+		if err != nil {
+			t.Fatalf("Failed to create network: %s", err)
+		}
+		defer n.Close()
+
+		if _, err := n.AddHost("h1"); err != nil {
+			t.Fatalf("Failed to create host: %s", err)
+		}
+
+		nets = append(nets, n)
+	}
+
+	for _, n := range nets {
+		if !hasLiveNetwork(n) {
+			t.Errorf("Network %s is missing", n)
+		}
+	}
+
+	if err := g.CleanupAll(); err != nil {
+		t.Fatalf("Failed to cleanup networks: %s", err)
+	}
+
+	if live := g.Networks(); len(live) != 0 {
+		t.Errorf("Networks remain after cleanup: %v", live)
+	}
+
+	for _, n := range nets {
+		if hasNetwork(n.Name) {
+			t.Errorf("Network %s has not been removed", n)
+		}
+
+		if _, err := netns.GetFromName(fmt.Sprintf("gont-%s-h1", n.Name)); err == nil {
+			t.Errorf("Namespace of network %s has not been removed", n)
+		}
+	}
+}
+
+// Namespaces of nodes are bind-mounted to /run/netns which makes them
+// visible to iproute2's "ip netns" commands during the lifetime of the network.
+func TestNetworkIPNetns(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if _, err := n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	ns := fmt.Sprintf("%s%s-h1", n.NSPrefix, n.Name)
+
+	list := func() []string {
+		out, err := exec.Command("ip", "netns", "list").Output()
+		if err != nil {
+			t.Fatalf("Failed to list namespaces: %s", err)
+		}
+
+		names := []string{}
+		for _, line := range strings.Split(string(out), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				names = append(names, fields[0])
+			}
+		}
+
+		return names
+	}
+
+	contains := func(names []string) bool {
+		for _, name := range names {
+			if name == ns {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !contains(list()) {
+		t.Errorf("Namespace %s is not listed by iproute2", ns)
+	}
+
+	if err := n.Close(); err != nil {
+		t.Fatalf("Failed to close network: %s", err)
+	}
+
+	if contains(list()) {
+		t.Errorf("Namespace %s is still listed by iproute2", ns)
+	}
+
+	if _, err := os.Stat(filepath.Join("/run/netns", ns)); !os.IsNotExist(err) {
+		t.Errorf("Namespace %s has not been removed from /run/netns: %v", ns, err)
+	}
+}
+
+// netnsFDCount returns the number of open network namespace file descriptors
+func netnsFDCount(t *testing.T) int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatalf("Failed to list file descriptors: %s", err)
+	}
+
+	cnt := 0
+	for _, e := range entries {
+		if tgt, err := os.Readlink(filepath.Join("/proc/self/fd", e.Name())); err == nil && strings.HasPrefix(tgt, "net:") {
+			cnt++
+		}
+	}
+
+	return cnt
+}
+
+func TestCheckLeaks(t *testing.T) {
+	before := netnsFDCount(t)
+
+	for i := 0; i < 5; i++ {
+		n, err := g.NewNetwork("", opts...)
+		if err != nil {
+			t.Fatalf("Failed to create network: %s", err)
+		}
+
+		sw, err := n.AddSwitch("sw")
+		if err != nil {
+			t.Fatalf("Failed to create switch: %s", err)
+		}
+
+		for _, name := range []string{"h1", "h2"} {
+			if _, err := n.AddHost(name,
+				o.Interface("veth0", sw)); err != nil {
+				t.Fatalf("Failed to create host: %s", err)
+			}
+		}
+
+		r, err := n.CheckLeaks()
+		if err != nil {
+			t.Fatalf("Failed to check leaks: %s", err)
+		}
+
+		if r.Leaked() {
+			t.Errorf("Found leaks: %v", r.Discrepancies)
+		}
+
+		if r.Nodes != 3 || r.NetNSFDs != 3 || r.Mounts != 6 {
+			t.Errorf("Invalid report: %+v", r)
+		}
+
+		if err := n.Close(); err != nil {
+			t.Fatalf("Failed to close network: %s", err)
+		}
+	}
+
+	if after := netnsFDCount(t); after != before {
+		t.Errorf("Namespace file descriptors leaked: %d != %d", after, before)
+	}
+
+	n, err := g.NewNetwork("", opts...)
+	if err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	h1, err := n.AddHost("h1")
+	if err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	// Simulate a leaked file descriptor
+	fd, err := syscall.Dup(int(h1.NetNSHandle()))
+	if err != nil {
+		t.Fatalf("Failed to duplicate namespace handle: %s", err)
+	}
+	defer syscall.Close(fd)
+
+	r, err := n.CheckLeaks()
+	if err != nil {
+		t.Fatalf("Failed to check leaks: %s", err)
+	}
+
+	if !r.Leaked() || r.NetNSFDs != 2 {
+		t.Errorf("Leaked file descriptor has not been detected: %+v", r)
+	}
+}
+
+func TestForEachNode(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	for i := 3; i >= 1; i-- {
+		if _, err := n.AddHost(fmt.Sprintf("h%d", i)); err != nil {
+			t.Fatalf("Failed to create host: %s", err)
+		}
+	}
+
+	if _, err := n.AddSwitch("sw1"); err != nil {
+		t.Fatalf("Failed to create switch: %s", err)
+	}
+
+	if err := n.ForEachNode(func(node g.Node) error {
+		if h, ok := node.(*g.Host); ok {
+			return h.EnableForwarding()
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to enable forwarding: %s", err)
+	}
+
+	names := []string{}
+	if err := n.ForEachNodeSerial(g.InNamespace(func(node g.Node) error {
+		names = append(names, node.Name())
+
+		if _, ok := node.(*g.Host); !ok {
+			return nil
+		}
+
+		// procfs refers to the namespace of the calling thread
+		fwd, err := os.ReadFile("/proc/sys/net/ipv4/ip_forward")
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(string(fwd)) != "1" {
+			return errors.New("forwarding is disabled")
+		}
+
+		return nil
+	})); err != nil {
+		t.Fatalf("Failed to verify forwarding: %s", err)
+	}
+
+	if strings.Join(names, ",") != "h1,h2,h3,sw1" {
+		t.Errorf("Invalid order of nodes: %v", names)
+	}
+
+	err = n.ForEachNode(func(node g.Node) error {
+		if node.Name() == "h1" {
+			return nil
+		}
+
+		return errors.New("failed")
+	})
+
+	if errs := multierr.Errors(err); len(errs) != 3 {
+		t.Errorf("Expected 3 errors, got: %v", err)
+	}
+}
+
+// TestForEachNodeHostNAT checks that the host node
+// registered by AddHostNAT() is skipped
+func TestForEachNodeHostNAT(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if _, err := n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if _, err := n.AddHostNAT("n1"); err != nil {
+		t.Fatalf("Failed to create NAT: %s", err)
+	}
+
+	for _, each := range []func(func(g.Node) error) error{n.ForEachNode, n.ForEachNodeSerial} {
+		names := []string{}
+		mu := sync.Mutex{}
+
+		if err := each(g.InNamespace(func(node g.Node) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			names = append(names, node.Name())
+
+			return nil
+		})); err != nil {
+			t.Fatalf("Failed to iterate nodes: %s", err)
+		}
+
+		if strings.Join(names, ",") != "h1" {
+			t.Errorf("Invalid nodes: %v", names)
+		}
+	}
+}
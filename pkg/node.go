@@ -14,7 +14,16 @@ type Node interface {
 	Network() *Network
 	Interface(name string) *Interface
 	NetNSHandle() netns.NsHandle
+	NetNSPath() string
+	DebugCommand() string
 	NetlinkHandle() *nl.Handle
+	Meta(key string) string
+
+	// Setters
+	SetMeta(key, value string)
 
 	ConfigureInterface(i *Interface) error
+
+	// base returns the base node which is embedded by all nodes
+	base() *BaseNode
 }
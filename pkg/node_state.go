@@ -0,0 +1,173 @@
+package gont
+
+import (
+	"fmt"
+
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// NodeState is a snapshot of the kernel state of a node
+// as observed in its network namespace.
+type NodeState struct {
+	Name       string           `json:"name"`
+	Interfaces []InterfaceState `json:"interfaces"`
+	Routes     []RouteState     `json:"routes"`
+	Neighbors  []NeighborState  `json:"neighbors"`
+}
+
+// InterfaceState is a snapshot of the kernel state of an interface
+type InterfaceState struct {
+	Name         string       `json:"name"`
+	Index        int          `json:"index"`
+	Type         string       `json:"type"`
+	MTU          int          `json:"mtu"`
+	HardwareAddr string       `json:"hardware_addr,omitempty"`
+	Master       string       `json:"master,omitempty"`
+	Up           bool         `json:"up"`
+	Carrier      bool         `json:"carrier"`
+	Addresses    []string     `json:"addresses"`
+	Qdiscs       []QdiscStats `json:"qdiscs"`
+}
+
+// RouteState is a snapshot of a route in the kernel
+type RouteState struct {
+	Dst      string `json:"dst"`
+	Gw       string `json:"gw,omitempty"`
+	Src      string `json:"src,omitempty"`
+	Device   string `json:"dev,omitempty"`
+	Table    int    `json:"table"`
+	Type     int    `json:"type"`
+	Priority int    `json:"metric,omitempty"`
+	Protocol string `json:"proto"`
+	Scope    string `json:"scope"`
+}
+
+// NeighborState is a snapshot of an entry in the neighbor table of the kernel
+type NeighborState struct {
+	IP           string `json:"ip"`
+	HardwareAddr string `json:"hardware_addr,omitempty"`
+	Device       string `json:"dev"`
+	State        string `json:"state"`
+}
+
+var neighborStates = map[int]string{
+	nl.NUD_INCOMPLETE: "incomplete",
+	nl.NUD_REACHABLE:  "reachable",
+	nl.NUD_STALE:      "stale",
+	nl.NUD_DELAY:      "delay",
+	nl.NUD_PROBE:      "probe",
+	nl.NUD_FAILED:     "failed",
+	nl.NUD_NOARP:      "noarp",
+	nl.NUD_PERMANENT:  "permanent",
+	nl.NUD_NONE:       "none",
+}
+
+// Dump returns a snapshot of the interfaces, addresses, routes,
+// neighbors and qdiscs in the network namespace of the node.
+func (n *BaseNode) Dump() (NodeState, error) {
+	s := NodeState{
+		Name:       n.name,
+		Interfaces: []InterfaceState{},
+		Routes:     []RouteState{},
+		Neighbors:  []NeighborState{},
+	}
+
+	links, err := n.nlHandle.LinkList()
+	if err != nil {
+		return s, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+
+	names := map[int]string{}
+	for _, link := range links {
+		names[link.Attrs().Index] = link.Attrs().Name
+	}
+
+	for _, link := range links {
+		attrs := link.Attrs()
+
+		is := InterfaceState{
+			Name:      attrs.Name,
+			Index:     attrs.Index,
+			Type:      link.Type(),
+			MTU:       attrs.MTU,
+			Master:    names[attrs.MasterIndex],
+			Up:        attrs.RawFlags&unix.IFF_UP != 0,
+			Carrier:   attrs.RawFlags&unix.IFF_LOWER_UP != 0,
+			Addresses: []string{},
+		}
+
+		if len(attrs.HardwareAddr) > 0 {
+			is.HardwareAddr = attrs.HardwareAddr.String()
+		}
+
+		addrs, err := n.nlHandle.AddrList(link, nl.FAMILY_ALL)
+		if err != nil {
+			return s, fmt.Errorf("failed to list addresses: %w", err)
+		}
+
+		for _, addr := range addrs {
+			is.Addresses = append(is.Addresses, addr.IPNet.String())
+		}
+
+		if is.Qdiscs, err = n.QdiscStats(attrs.Name); err != nil {
+			return s, err
+		}
+
+		s.Interfaces = append(s.Interfaces, is)
+	}
+
+	routes, err := n.nlHandle.RouteListFiltered(nl.FAMILY_ALL, &nl.Route{
+		Table: unix.RT_TABLE_UNSPEC,
+	}, nl.RT_FILTER_TABLE)
+	if err != nil {
+		return s, fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	for _, r := range routes {
+		rs := RouteState{
+			Dst:      "default",
+			Device:   names[r.LinkIndex],
+			Table:    r.Table,
+			Type:     r.Type,
+			Priority: r.Priority,
+			Protocol: r.Protocol.String(),
+			Scope:    r.Scope.String(),
+		}
+
+		if r.Dst != nil {
+			rs.Dst = r.Dst.String()
+		}
+
+		if r.Gw != nil {
+			rs.Gw = r.Gw.String()
+		}
+
+		if r.Src != nil {
+			rs.Src = r.Src.String()
+		}
+
+		s.Routes = append(s.Routes, rs)
+	}
+
+	neighs, err := n.nlHandle.NeighList(0, nl.FAMILY_ALL)
+	if err != nil {
+		return s, fmt.Errorf("failed to list neighbors: %w", err)
+	}
+
+	for _, ne := range neighs {
+		ns := NeighborState{
+			IP:     ne.IP.String(),
+			Device: names[ne.LinkIndex],
+			State:  neighborStates[ne.State],
+		}
+
+		if len(ne.HardwareAddr) > 0 {
+			ns.HardwareAddr = ne.HardwareAddr.String()
+		}
+
+		s.Neighbors = append(s.Neighbors, ns)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,123 @@
+package gont
+
+import "sort"
+
+// AddressState is an address assigned to an interface
+type AddressState struct {
+	Interface string `json:"interface"`
+	Address   string `json:"address"`
+}
+
+// NodeStateDiff contains the differences between two node states
+type NodeStateDiff struct {
+	AddedInterfaces   []InterfaceState `json:"added_interfaces"`
+	RemovedInterfaces []InterfaceState `json:"removed_interfaces"`
+	AddedAddresses    []AddressState   `json:"added_addresses"`
+	RemovedAddresses  []AddressState   `json:"removed_addresses"`
+	AddedRoutes       []RouteState     `json:"added_routes"`
+	RemovedRoutes     []RouteState     `json:"removed_routes"`
+}
+
+// Empty returns true if there are no differences
+func (d NodeStateDiff) Empty() bool {
+	return len(d.AddedInterfaces) == 0 && len(d.RemovedInterfaces) == 0 &&
+		len(d.AddedAddresses) == 0 && len(d.RemovedAddresses) == 0 &&
+		len(d.AddedRoutes) == 0 && len(d.RemovedRoutes) == 0
+}
+
+// Diff compares two node states returned by BaseNode.Dump() and reports
+// the interfaces, addresses and routes which have been added or removed.
+//
+// The comparison does not depend on the order of the entries.
+// Interfaces are identified by their name.
+func Diff(before, after NodeState) NodeStateDiff {
+	d := NodeStateDiff{}
+
+	// Interfaces
+	beforeIntfs := map[string]InterfaceState{}
+	afterIntfs := map[string]InterfaceState{}
+
+	for _, i := range before.Interfaces {
+		beforeIntfs[i.Name] = i
+	}
+
+	for _, i := range after.Interfaces {
+		afterIntfs[i.Name] = i
+
+		if _, ok := beforeIntfs[i.Name]; !ok {
+			d.AddedInterfaces = append(d.AddedInterfaces, i)
+		}
+	}
+
+	for _, i := range before.Interfaces {
+		if _, ok := afterIntfs[i.Name]; !ok {
+			d.RemovedInterfaces = append(d.RemovedInterfaces, i)
+		}
+	}
+
+	// Addresses
+	beforeAddrs := addressSet(before)
+	afterAddrs := addressSet(after)
+
+	for a := range afterAddrs {
+		if !beforeAddrs[a] {
+			d.AddedAddresses = append(d.AddedAddresses, a)
+		}
+	}
+
+	for a := range beforeAddrs {
+		if !afterAddrs[a] {
+			d.RemovedAddresses = append(d.RemovedAddresses, a)
+		}
+	}
+
+	// Routes
+	beforeRoutes := map[RouteState]bool{}
+	afterRoutes := map[RouteState]bool{}
+
+	for _, r := range before.Routes {
+		beforeRoutes[r] = true
+	}
+
+	for _, r := range after.Routes {
+		afterRoutes[r] = true
+
+		if !beforeRoutes[r] {
+			d.AddedRoutes = append(d.AddedRoutes, r)
+		}
+	}
+
+	for _, r := range before.Routes {
+		if !afterRoutes[r] {
+			d.RemovedRoutes = append(d.RemovedRoutes, r)
+		}
+	}
+
+	// Sort address lists as they originate from map iteration
+	for _, as := range [][]AddressState{d.AddedAddresses, d.RemovedAddresses} {
+		sort.Slice(as, func(i, j int) bool {
+			if as[i].Interface != as[j].Interface {
+				return as[i].Interface < as[j].Interface
+			}
+
+			return as[i].Address < as[j].Address
+		})
+	}
+
+	return d
+}
+
+func addressSet(s NodeState) map[AddressState]bool {
+	addrs := map[AddressState]bool{}
+
+	for _, i := range s.Interfaces {
+		for _, a := range i.Addresses {
+			addrs[AddressState{
+				Interface: i.Name,
+				Address:   a,
+			}] = true
+		}
+	}
+
+	return addrs
+}
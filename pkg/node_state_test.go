@@ -0,0 +1,302 @@
+package gont_test
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+)
+
+// TestDump checks that the observed state of a node
+// reflects the configured address and route
+//
+//  h1 <-> h2
+func TestDump(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if err := h1.AddRoute(&nl.Route{
+		Dst: &net.IPNet{
+			IP:   net.IPv4(10, 1, 0, 0),
+			Mask: net.CIDRMask(16, 32),
+		},
+		Gw: net.IPv4(10, 0, 0, 2),
+	}); err != nil {
+		t.Fatalf("Failed to add route: %s", err)
+	}
+
+	state, err := h1.Dump()
+	if err != nil {
+		t.Fatalf("Failed to dump node state: %s", err)
+	}
+
+	// The state must survive a round-trip through JSON
+	buf, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("Failed to marshal node state: %s", err)
+	}
+
+	state = g.NodeState{}
+	if err := json.Unmarshal(buf, &state); err != nil {
+		t.Fatalf("Failed to unmarshal node state: %s", err)
+	}
+
+	var intf *g.InterfaceState
+	for i := range state.Interfaces {
+		if state.Interfaces[i].Name == "veth0" {
+			intf = &state.Interfaces[i]
+		}
+	}
+
+	if intf == nil {
+		t.Fatal("Interface is missing from dump")
+	}
+
+	if !intf.Up {
+		t.Error("Interface is not up")
+	}
+
+	found := false
+	for _, addr := range intf.Addresses {
+		if addr == "10.0.0.1/24" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Address is missing from dump: %v", intf.Addresses)
+	}
+
+	found = false
+	for _, r := range state.Routes {
+		if r.Dst == "10.1.0.0/16" && r.Gw == "10.0.0.2" && r.Device == "veth0" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Route is missing from dump: %v", state.Routes)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	before, err := h1.Dump()
+	if err != nil {
+		t.Fatalf("Failed to dump node state: %s", err)
+	}
+
+	if d := g.Diff(before, before); !d.Empty() {
+		t.Fatalf("Identical states differ: %+v", d)
+	}
+
+	// Reversing the order of entries must not change the result
+	reversed := before
+	reversed.Routes = make([]g.RouteState, len(before.Routes))
+	for i, r := range before.Routes {
+		reversed.Routes[len(before.Routes)-1-i] = r
+	}
+
+	if d := g.Diff(before, reversed); !d.Empty() {
+		t.Fatalf("Reordered states differ: %+v", d)
+	}
+
+	if err := h1.AddRoute(&nl.Route{
+		Dst: &net.IPNet{
+			IP:   net.IPv4(10, 1, 0, 0),
+			Mask: net.CIDRMask(16, 32),
+		},
+		LinkIndex: h1.Interface("lo").Link.Attrs().Index,
+	}); err != nil {
+		t.Fatalf("Failed to add route: %s", err)
+	}
+
+	after, err := h1.Dump()
+	if err != nil {
+		t.Fatalf("Failed to dump node state: %s", err)
+	}
+
+	d := g.Diff(before, after)
+	if len(d.AddedRoutes) != 1 || d.AddedRoutes[0].Dst != "10.1.0.0/16" {
+		t.Fatalf("Expected exactly one added route: %+v", d.AddedRoutes)
+	}
+
+	d.AddedRoutes = nil
+	if !d.Empty() {
+		t.Errorf("Unexpected differences: %+v", d)
+	}
+
+	if d := g.Diff(after, before); len(d.RemovedRoutes) != 1 {
+		t.Errorf("Expected exactly one removed route: %+v", d.RemovedRoutes)
+	}
+}
+
+//  h1 <-> h2
+func TestSnapshot(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	before, err := n.Snapshot()
+	if err != nil {
+		t.Fatalf("Failed to take snapshot: %s", err)
+	}
+
+	if len(before.Nodes) != 2 || before.Nodes[0].Name != "h1" || before.Nodes[1].Name != "h2" {
+		t.Fatalf("Invalid nodes in snapshot: %+v", before.Nodes)
+	}
+
+	// Snapshots must survive a round trip for golden comparisons
+	buf, err := json.Marshal(before)
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %s", err)
+	}
+
+	var decoded g.NetworkState
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal snapshot: %s", err)
+	}
+
+	if d := g.DiffNetwork(before, decoded); !d.Empty() {
+		t.Errorf("Decoded snapshot differs: %+v", d)
+	}
+
+	if err := h1.AddRoute(&nl.Route{
+		Dst: &net.IPNet{
+			IP:   net.IPv4(10, 1, 0, 0),
+			Mask: net.CIDRMask(16, 32),
+		},
+		Gw: net.IPv4(10, 0, 0, 2),
+	}); err != nil {
+		t.Fatalf("Failed to add route: %s", err)
+	}
+
+	if _, err := n.AddHost("h3"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	after, err := n.Snapshot()
+	if err != nil {
+		t.Fatalf("Failed to take snapshot: %s", err)
+	}
+
+	d := g.DiffNetwork(before, after)
+	if len(d.AddedNodes) != 1 || d.AddedNodes[0] != "h3" || len(d.RemovedNodes) != 0 {
+		t.Errorf("Unexpected added or removed nodes: %+v", d)
+	}
+
+	nd, ok := d.Nodes["h1"]
+	if len(d.Nodes) != 1 || !ok {
+		t.Fatalf("Expected only differences of h1: %+v", d.Nodes)
+	}
+
+	if len(nd.AddedRoutes) != 1 || nd.AddedRoutes[0].Dst != "10.1.0.0/16" || nd.AddedRoutes[0].Gw != "10.0.0.2" {
+		t.Fatalf("Expected exactly one added route: %+v", nd.AddedRoutes)
+	}
+
+	nd.AddedRoutes = nil
+	if !nd.Empty() {
+		t.Errorf("Unexpected differences: %+v", nd)
+	}
+
+	if d := g.DiffNetwork(after, before); len(d.RemovedNodes) != 1 || len(d.Nodes["h1"].RemovedRoutes) != 1 {
+		t.Errorf("Unexpected reverse differences: %+v", d)
+	}
+}
+
+// TestSnapshotHostNAT checks that the host node
+// registered by AddHostNAT() is not included
+func TestSnapshotHostNAT(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if _, err := n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if _, err := n.AddHostNAT("n1"); err != nil {
+		t.Fatalf("Failed to create NAT: %s", err)
+	}
+
+	s, err := n.Snapshot()
+	if err != nil {
+		t.Fatalf("Failed to take snapshot: %s", err)
+	}
+
+	if len(s.Nodes) != 1 || s.Nodes[0].Name != "h1" {
+		t.Errorf("Invalid nodes in snapshot: %+v", s.Nodes)
+	}
+}
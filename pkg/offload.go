@@ -0,0 +1,149 @@
+package gont
+
+import (
+	"fmt"
+	"unsafe"
+
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// Offloads describes the segmentation offloads of an interface
+type Offloads struct {
+	TSO bool // TCP segmentation offload
+	GSO bool // Generic segmentation offload
+	GRO bool // Generic receive offload
+}
+
+// ethtoolValue corresponds to struct ethtool_value
+type ethtoolValue struct {
+	cmd  uint32
+	data uint32
+}
+
+// ifreqData corresponds to struct ifreq with a pointer in its data union
+type ifreqData struct {
+	name [unix.IFNAMSIZ]byte
+	data unsafe.Pointer
+	_    [16]byte
+}
+
+// Offloads returns the current segmentation offloads of the interface iface.
+func (n *BaseNode) Offloads(iface string) (Offloads, error) {
+	o := Offloads{}
+
+	err := n.ethtool(iface, func(get func(cmd uint32) (bool, error), _ func(cmd uint32, v bool) error) (err error) {
+		if o.TSO, err = get(unix.ETHTOOL_GTSO); err != nil {
+			return err
+		}
+
+		if o.GSO, err = get(unix.ETHTOOL_GGSO); err != nil {
+			return err
+		}
+
+		o.GRO, err = get(unix.ETHTOOL_GGRO)
+		return err
+	})
+
+	return o, err
+}
+
+// SetOffloads changes the segmentation offloads of the interface iface.
+func (n *BaseNode) SetOffloads(iface string, o Offloads) error {
+	n.logger.Info("Setting interface offloads",
+		zap.String("intf", iface),
+		zap.Bool("tso", o.TSO),
+		zap.Bool("gso", o.GSO),
+		zap.Bool("gro", o.GRO))
+
+	return n.ethtool(iface, func(_ func(cmd uint32) (bool, error), set func(cmd uint32, v bool) error) error {
+		if err := set(unix.ETHTOOL_STSO, o.TSO); err != nil {
+			return err
+		}
+
+		if err := set(unix.ETHTOOL_SGSO, o.GSO); err != nil {
+			return err
+		}
+
+		return set(unix.ETHTOOL_SGRO, o.GRO)
+	})
+}
+
+// DisableOffloads disables all segmentation offloads of the interface iface
+// so that captured frames do not exceed the MTU of the link.
+//
+// The returned function restores the previous offloads.
+func (n *BaseNode) DisableOffloads(iface string) (func() error, error) {
+	prev, err := n.Offloads(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := n.SetOffloads(iface, Offloads{}); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return n.SetOffloads(iface, prev)
+	}, nil
+}
+
+func (n *BaseNode) ethtool(iface string, cb func(get func(cmd uint32) (bool, error), set func(cmd uint32, v bool) error) error) error {
+	ioctl := func(cmd, data uint32) (uint32, error) {
+		ev := ethtoolValue{
+			cmd:  cmd,
+			data: data,
+		}
+
+		if err := n.ethtoolIoctl(iface, unsafe.Pointer(&ev)); err != nil {
+			return 0, fmt.Errorf("ethtool command 0x%x failed: %w", cmd, err)
+		}
+
+		return ev.data, nil
+	}
+
+	get := func(cmd uint32) (bool, error) {
+		v, err := ioctl(cmd, 0)
+		return v != 0, err
+	}
+
+	set := func(cmd uint32, v bool) error {
+		var data uint32
+		if v {
+			data = 1
+		}
+
+		_, err := ioctl(cmd, data)
+		return err
+	}
+
+	return cb(get, set)
+}
+
+// ethtoolIoctl issues an ethtool command for the interface iface within the
+// namespace of the node. The structure pointed to by data must start with
+// the command number.
+func (n *BaseNode) ethtoolIoctl(iface string, data unsafe.Pointer) error {
+	if len(iface) >= unix.IFNAMSIZ {
+		return fmt.Errorf("interface name too long: %s", iface)
+	}
+
+	return n.RunFunc(func() error {
+		fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open socket: %w", err)
+		}
+		defer unix.Close(fd)
+
+		ifr := ifreqData{
+			data: data,
+		}
+		copy(ifr.name[:], iface)
+
+		if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCETHTOOL, uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+			return errno
+		}
+
+		return nil
+	})
+}
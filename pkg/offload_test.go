@@ -0,0 +1,129 @@
+package gont_test
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+// maxFrameSize sends a bulk TCP transfer from h1 to h2 and returns
+// the size of the largest TCP frame observed on the interface of h1
+func maxFrameSize(t *testing.T, h1, h2 *g.Host) int {
+	var lst net.Listener
+	if err := h2.RunFunc(func() (err error) {
+		lst, err = net.Listen("tcp", "10.0.0.2:8080")
+		return
+	}); err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer lst.Close()
+
+	go func() {
+		c, err := lst.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		_, _ = io.Copy(io.Discard, c)
+	}()
+
+	fd := listenPacket(t, h1.BaseNode, "veth0")
+	defer unix.Close(fd)
+
+	c, err := h1.Dial("tcp", "10.0.0.2:8080")
+	if err != nil {
+		t.Fatalf("Failed to connect: %s", err)
+	}
+
+	buf := make([]byte, 1<<20)
+	if _, err := c.Write(buf); err != nil {
+		t.Fatalf("Failed to send: %s", err)
+	}
+	c.Close()
+
+	max := 0
+	receivePacket(fd, time.Second, func(p gopacket.Packet) bool {
+		if p.Layer(layers.LayerTypeTCP) != nil && len(p.Data()) > max {
+			max = len(p.Data())
+		}
+		return false
+	})
+
+	return max
+}
+
+// TestDisableOffloads checks that frames captured on an interface
+// exceed the MTU only if segmentation offloads are enabled
+//
+//  h1 <-> h2
+func TestDisableOffloads(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	const maxWireSize = 1500 + 14
+
+	prev, err := h1.Offloads("veth0")
+	if err != nil {
+		t.Fatalf("Failed to get offloads: %s", err)
+	}
+
+	if !prev.TSO && !prev.GSO {
+		t.Skip("Segmentation offloads are not enabled")
+	}
+
+	if size := maxFrameSize(t, h1, h2); size <= maxWireSize {
+		t.Errorf("Expected oversized frames with offloads enabled: %d", size)
+	}
+
+	restore, err := h1.DisableOffloads("veth0")
+	if err != nil {
+		t.Fatalf("Failed to disable offloads: %s", err)
+	}
+
+	if size := maxFrameSize(t, h1, h2); size > maxWireSize {
+		t.Errorf("Captured oversized frame with disabled offloads: %d", size)
+	}
+
+	if err := restore(); err != nil {
+		t.Fatalf("Failed to restore offloads: %s", err)
+	}
+
+	if cur, err := h1.Offloads("veth0"); err != nil {
+		t.Fatalf("Failed to get offloads: %s", err)
+	} else if cur != prev {
+		t.Errorf("Offloads have not been restored: %+v != %+v", cur, prev)
+	}
+}
@@ -1,6 +1,13 @@
 package gont
 
-import nl "github.com/vishvananda/netlink"
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	nl "github.com/vishvananda/netlink"
+)
 
 type Option any
 type Options []Option
@@ -45,6 +52,118 @@ type LinkOption interface {
 	Apply(la *nl.LinkAttrs)
 }
 
+type DialOption interface {
+	Option
+	Apply(d *net.Dialer)
+}
+
+type ReplayOption interface {
+	Option
+	Apply(r *Replayer)
+}
+
+type FileServerOption interface {
+	Option
+	Apply(s *FileServer)
+}
+
+type CmdOption interface {
+	Option
+	Apply(c *exec.Cmd)
+}
+
+type AddressOption interface {
+	Option
+	Apply(a *nl.Addr)
+}
+
 type BridgeOption interface {
 	Apply(b *nl.Bridge)
 }
+
+const (
+	targetNetwork    = "network"
+	targetNode       = "node"
+	targetHost       = "host"
+	targetNAT        = "nat"
+	targetSwitch     = "switch"
+	targetInterface  = "interface"
+	targetVeth       = "veth"
+	targetLink       = "link"
+	targetBridge     = "bridge"
+	targetDial       = "dial"
+	targetReplay     = "replay"
+	targetFileServer = "fileserver"
+	targetCmd        = "cmd"
+	targetAddress    = "address"
+)
+
+// AppliesTo returns the names of the targets to which the option can be applied.
+func AppliesTo(opt Option) []string {
+	targets := []string{}
+
+	add := func(ok bool, target string) {
+		if ok {
+			targets = append(targets, target)
+		}
+	}
+
+	_, ok := opt.(NetworkOption)
+	add(ok, targetNetwork)
+	_, ok = opt.(NodeOption)
+	add(ok, targetNode)
+	_, ok = opt.(HostOption)
+	add(ok, targetHost)
+	_, ok = opt.(NATOption)
+	add(ok, targetNAT)
+	_, ok = opt.(SwitchOption)
+	add(ok, targetSwitch)
+	_, ok = opt.(InterfaceOption)
+	add(ok, targetInterface)
+	_, ok = opt.(VethOption)
+	add(ok, targetVeth)
+	_, ok = opt.(LinkOption)
+	add(ok, targetLink)
+	_, ok = opt.(BridgeOption)
+	add(ok, targetBridge)
+	_, ok = opt.(DialOption)
+	add(ok, targetDial)
+	_, ok = opt.(ReplayOption)
+	add(ok, targetReplay)
+	_, ok = opt.(FileServerOption)
+	add(ok, targetFileServer)
+	_, ok = opt.(CmdOption)
+	add(ok, targetCmd)
+	_, ok = opt.(AddressOption)
+	add(ok, targetAddress)
+
+	return targets
+}
+
+// checkOptions returns an error if any of the options
+// can not be applied to one of the targets.
+func checkOptions(opts []Option, targets ...string) error {
+	for _, opt := range opts {
+		applies := AppliesTo(opt)
+
+		found := false
+		for _, a := range applies {
+			for _, t := range targets {
+				if a == t {
+					found = true
+				}
+			}
+		}
+
+		if !found {
+			if len(applies) == 0 {
+				return fmt.Errorf("invalid option %T: not applicable to any target", opt)
+			}
+
+			return fmt.Errorf("invalid option %T: applies to %s but not %s",
+				opt, strings.Join(applies, ", "), strings.Join(targets, ", "))
+		}
+	}
+
+	return nil
+}
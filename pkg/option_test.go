@@ -0,0 +1,48 @@
+package gont_test
+
+import (
+	"strings"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+func TestAppliesTo(t *testing.T) {
+	for _, tc := range []struct {
+		opt     g.Option
+		targets []string
+	}{
+		{o.Persistent(true), []string{"network"}},
+		{o.MTU(1500), []string{"link"}},
+		{o.WithHostname("h1"), []string{"node"}},
+		{o.DefaultGatewayIPv4(10, 0, 0, 1), []string{"host"}},
+		{42, []string{}},
+	} {
+		if targets := g.AppliesTo(tc.opt); strings.Join(targets, ",") != strings.Join(tc.targets, ",") {
+			t.Errorf("Unexpected targets of %T: %v", tc.opt, targets)
+		}
+	}
+}
+
+func TestCheckOptions(t *testing.T) {
+	n, err := g.NewNetwork(*nname, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if _, err := n.AddNode("n1", o.Persistent(true)); err == nil {
+		t.Error("Network option has been accepted by AddNode")
+	} else if !strings.Contains(err.Error(), "network") {
+		t.Errorf("Error does not mention the target of the option: %s", err)
+	}
+
+	if _, err := n.AddNode("n1", o.DefaultGatewayIPv4(10, 0, 0, 1)); err == nil {
+		t.Error("Host option has been accepted by AddNode")
+	}
+
+	if _, err := n.AddHost("h1", o.WithHostname("h1"), o.Forwarding(true)); err != nil {
+		t.Errorf("Failed to add host with node and host options: %s", err)
+	}
+}
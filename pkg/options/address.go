@@ -0,0 +1,91 @@
+package options
+
+import (
+	"net"
+	"time"
+
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// LifetimeForever can be passed to WithLifetime() for an infinite lifetime.
+const LifetimeForever time.Duration = -1
+
+type AddressFlags int
+
+// WithAddressFlags sets the IFA_F_* flags of an address
+// added by BaseNode.LinkAddAddress().
+func WithAddressFlags(flags int) AddressFlags {
+	return AddressFlags(flags)
+}
+
+// WithNoDAD skips the duplicate address detection for an IPv6 address.
+func WithNoDAD() AddressFlags {
+	return unix.IFA_F_NODAD
+}
+
+// WithHomeAddress marks an IPv6 address as a Mobile IPv6 home address.
+func WithHomeAddress() AddressFlags {
+	return unix.IFA_F_HOMEADDRESS
+}
+
+func (f AddressFlags) Apply(a *nl.Addr) {
+	a.Flags |= int(f)
+}
+
+type Broadcast net.IP
+
+// WithBroadcast sets the broadcast address of an IPv4 address
+// added by BaseNode.LinkAddAddress().
+//
+// By default, the broadcast address is derived from the prefix
+// unless the prefix is longer than 30 bits.
+func WithBroadcast(ip net.IP) Broadcast {
+	return Broadcast(ip)
+}
+
+func (b Broadcast) Apply(a *nl.Addr) {
+	if ip := net.IP(b).To4(); ip != nil {
+		a.Broadcast = ip
+	} else {
+		a.Broadcast = net.IP(b)
+	}
+}
+
+type AddressLifetime struct {
+	Valid     time.Duration
+	Preferred time.Duration
+}
+
+// WithLifetime sets the valid and preferred lifetimes of an address
+// added by BaseNode.LinkAddAddress() which are rounded to seconds.
+//
+// The address is removed after the valid lifetime and becomes
+// deprecated after the preferred lifetime.
+func WithLifetime(valid, preferred time.Duration) AddressLifetime {
+	return AddressLifetime{
+		Valid:     valid,
+		Preferred: preferred,
+	}
+}
+
+// WithDeprecated adds an address which is valid forever but deprecated
+// immediately. Deprecated addresses are avoided as source addresses
+// for new connections.
+func WithDeprecated() AddressLifetime {
+	return WithLifetime(LifetimeForever, 0)
+}
+
+func (l AddressLifetime) Apply(a *nl.Addr) {
+	a.ValidLft = lifetimeSeconds(l.Valid)
+	a.PreferedLft = lifetimeSeconds(l.Preferred)
+}
+
+func lifetimeSeconds(d time.Duration) int {
+	if d < 0 {
+		infinity := uint32(0xffffffff) // INFINITY_LIFE_TIME
+		return int(infinity)
+	}
+
+	return int(d / time.Second)
+}
@@ -1,7 +1,10 @@
 package options
 
 import (
+	"time"
+
 	g "github.com/stv0g/gont/pkg"
+	"go.uber.org/zap/zapcore"
 )
 
 func Interface(name string, opts ...g.Option) *g.Interface {
@@ -35,4 +38,93 @@ func (d ExistingDockerContainer) Apply(n *g.BaseNode) {
 
 func (l LogToDebug) Apply(n *g.BaseNode) {
 	n.LogToDebug = bool(l)
+	n.LogToDebugSet = true
+}
+
+type LogLevel zapcore.Level
+
+// WithLogLevel restricts the verbosity of the node-scoped logs.
+// The level can only be raised above the level of the network logger.
+func WithLogLevel(l zapcore.Level) LogLevel {
+	return LogLevel(l)
+}
+
+func (l LogLevel) Apply(n *g.BaseNode) {
+	n.LogLevel = zapcore.Level(l)
+}
+
+type TimeOffset time.Duration
+
+// WithTimeOffset shifts the monotonic and boot-time clocks of processes
+// started in the node by d using a time namespace.
+//
+// Only processes started via Command(), Run() or Start() are affected.
+// Functions executed via RunFunc() use the clocks of the host.
+func WithTimeOffset(d time.Duration) TimeOffset {
+	return TimeOffset(d)
+}
+
+func (t TimeOffset) Apply(n *g.BaseNode) {
+	n.TimeOffset = time.Duration(t)
+}
+
+type Hostname string
+
+// WithHostname sets the hostname which is seen by processes started in the node.
+// By default, the hostname is composed of the node and network names.
+func WithHostname(name string) Hostname {
+	return Hostname(name)
+}
+
+func (h Hostname) Apply(n *g.BaseNode) {
+	n.Hostname = string(h)
+}
+
+type Cgroup string
+
+// WithCgroup places processes started in the node via Command(),
+// Run() or Start() into a new cgroup below the cgroup v2 directory parent.
+// The cgroup is required for suspending the processes via BaseNode.Freeze().
+//
+// Remaining processes are killed when the node is torn down.
+// Functions executed via RunFunc() are not affected.
+func WithCgroup(parent string) Cgroup {
+	return Cgroup(parent)
+}
+
+func (c Cgroup) Apply(n *g.BaseNode) {
+	n.CgroupParent = string(c)
+}
+
+type NSSwitch []string
+
+// WithNSSwitch sets the order of the sources which are consulted by
+// glibc for resolving hostnames of processes started in the node,
+// e.g. []string{"files"} to ignore DNS or []string{"dns", "files"}.
+//
+// Only processes started via Command(), Run() or Start() are affected.
+func WithNSSwitch(order []string) NSSwitch {
+	return NSSwitch(order)
+}
+
+func (s NSSwitch) Apply(n *g.BaseNode) {
+	n.NSSwitchHosts = []string(s)
+}
+
+type SeccompProfile g.SeccompProfile
+
+// WithSeccompProfile confines processes started in the node
+// via Command(), Run() or Start() by a seccomp filter.
+//
+// The filter is installed right before the program is executed
+// and hence must allow the execve() system call.
+// Functions executed via RunFunc() are not affected.
+// Seccomp is only available on Linux.
+func WithSeccompProfile(p *g.SeccompProfile) SeccompProfile {
+	return SeccompProfile(*p)
+}
+
+func (s SeccompProfile) Apply(n *g.BaseNode) {
+	p := g.SeccompProfile(s)
+	n.SeccompProfile = &p
 }
@@ -0,0 +1,58 @@
+package options
+
+import (
+	"os"
+	"os/exec"
+
+	g "github.com/stv0g/gont/pkg"
+)
+
+// Env sets environment variables of a process started via
+// BaseNode.Run() or BaseNode.Start() in addition to our own.
+type Env map[string]string
+
+// WithEnv sets the environment variables env for a process.
+func WithEnv(env map[string]string) Env {
+	return Env(env)
+}
+
+func (e Env) Apply(c *exec.Cmd) {
+	for k, v := range e {
+		setEnv(c, k, v)
+	}
+}
+
+// Path sets the search path in which the binary of a process
+// started via BaseNode.Run() or BaseNode.Start() is resolved.
+type Path string
+
+// WithPath resolves the binary of a process in the directories of
+// path rather than our own PATH. The process inherits the path.
+func WithPath(path string) Path {
+	return Path(path)
+}
+
+func (p Path) Apply(c *exec.Cmd) {
+	setEnv(c, "PATH", string(p))
+}
+
+// WithRlimit limits the consumption of the resource by a process
+// started via BaseNode.Run() or BaseNode.Start() to the soft and hard limits.
+// The limits are set right before the program is executed.
+//
+// Limits are not supported for processes of the host node.
+func WithRlimit(resource int, soft, hard uint64) g.Rlimit {
+	return g.Rlimit{
+		Resource: resource,
+		Soft:     soft,
+		Hard:     hard,
+	}
+}
+
+func setEnv(c *exec.Cmd, key, value string) {
+	if c.Env == nil {
+		c.Env = os.Environ()
+	}
+
+	c.Env = append(c.Env, key+"="+value)
+}
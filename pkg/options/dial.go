@@ -0,0 +1,33 @@
+package options
+
+import (
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// CongestionControl selects the TCP congestion control algorithm
+// of sockets created by BaseNode.Dial().
+type CongestionControl string
+
+func (cc CongestionControl) Apply(d *net.Dialer) {
+	ctrl := d.Control
+
+	d.Control = func(network, address string, c syscall.RawConn) error {
+		if ctrl != nil {
+			if err := ctrl(network, address, c); err != nil {
+				return err
+			}
+		}
+
+		var serr error
+		if err := c.Control(func(fd uintptr) {
+			serr = unix.SetsockoptString(int(fd), unix.IPPROTO_TCP, unix.TCP_CONGESTION, string(cc))
+		}); err != nil {
+			return err
+		}
+
+		return serr
+	}
+}
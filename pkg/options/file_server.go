@@ -0,0 +1,21 @@
+package options
+
+import (
+	"crypto/tls"
+
+	g "github.com/stv0g/gont/pkg"
+)
+
+type TLSConfig struct {
+	config *tls.Config
+}
+
+// WithTLSConfig enables HTTPS for BaseNode.ServeFiles().
+// The configuration must contain at least one certificate.
+func WithTLSConfig(cfg *tls.Config) TLSConfig {
+	return TLSConfig{cfg}
+}
+
+func (c TLSConfig) Apply(s *g.FileServer) {
+	s.TLSConfig = c.config
+}
@@ -27,3 +27,52 @@ func AddressIP(str string) Address {
 		Mask: n.Mask,
 	}
 }
+
+type DAD bool
+
+// WithDAD enables or disables the IPv6 duplicate address detection (DAD)
+// of the interface. DAD is disabled by default so that addresses are usable
+// immediately.
+func WithDAD(enable bool) DAD {
+	return DAD(enable)
+}
+
+func (d DAD) Apply(i *g.Interface) {
+	i.EnableDAD = bool(d)
+}
+
+type AutoAddress bool
+
+// WithAutoAddress enables or disables the automatic assignment of an IPv6
+// address from the prefix configured by WithIPv6Prefix() to the interface.
+// Auto-addressing is enabled by default.
+func WithAutoAddress(enable bool) AutoAddress {
+	return AutoAddress(enable)
+}
+
+func (a AutoAddress) Apply(i *g.Interface) {
+	i.DisableAutoAddress = !bool(a)
+}
+
+type PreUp g.InterfaceHook
+type PostUp g.InterfaceHook
+
+// WithPreUp registers a hook which is invoked within the network
+// namespace of the node before the interface is set up.
+func WithPreUp(h g.InterfaceHook) PreUp {
+	return PreUp(h)
+}
+
+// WithPostUp registers a hook which is invoked within the network
+// namespace of the node after the interface has been set up.
+func WithPostUp(h g.InterfaceHook) PostUp {
+	return PostUp(h)
+}
+
+func (h PreUp) Apply(i *g.Interface) {
+	i.PreUp = append(i.PreUp, g.InterfaceHook(h))
+}
+
+func (h PostUp) Apply(i *g.Interface) {
+	i.PostUp = append(i.PostUp, g.InterfaceHook(h))
+}
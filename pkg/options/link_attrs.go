@@ -27,6 +27,27 @@ func (l TxQLen) Apply(la *nl.LinkAttrs) {
 	la.TxQLen = int(l)
 }
 
+// NumTxQueues sets the number of transmit queues at creation time of the link
+type NumTxQueues int
+
+func (q NumTxQueues) Apply(la *nl.LinkAttrs) {
+	la.NumTxQueues = int(q)
+}
+
+// NumRxQueues sets the number of receive queues at creation time of the link
+type NumRxQueues int
+
+func (q NumRxQueues) Apply(la *nl.LinkAttrs) {
+	la.NumRxQueues = int(q)
+}
+
+// Alias sets the alias of the interface which is shown by "ip link"
+type Alias string
+
+func (a Alias) Apply(la *nl.LinkAttrs) {
+	la.Alias = string(a)
+}
+
 type HardwareAddress net.HardwareAddr
 
 func (a HardwareAddress) Apply(la *nl.LinkAttrs) {
@@ -1,11 +1,88 @@
 package options
 
 import (
+	"net"
+
 	g "github.com/stv0g/gont/pkg"
+	"go.uber.org/zap"
 )
 
 type NSPrefix string
 type Persistent bool
+type Nameserver net.IP
+type Logger zap.Logger
+type SubnetPool net.IPNet
+type IPv6Prefix net.IPNet
+type DisableIPv4 bool
+type DisableIPv6 bool
+type Init bool
+type KeepOnFailure bool
+type KernelLog bool
+type UserNamespace bool
+
+// SubnetPoolIP parses a CIDR string into a subnet pool
+// from which subnets can be allocated by Network.AllocSubnet().
+func SubnetPoolIP(str string) SubnetPool {
+	_, n, _ := net.ParseCIDR(str)
+
+	return SubnetPool(*n)
+}
+
+// WithIPv6Prefix parses a CIDR string into a ULA or GUA prefix from which
+// a /64 subnet is allocated for each link or switch of the network.
+// Interfaces of hosts are assigned an address from the subnet of their link
+// which is derived from their MAC address (EUI-64).
+// Interfaces with an IPv6 address or WithAutoAddress(false) are skipped.
+func WithIPv6Prefix(str string) IPv6Prefix {
+	_, n, err := net.ParseCIDR(str)
+	if err != nil {
+		return IPv6Prefix{}
+	}
+
+	return IPv6Prefix(*n)
+}
+
+// WithLogger uses the provided logger for the network and all its nodes
+// instead of the global logger.
+func WithLogger(l *zap.Logger) *Logger {
+	return (*Logger)(l)
+}
+
+// WithDisableIPv6 disables IPv6 in all nodes of an IPv4-only network.
+func WithDisableIPv6() DisableIPv6 {
+	return true
+}
+
+// WithDisableIPv4 rejects IPv4 addresses for all interfaces of an IPv6-only network.
+func WithDisableIPv4() DisableIPv4 {
+	return true
+}
+
+// WithInit spawns a pause-style init process for each node which
+// keeps its namespaces alive independently of the creating process.
+func WithInit() Init {
+	return true
+}
+
+// WithKeepOnFailure keeps the namespaces and files of the network
+// for a post-mortem inspection if the creating test panics or fails.
+func WithKeepOnFailure() KeepOnFailure {
+	return true
+}
+
+// WithKernelLog attaches the messages which are logged by the kernel during
+// failed netlink operations like adding links, qdiscs or routes to their errors.
+// Reading the kernel log might require CAP_SYSLOG.
+func WithKernelLog() KernelLog {
+	return true
+}
+
+// WithUserNamespace creates the network within the user namespace in which
+// the process has been re-executed by gont.ExecRootless(). Creating the
+// network fails with gont.ErrNotRootless if the process runs outside of it.
+func WithUserNamespace() UserNamespace {
+	return true
+}
 
 func (pfx NSPrefix) Apply(n *g.Network) {
 	n.NSPrefix = string(pfx)
@@ -15,6 +92,47 @@ func (p Persistent) Apply(n *g.Network) {
 	n.Persistent = bool(p)
 }
 
+func (ns Nameserver) Apply(n *g.Network) {
+	n.Nameservers = append(n.Nameservers, net.IP(ns))
+}
+
+func (l *Logger) Apply(n *g.Network) {
+	n.Logger = (*zap.Logger)(l)
+}
+
+func (p SubnetPool) Apply(n *g.Network) {
+	n.SubnetPools = append(n.SubnetPools, net.IPNet(p))
+}
+
+func (p IPv6Prefix) Apply(n *g.Network) {
+	pfx := net.IPNet(p)
+	n.IPv6Prefix = &pfx
+}
+
+func (d DisableIPv4) Apply(n *g.Network) {
+	n.DisableIPv4 = bool(d)
+}
+
+func (d DisableIPv6) Apply(n *g.Network) {
+	n.DisableIPv6 = bool(d)
+}
+
+func (i Init) Apply(n *g.Network) {
+	n.Init = bool(i)
+}
+
+func (k KeepOnFailure) Apply(n *g.Network) {
+	n.KeepOnFailure = bool(k)
+}
+
+func (k KernelLog) Apply(n *g.Network) {
+	n.KernelLog = bool(k)
+}
+
+func (u UserNamespace) Apply(n *g.Network) {
+	n.UserNamespace = bool(u)
+}
+
 func DefaultNetwork() (*g.Network, error) {
 	return g.NewNetwork("",
 		MTU(1500))
@@ -7,7 +7,14 @@ import (
 	nl "github.com/vishvananda/netlink"
 )
 
-type Netem nl.NetemQdiscAttrs
+type Netem struct {
+	nl.NetemQdiscAttrs
+
+	Handle uint32
+	Parent uint32
+	Slot   *g.NetemSlot
+}
+
 type Tbf nl.Tbf
 
 type NetemOption interface {
@@ -42,7 +49,10 @@ type Probability struct {
 }
 
 func (ne Netem) Apply(p *g.Interface) {
-	p.Netem = nl.NetemQdiscAttrs(ne)
+	p.Netem = ne.NetemQdiscAttrs
+	p.NetemAttrs.Handle = ne.Handle
+	p.NetemAttrs.Parent = ne.Parent
+	p.NetemSlot = ne.Slot
 	p.Flags |= g.WithQdiscNetem
 }
 
@@ -51,6 +61,30 @@ func (tbf Tbf) Apply(p *g.Interface) {
 	p.Flags |= g.WithQdiscTbf
 }
 
+// QdiscHandle sets the handle of the Netem or TBF qdisc like nl.MakeHandle(10, 0).
+// The handle must not be used by another qdisc of the interface.
+type QdiscHandle uint32
+
+// QdiscParent sets the handle of the qdisc or class to which the
+// Netem or TBF qdisc is attached like nl.HANDLE_ROOT.
+type QdiscParent uint32
+
+func (h QdiscHandle) ApplyNetem(n *Netem) {
+	n.Handle = uint32(h)
+}
+
+func (h QdiscHandle) ApplyTbf(t *Tbf) {
+	t.Handle = uint32(h)
+}
+
+func (p QdiscParent) ApplyNetem(n *Netem) {
+	n.Parent = uint32(p)
+}
+
+func (p QdiscParent) ApplyTbf(t *Tbf) {
+	t.Parent = uint32(p)
+}
+
 // Netem options
 
 type Latency time.Duration
@@ -101,6 +135,14 @@ func (c Corruption) ApplyNetem(n *Netem) {
 	n.CorruptCorr = c.Correlation
 }
 
+// Slot releases packets in bursts. See g.NetemSlot.
+type Slot g.NetemSlot
+
+func (s Slot) ApplyNetem(n *Netem) {
+	slot := g.NetemSlot(s)
+	n.Slot = &slot
+}
+
 // Tbf options
 
 type Buffer uint32
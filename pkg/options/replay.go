@@ -0,0 +1,19 @@
+package options
+
+import g "github.com/stv0g/gont/pkg"
+
+type Speed float64
+type Loops int
+type PacketsPerSecond float64
+
+func (s Speed) Apply(r *g.Replayer) {
+	r.Speed = float64(s)
+}
+
+func (l Loops) Apply(r *g.Replayer) {
+	r.Loops = int(l)
+}
+
+func (p PacketsPerSecond) Apply(r *g.Replayer) {
+	r.PacketsPerSecond = float64(p)
+}
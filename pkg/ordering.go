@@ -0,0 +1,79 @@
+package gont
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// SequenceFunc extracts the sequence number which has been assigned to a
+// packet by its sender. Packets for which ok is false are ignored.
+type SequenceFunc func(p gopacket.Packet) (seq uint64, ok bool)
+
+// ReorderedPacket is a packet which arrived after a packet
+// with a higher sequence number.
+type ReorderedPacket struct {
+	Sequence uint64
+
+	// After is the highest sequence number received before the packet
+	After uint64
+
+	// Index is the position of the packet among all sequenced packets
+	Index int
+}
+
+// OrderingSummary contains the results of AnalyzeOrdering()
+type OrderingSummary struct {
+	Packets    int
+	Duplicates int
+	Reordered  []ReorderedPacket
+}
+
+// InOrder returns true if all packets arrived in the order in which they have been sent
+func (s OrderingSummary) InOrder() bool {
+	return len(s.Reordered) == 0
+}
+
+// AnalyzeOrdering checks whether the packets contained in the PCAP or PCAPng
+// file at path have been captured in the order of their sequence numbers
+// as returned by seq.
+//
+// The capture should be taken on the receiving side. Packets are reported as
+// reordered if a packet with a higher sequence number has been captured before.
+// Packets with an already seen sequence number are counted as duplicates.
+func AnalyzeOrdering(path string, seq SequenceFunc) (OrderingSummary, error) {
+	var s OrderingSummary
+	var highest uint64
+
+	seen := map[uint64]bool{}
+
+	if err := readPacketFile(path, func(data []byte, ci gopacket.CaptureInfo, lt layers.LinkType) error {
+		sn, ok := seq(decodePacket(data, lt))
+		if !ok {
+			return nil
+		}
+
+		if seen[sn] {
+			s.Duplicates++
+			return nil
+		}
+
+		if s.Packets > 0 && sn < highest {
+			s.Reordered = append(s.Reordered, ReorderedPacket{
+				Sequence: sn,
+				After:    highest,
+				Index:    s.Packets,
+			})
+		} else {
+			highest = sn
+		}
+
+		seen[sn] = true
+		s.Packets++
+
+		return nil
+	}); err != nil {
+		return OrderingSummary{}, err
+	}
+
+	return s, nil
+}
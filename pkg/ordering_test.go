@@ -0,0 +1,208 @@
+package gont_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+// udpSequence extracts the sequence number from the payload of datagrams towards port 5000
+func udpSequence(p gopacket.Packet) (uint64, bool) {
+	udp, ok := p.TransportLayer().(*layers.UDP)
+	if !ok || udp.DstPort != 5000 || len(udp.Payload) != 8 {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint64(udp.Payload), true
+}
+
+// TestAnalyzeOrdering sends numbered datagrams from h1 to h2
+// over a clean link and a link which reorders packets
+//
+//  h1 <-> h2
+func TestAnalyzeOrdering(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		netem   []g.Option
+		inOrder bool
+	}{
+		{"clean", nil, true},
+		{"reorder", []g.Option{o.WithNetem(
+			o.Latency(10*time.Millisecond),
+			o.Reordering{Probability: 50},
+		)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				err    error
+				n      *g.Network
+				h1, h2 *g.Host
+			)
+
+			if n, err = g.NewNetwork(*nname, opts...); err != nil {
+				t.Fatalf("Failed to create network: %s", err)
+			}
+			defer n.Close()
+
+			if h1, err = n.AddHost("h1"); err != nil {
+				t.Fatalf("Failed to create host: %s", err)
+			}
+
+			if h2, err = n.AddHost("h2"); err != nil {
+				t.Fatalf("Failed to create host: %s", err)
+			}
+
+			if err := n.AddLink(
+				o.Interface("veth0", append([]g.Option{h1,
+					o.AddressIPv4(10, 0, 0, 1, 24)}, tc.netem...)...),
+				o.Interface("veth0", h2,
+					o.AddressIPv4(10, 0, 0, 2, 24)),
+			); errors.Is(err, unix.ENOENT) {
+				t.Skip("Kernel lacks support for Netem")
+			} else if err != nil {
+				t.Fatalf("Failed to connect hosts: %s", err)
+			}
+
+			var rconn *net.UDPConn
+			if err := h2.RunFunc(func() (err error) {
+				rconn, err = net.ListenUDP("udp4", &net.UDPAddr{Port: 5000})
+				return
+			}); err != nil {
+				t.Fatalf("Failed to open socket: %s", err)
+			}
+			defer rconn.Close()
+
+			path := filepath.Join(t.TempDir(), "ordering.pcap")
+
+			fd := listenPacket(t, h2.BaseNode, "veth0")
+			defer unix.Close(fd)
+
+			stop := capturePackets(t, fd, path)
+
+			var conn *net.UDPConn
+			if err := h1.RunFunc(func() (err error) {
+				conn, err = net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 5000})
+				return
+			}); err != nil {
+				t.Fatalf("Failed to open socket: %s", err)
+			}
+			defer conn.Close()
+
+			count := 100
+			buf := make([]byte, 8)
+			for i := 0; i < count; i++ {
+				binary.BigEndian.PutUint64(buf, uint64(i))
+				if _, err := conn.Write(buf); err != nil {
+					t.Fatalf("Failed to send datagram: %s", err)
+				}
+			}
+
+			time.Sleep(200 * time.Millisecond)
+			stop()
+
+			s, err := g.AnalyzeOrdering(path, udpSequence)
+			if err != nil {
+				t.Fatalf("Failed to analyze ordering: %s", err)
+			}
+
+			t.Logf("Ordering: %+v", s)
+
+			if s.Packets != count {
+				t.Errorf("Expected %d packets, got %d", count, s.Packets)
+			}
+
+			if s.InOrder() != tc.inOrder {
+				t.Errorf("Expected in-order delivery to be %t, got %d reordered packets", tc.inOrder, len(s.Reordered))
+			}
+		})
+	}
+}
+
+// TestAnalyzeOrderingFile analyzes a capture with late and duplicate packets
+func TestAnalyzeOrderingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ordering.pcap")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file: %s", err)
+	}
+
+	w := pcapgo.NewWriter(f)
+	if err := w.WriteFileHeader(65536, layers.LinkTypeEthernet); err != nil {
+		t.Fatalf("Failed to write file header: %s", err)
+	}
+
+	for _, sn := range []uint64{0, 1, 3, 2, 4, 4, 6, 5} {
+		payload := make([]byte, 8)
+		binary.BigEndian.PutUint64(payload, sn)
+
+		ip := &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: layers.IPProtocolUDP,
+			SrcIP:    net.IPv4(10, 0, 0, 1).To4(),
+			DstIP:    net.IPv4(10, 0, 0, 2).To4(),
+		}
+
+		udp := &layers.UDP{
+			SrcPort: 4000,
+			DstPort: 5000,
+		}
+		udp.SetNetworkLayerForChecksum(ip)
+
+		buf := gopacket.NewSerializeBuffer()
+		if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
+			&layers.Ethernet{
+				SrcMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 1},
+				DstMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 2},
+				EthernetType: layers.EthernetTypeIPv4,
+			}, ip, udp, gopacket.Payload(payload)); err != nil {
+			t.Fatalf("Failed to serialize packet: %s", err)
+		}
+
+		if err := w.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     time.Now(),
+			CaptureLength: len(buf.Bytes()),
+			Length:        len(buf.Bytes()),
+		}, buf.Bytes()); err != nil {
+			t.Fatalf("Failed to write packet: %s", err)
+		}
+	}
+
+	f.Close()
+
+	s, err := g.AnalyzeOrdering(path, udpSequence)
+	if err != nil {
+		t.Fatalf("Failed to analyze ordering: %s", err)
+	}
+
+	if s.Packets != 7 || s.Duplicates != 1 || s.InOrder() {
+		t.Fatalf("Invalid summary: %+v", s)
+	}
+
+	expected := []g.ReorderedPacket{
+		{Sequence: 2, After: 3, Index: 3},
+		{Sequence: 5, After: 6, Index: 6},
+	}
+
+	if len(s.Reordered) != len(expected) {
+		t.Fatalf("Expected %d reordered packets, got %d", len(expected), len(s.Reordered))
+	}
+
+	for i, r := range s.Reordered {
+		if r != expected[i] {
+			t.Errorf("Expected reordered packet %+v, got %+v", expected[i], r)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package gont_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+func htons(i uint16) uint16 {
+	return (i<<8)&0xff00 | i>>8
+}
+
+// listenPacket opens an AF_PACKET socket on the interface of a node
+func listenPacket(t *testing.T, n *g.BaseNode, iface string) int {
+	var fd int
+
+	if err := n.RunFunc(func() (err error) {
+		if fd, err = unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(htons(unix.ETH_P_ALL))); err != nil {
+			return err
+		}
+
+		intf, err := net.InterfaceByName(iface)
+		if err != nil {
+			return err
+		}
+
+		return unix.Bind(fd, &unix.SockaddrLinklayer{
+			Protocol: htons(unix.ETH_P_ALL),
+			Ifindex:  intf.Index,
+		})
+	}); err != nil {
+		t.Fatalf("Failed to open packet socket: %s", err)
+	}
+
+	tv := unix.NsecToTimeval(int64(100 * time.Millisecond))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		t.Fatalf("Failed to set timeout: %s", err)
+	}
+
+	return fd
+}
+
+// receivePacket waits for a packet matching the provided function
+func receivePacket(fd int, timeout time.Duration, match func(p gopacket.Packet) bool) gopacket.Packet {
+	buf := make([]byte, 1<<16)
+
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			continue
+		}
+
+		p := gopacket.NewPacket(buf[:n], layers.LayerTypeEthernet, gopacket.Default)
+		if match(p) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+func TestSendPacket(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	fd := listenPacket(t, h1.BaseNode, "veth0")
+	defer unix.Close(fd)
+
+	srcMAC := h1.Interface("veth0").Link.Attrs().HardwareAddr
+	dstMAC := h2.Interface("veth0").Link.Attrs().HardwareAddr
+
+	// L2: ARP request
+	eth := &layers.Ethernet{
+		SrcMAC:       srcMAC,
+		DstMAC:       layers.EthernetBroadcast,
+		EthernetType: layers.EthernetTypeARP,
+	}
+
+	arp := &layers.ARP{
+		AddrType:          layers.LinkTypeEthernet,
+		Protocol:          layers.EthernetTypeIPv4,
+		HwAddressSize:     6,
+		ProtAddressSize:   4,
+		Operation:         layers.ARPRequest,
+		SourceHwAddress:   srcMAC,
+		SourceProtAddress: net.IPv4(10, 0, 0, 1).To4(),
+		DstHwAddress:      make([]byte, 6),
+		DstProtAddress:    net.IPv4(10, 0, 0, 2).To4(),
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{}, eth, arp); err != nil {
+		t.Fatalf("Failed to serialize packet: %s", err)
+	}
+
+	if err := h1.SendPacket("veth0", gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	if p := receivePacket(fd, 2*time.Second, func(p gopacket.Packet) bool {
+		arp, ok := p.Layer(layers.LayerTypeARP).(*layers.ARP)
+		return ok && arp.Operation == layers.ARPReply && bytes.Equal(arp.SourceHwAddress, dstMAC)
+	}); p == nil {
+		t.Error("Did not receive ARP reply")
+	}
+
+	// L3: ICMP echo request
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolICMPv4,
+		SrcIP:    net.IPv4(10, 0, 0, 1),
+		DstIP:    net.IPv4(10, 0, 0, 2),
+	}
+
+	icmp := &layers.ICMPv4{
+		TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeEchoRequest, 0),
+		Id:       1234,
+		Seq:      1,
+	}
+
+	buf = gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{
+		FixLengths:       true,
+		ComputeChecksums: true,
+	}, ip, icmp); err != nil {
+		t.Fatalf("Failed to serialize packet: %s", err)
+	}
+
+	if err := h1.SendPacket("veth0", gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)); err != nil {
+		t.Fatalf("Failed to send packet: %s", err)
+	}
+
+	if p := receivePacket(fd, 2*time.Second, func(p gopacket.Packet) bool {
+		icmp, ok := p.Layer(layers.LayerTypeICMPv4).(*layers.ICMPv4)
+		return ok && icmp.TypeCode.Type() == layers.ICMPv4TypeEchoReply && icmp.Id == 1234
+	}); p == nil {
+		t.Error("Did not receive ICMP echo reply")
+	}
+}
+
+func TestNeighborProbe(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIP("10.0.0.1/24"),
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth0", h2,
+			o.AddressIP("10.0.0.2/24"),
+			o.AddressIP("fc::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	mac := h2.Interface("veth0").Link.Attrs().HardwareAddr
+
+	for _, tc := range []struct {
+		name  string
+		probe func(iface string, target net.IP) (net.HardwareAddr, error)
+		peer  string
+		other string
+	}{
+		{"ARP", h1.ARPProbe, "10.0.0.2", "10.0.0.3"},
+		{"NDP", h1.NDPProbe, "fc::2", "fc::3"},
+	} {
+		if addr, err := tc.probe("veth0", net.ParseIP(tc.peer)); err != nil {
+			t.Errorf("Failed to probe %s via %s: %s", tc.peer, tc.name, err)
+		} else if !bytes.Equal(addr, mac) {
+			t.Errorf("Invalid link-layer address of %s: %s != %s", tc.peer, addr, mac)
+		}
+
+		if _, err := tc.probe("veth0", net.ParseIP(tc.other)); !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("Unexpected error for probing non-existing %s via %s: %v", tc.other, tc.name, err)
+		}
+	}
+
+	if _, err := h1.ARPProbe("veth0", net.ParseIP("fc::2")); err == nil {
+		t.Error("Probed IPv6 address via ARP")
+	}
+
+	if _, err := h1.NDPProbe("veth0", net.ParseIP("10.0.0.2")); err == nil {
+		t.Error("Probed IPv4 address via NDP")
+	}
+}
@@ -0,0 +1,245 @@
+package gont
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vishvananda/netlink/nl"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// QdiscStats contains the statistics of a queueing discipline
+type QdiscStats struct {
+	Kind   string `json:"kind"`
+	Handle uint32 `json:"handle"`
+	Parent uint32 `json:"parent"`
+
+	Bytes      uint64 `json:"bytes"`
+	Packets    uint32 `json:"packets"`
+	Drops      uint32 `json:"drops"`
+	Overlimits uint32 `json:"overlimits"`
+	Requeues   uint32 `json:"requeues"`
+	Qlen       uint32 `json:"qlen"`
+	Backlog    uint32 `json:"backlog"`
+}
+
+// QdiscCallback is invoked by a QdiscMonitor for each queueing discipline
+// whose drops or overlimits exceeded the threshold within one interval.
+type QdiscCallback func(iface string, stats QdiscStats)
+
+// QdiscMonitor periodically polls the statistics of the
+// queueing disciplines of an interface.
+type QdiscMonitor struct {
+	node      *BaseNode
+	iface     string
+	interval  time.Duration
+	threshold uint32
+	callback  QdiscCallback
+
+	last map[uint32]QdiscStats
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// QdiscStats returns the statistics of all queueing disciplines
+// attached to the interface iface of the node.
+func (n *BaseNode) QdiscStats(iface string) ([]QdiscStats, error) {
+	link, err := n.nlHandle.LinkByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", iface, err)
+	}
+
+	var msgs [][]byte
+	if err := n.RunFunc(func() (err error) {
+		req := nl.NewNetlinkRequest(unix.RTM_GETQDISC, unix.NLM_F_DUMP)
+		req.AddData(&nl.TcMsg{
+			Family:  nl.FAMILY_ALL,
+			Ifindex: int32(link.Attrs().Index),
+		})
+
+		msgs, err = req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWQDISC)
+		return
+	}); err != nil {
+		return nil, fmt.Errorf("failed to dump qdiscs: %w", err)
+	}
+
+	stats := []QdiscStats{}
+	for _, msg := range msgs {
+		tcm := nl.DeserializeTcMsg(msg)
+		if int(tcm.Ifindex) != link.Attrs().Index {
+			continue
+		}
+
+		attrs, err := nl.ParseRouteAttr(msg[tcm.Len():])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse qdisc attributes: %w", err)
+		}
+
+		s := QdiscStats{
+			Handle: tcm.Handle,
+			Parent: tcm.Parent,
+		}
+
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case nl.TCA_KIND:
+				s.Kind = string(attr.Value[:len(attr.Value)-1])
+			case nl.TCA_STATS2:
+				if err := s.parseStats2(attr.Value); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+func (s *QdiscStats) parseStats2(b []byte) error {
+	attrs, err := nl.ParseRouteAttr(b)
+	if err != nil {
+		return fmt.Errorf("failed to parse qdisc statistics: %w", err)
+	}
+
+	native := nl.NativeEndian()
+
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.TCA_STATS_BASIC:
+			// struct gnet_stats_basic
+			if len(attr.Value) < 12 {
+				return syscall.EINVAL
+			}
+			s.Bytes = native.Uint64(attr.Value[0:])
+			s.Packets = native.Uint32(attr.Value[8:])
+
+		case nl.TCA_STATS_QUEUE:
+			// struct gnet_stats_queue
+			if len(attr.Value) < 20 {
+				return syscall.EINVAL
+			}
+			s.Qlen = native.Uint32(attr.Value[0:])
+			s.Backlog = native.Uint32(attr.Value[4:])
+			s.Drops = native.Uint32(attr.Value[8:])
+			s.Requeues = native.Uint32(attr.Value[12:])
+			s.Overlimits = native.Uint32(attr.Value[16:])
+		}
+	}
+
+	return nil
+}
+
+// MonitorQdisc starts a background monitor which polls the qdisc statistics
+// of the interface iface every interval. The callback is invoked whenever
+// the number of drops or overlimits of a qdisc increased by more than
+// threshold since the last poll.
+//
+// The monitor is stopped by QdiscMonitor.Stop() or when the node is torn down.
+func (n *BaseNode) MonitorQdisc(iface string, interval time.Duration, threshold uint32, cb QdiscCallback) (*QdiscMonitor, error) {
+	m := &QdiscMonitor{
+		node:      n,
+		iface:     iface,
+		interval:  interval,
+		threshold: threshold,
+		callback:  cb,
+		last:      map[uint32]QdiscStats{},
+		stop:      make(chan struct{}),
+	}
+
+	// Take initial snapshot to detect failures early
+	if err := m.poll(false); err != nil {
+		return nil, err
+	}
+
+	n.qdiscMonitors = append(n.qdiscMonitors, m)
+
+	m.wg.Add(1)
+	go m.run()
+
+	return m, nil
+}
+
+// Stop stops the monitor and waits for it to finish
+func (m *QdiscMonitor) Stop() {
+	select {
+	case <-m.stop:
+		return
+	default:
+	}
+
+	close(m.stop)
+	m.wg.Wait()
+}
+
+func (m *QdiscMonitor) run() {
+	defer m.wg.Done()
+
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-t.C:
+			if err := m.poll(true); err != nil {
+				m.node.logger.Warn("Failed to poll qdisc statistics",
+					zap.String("intf", m.iface),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+func (m *QdiscMonitor) poll(notify bool) error {
+	stats, err := m.node.QdiscStats(m.iface)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stats {
+		last, ok := m.last[s.Handle]
+		m.last[s.Handle] = s
+
+		if !ok || !notify {
+			continue
+		}
+
+		// The counters have been reset by replacing the qdisc or its statistics.
+		// The current statistics serve as a new baseline.
+		if s.Drops < last.Drops || s.Overlimits < last.Overlimits {
+			m.node.logger.Debug("Qdisc statistics have been reset",
+				zap.String("intf", m.iface),
+				zap.String("kind", s.Kind))
+			continue
+		}
+
+		if s.Drops-last.Drops > m.threshold || s.Overlimits-last.Overlimits > m.threshold {
+			m.node.logger.Warn("Qdisc drops exceeded threshold",
+				zap.String("intf", m.iface),
+				zap.String("kind", s.Kind),
+				zap.Uint32("drops", s.Drops),
+				zap.Uint32("overlimits", s.Overlimits))
+
+			if m.callback != nil {
+				m.callback(m.iface, s)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *BaseNode) stopQdiscMonitors() {
+	for _, m := range n.qdiscMonitors {
+		m.Stop()
+	}
+
+	n.qdiscMonitors = nil
+}
@@ -0,0 +1,117 @@
+package gont_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+// TestQdiscMonitor overloads a link with a tiny TBF qdisc
+// and checks that the monitor reports the drops
+//
+//  h1 <-> h2
+func TestQdiscMonitor(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.WithTbf(o.Rate(8000)),
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	var drops uint32
+	var calls int32
+
+	if _, err := h1.MonitorQdisc("veth0", 50*time.Millisecond, 10, func(iface string, s g.QdiscStats) {
+		if s.Kind == "tbf" {
+			atomic.StoreUint32(&drops, s.Drops)
+			atomic.AddInt32(&calls, 1)
+		}
+	}); err != nil {
+		t.Fatalf("Failed to start monitor: %s", err)
+	}
+
+	c, err := h1.Dial("udp", "10.0.0.2:5000")
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer c.Close()
+
+	buf := make([]byte, 1000)
+	for i := 0; i < 1000; i++ {
+		// Errors are expected once the queue overflows
+		c.Write(buf)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	if atomic.LoadInt32(&calls) == 0 {
+		t.Fatal("Monitor callback did not fire")
+	}
+
+	stats, err := h1.QdiscStats("veth0")
+	if err != nil {
+		t.Fatalf("Failed to get qdisc stats: %s", err)
+	}
+
+	for _, s := range stats {
+		if s.Kind == "tbf" && s.Drops < atomic.LoadUint32(&drops) {
+			t.Errorf("Drop counter decreased: %d < %d", s.Drops, drops)
+		}
+	}
+
+	// Recreating the qdisc resets its counters which must not be
+	// mistaken for an increase of the drops
+	tbf := &h1.Interface("veth0").Tbf
+
+	if err := h1.NetlinkHandle().QdiscDel(tbf); err != nil {
+		t.Fatalf("Failed to delete qdisc: %s", err)
+	}
+
+	if err := h1.NetlinkHandle().QdiscAdd(tbf); err != nil {
+		t.Fatalf("Failed to add qdisc: %s", err)
+	}
+
+	reset := atomic.LoadInt32(&calls)
+	time.Sleep(200 * time.Millisecond)
+
+	if atomic.LoadInt32(&calls) != reset {
+		t.Error("Monitor fired after the counters have been reset")
+	}
+
+	if err := n.Teardown(); err != nil {
+		t.Fatalf("Failed to teardown network: %s", err)
+	}
+
+	// Monitor must not fire after teardown
+	fired := atomic.LoadInt32(&calls)
+	time.Sleep(200 * time.Millisecond)
+
+	if atomic.LoadInt32(&calls) != fired {
+		t.Error("Monitor still active after teardown")
+	}
+}
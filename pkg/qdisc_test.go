@@ -1,7 +1,10 @@
 package gont_test
 
 import (
+	"context"
+	"errors"
 	"math"
+	"net"
 	"os"
 	"strings"
 	"testing"
@@ -10,6 +13,7 @@ import (
 	"github.com/go-ping/ping"
 	g "github.com/stv0g/gont/pkg"
 	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
 )
 
 func testNetem(t *testing.T, ne o.Netem) (*ping.Statistics, error) {
@@ -126,3 +130,267 @@ func TestNetemDuplication(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// TestNetemOneWayDelay delays only packets sent by h1
+//
+// h1 <-> h2
+func TestNetemOneWayDelay(t *testing.T) {
+	if _, ok := os.LookupEnv("GITHUB_WORKFLOW"); ok {
+		// GitHubs Azure based CI environment is to unreliable
+		// for this test to success consistently
+		t.Skip()
+	}
+
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	latency := 50 * time.Millisecond
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.WithNetem(o.Latency(latency)),
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if _, err := n.OneWayDelay(h1, h2, 0); err == nil {
+		t.Error("Measured one-way delay without datagrams")
+	}
+
+	avg := func(src, dst *g.Host) time.Duration {
+		delays, err := n.OneWayDelay(src, dst, 10)
+		if err != nil {
+			t.Fatalf("Failed to measure one-way delay: %s", err)
+		}
+
+		if len(delays) != 10 {
+			t.Errorf("Lost %d datagrams", 10-len(delays))
+		}
+
+		var sum time.Duration
+		for _, d := range delays {
+			sum += d
+		}
+
+		return sum / time.Duration(len(delays))
+	}
+
+	if d := avg(h1, h2); d < latency || d > latency+10*time.Millisecond {
+		t.Errorf("Invalid one-way delay from h1 to h2: %s", d)
+	}
+
+	if d := avg(h2, h1); d > 10*time.Millisecond {
+		t.Errorf("Invalid one-way delay from h2 to h1: %s", d)
+	}
+}
+
+// TestNetemSlot releases the packets sent by h1 in bursts
+//
+// h1 <-> h2
+func TestNetemSlot(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	for _, slot := range []o.Slot{
+		{MinDelay: 20 * time.Millisecond},
+		{MinDelay: 20 * time.Millisecond, MaxDelay: 10 * time.Millisecond},
+		{MinDelay: -10 * time.Millisecond, MaxDelay: 10 * time.Millisecond},
+		{MaxDelay: 10 * time.Millisecond, Packets: -1},
+	} {
+		if err := n.AddLink(
+			o.Interface("veth1", h1,
+				o.WithNetem(slot)),
+			o.Interface("veth1", h2),
+		); err == nil || !strings.Contains(err.Error(), "invalid Netem slot") {
+			t.Errorf("Added invalid slot %+v: %v", slot, err)
+		}
+	}
+
+	slotDelay := 100 * time.Millisecond
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.WithNetem(o.Slot{
+				MinDelay: slotDelay,
+				MaxDelay: slotDelay,
+			}),
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); errors.Is(err, unix.ENOENT) {
+		t.Skip("Kernel lacks support for Netem")
+	} else if err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	var rconn, sconn *net.UDPConn
+	if err := h2.RunFunc(func() (err error) {
+		rconn, err = net.ListenUDP("udp4", &net.UDPAddr{Port: 5000})
+		return
+	}); err != nil {
+		t.Fatalf("Failed to open socket: %s", err)
+	}
+	defer rconn.Close()
+
+	if err := h1.RunFunc(func() (err error) {
+		sconn, err = net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 5000})
+		return
+	}); err != nil {
+		t.Fatalf("Failed to open socket: %s", err)
+	}
+	defer sconn.Close()
+
+	// Send steadily for a few slots
+	count := 50
+	go func() {
+		for i := 0; i < count; i++ {
+			sconn.Write([]byte("hello"))
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	if err := rconn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("Failed to set deadline: %s", err)
+	}
+
+	// Packets within a burst arrive almost at once
+	bursts := 0
+	last := time.Time{}
+	buf := make([]byte, 128)
+	for i := 0; i < count; i++ {
+		if _, err := rconn.Read(buf); err != nil {
+			t.Fatalf("Failed to receive packet %d: %s", i, err)
+		}
+
+		now := time.Now()
+		if now.Sub(last) > slotDelay/2 {
+			bursts++
+		}
+		last = now
+	}
+
+	t.Logf("Received %d packets in %d bursts", count, bursts)
+
+	if bursts < 2 || bursts > count/5 {
+		t.Errorf("Packets did not arrive in bursts: %d bursts", bursts)
+	}
+}
+
+// TestLatencySpikes periodically raises the latency of the link
+// and observes the oscillating RTT
+//
+// h1 <-> h2
+func TestLatencySpikes(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	baseline := 5 * time.Millisecond
+	spike := 200 * time.Millisecond
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.WithNetem(o.Latency(baseline)),
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); errors.Is(err, unix.ENOENT) {
+		t.Skip("Kernel lacks support for Netem")
+	} else if err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if _, err := h2.Interface("veth0").InjectLatencySpikes(context.Background(), baseline, spike, time.Second, 300*time.Millisecond); err == nil {
+		t.Error("Injected latency spikes without Netem qdisc")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := h1.Interface("veth0").InjectLatencySpikes(ctx, baseline, spike, time.Second, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to inject latency spikes: %s", err)
+	}
+
+	stats, err := h1.PingWithOptions(h2, "ip", 60, 10*time.Second, 50*time.Millisecond, false)
+	if err != nil {
+		t.Fatalf("Failed to ping: %s", err)
+	}
+
+	low, high := 0, 0
+	for _, rtt := range stats.Rtts {
+		if rtt < spike/2 {
+			low++
+		} else {
+			high++
+		}
+	}
+
+	t.Logf("RTTs below spike: %d, during spike: %d", low, high)
+
+	if low == 0 || high == 0 {
+		t.Errorf("RTT did not oscillate")
+	}
+
+	cancel()
+	s.Stop()
+
+	stats, err = h1.PingWithOptions(h2, "ip", 10, 5*time.Second, 100*time.Millisecond, false)
+	if err != nil {
+		t.Fatalf("Failed to ping: %s", err)
+	}
+
+	if stats.MaxRtt > spike/2 {
+		t.Errorf("Latency has not been reset: %s", stats.MaxRtt)
+	}
+}
@@ -0,0 +1,162 @@
+package gont
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	nl "github.com/vishvananda/netlink"
+)
+
+// QdiscNode is a queueing discipline or a class within
+// the hierarchy of traffic control objects of an interface.
+type QdiscNode struct {
+	Kind   string
+	Handle uint32
+	Parent uint32
+
+	// Either Qdisc or Class is set and contains the parameters of the object.
+	Qdisc nl.Qdisc
+	Class nl.Class
+
+	Children []*QdiscNode
+}
+
+// IsClass returns true if the node is a class rather than a qdisc
+func (q *QdiscNode) IsClass() bool {
+	return q.Class != nil
+}
+
+// Find returns the qdisc or class with the handle within the tree
+// or nil if it does not exist.
+func (q *QdiscNode) Find(handle uint32) *QdiscNode {
+	if q.Handle == handle {
+		return q
+	}
+
+	for _, c := range q.Children {
+		if f := c.Find(handle); f != nil {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// String renders the tree similar to the output of "tc qdisc" and "tc class"
+func (q *QdiscNode) String() string {
+	var b strings.Builder
+	q.render(&b, 0)
+	return b.String()
+}
+
+func (q *QdiscNode) render(b *strings.Builder, depth int) {
+	typ := "qdisc"
+	if q.IsClass() {
+		typ = "class"
+	}
+
+	fmt.Fprintf(b, "%s%s %s %s\n", strings.Repeat("  ", depth), typ, q.Kind, nl.HandleStr(q.Handle))
+
+	for _, c := range q.Children {
+		c.render(b, depth+1)
+	}
+}
+
+// QdiscTree returns the hierarchy of the egress qdiscs and classes
+// of the interface starting at its root qdisc.
+//
+// The ingress and clsact qdiscs are not part of the tree.
+func (i *Interface) QdiscTree() (*QdiscNode, error) {
+	link, err := i.currentLink()
+	if err != nil {
+		return nil, err
+	}
+
+	h := i.Node.NetlinkHandle()
+
+	qdiscs, err := h.QdiscList(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list qdiscs: %w", err)
+	}
+
+	classes, err := h.ClassList(link, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classes: %w", err)
+	}
+
+	var root *QdiscNode
+	nodes := []*QdiscNode{}
+
+	for _, qdisc := range qdiscs {
+		attrs := qdisc.Attrs()
+		if attrs.Parent == nl.HANDLE_INGRESS || attrs.Parent == nl.HANDLE_CLSACT {
+			continue
+		}
+
+		node := &QdiscNode{
+			Kind:   qdisc.Type(),
+			Handle: attrs.Handle,
+			Parent: attrs.Parent,
+			Qdisc:  qdisc,
+		}
+
+		if attrs.Parent == nl.HANDLE_ROOT {
+			root = node
+		} else {
+			nodes = append(nodes, node)
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("interface %s has no root qdisc", i.Name)
+	}
+
+	for _, class := range classes {
+		attrs := class.Attrs()
+
+		// The kernel reports the top-level classes of a qdisc as root
+		parent := attrs.Parent
+		if parent == nl.HANDLE_ROOT {
+			major, _ := nl.MajorMinor(attrs.Handle)
+			parent = nl.MakeHandle(major, 0)
+		}
+
+		nodes = append(nodes, &QdiscNode{
+			Kind:   class.Type(),
+			Handle: attrs.Handle,
+			Parent: parent,
+			Class:  class,
+		})
+	}
+
+	// Parents are either qdiscs or classes. Default qdiscs of
+	// multi-queue devices share the handle 0: with their root.
+	// Hence the first object registered for a handle wins.
+	byHandle := map[uint32]*QdiscNode{
+		root.Handle: root,
+	}
+
+	for _, node := range nodes {
+		if _, ok := byHandle[node.Handle]; !ok {
+			byHandle[node.Handle] = node
+		}
+	}
+
+	for _, node := range nodes {
+		parent, ok := byHandle[node.Parent]
+		if !ok {
+			return nil, fmt.Errorf("failed to find parent %s of %s", nl.HandleStr(node.Parent), nl.HandleStr(node.Handle))
+		}
+
+		parent.Children = append(parent.Children, node)
+	}
+
+	for _, node := range byHandle {
+		sort.Slice(node.Children, func(a, b int) bool {
+			return node.Children[a].Handle < node.Children[b].Handle
+		})
+	}
+
+	return root, nil
+}
@@ -0,0 +1,244 @@
+package gont_test
+
+import (
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+)
+
+//  h1 <-> h2
+func TestQdiscTree(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	i := h1.Interface("veth0")
+	nlh := h1.NetlinkHandle()
+	idx := i.Link.Attrs().Index
+
+	var (
+		root    = nl.MakeHandle(1, 0)
+		classP  = nl.MakeHandle(1, 1)
+		classHi = nl.MakeHandle(1, 10)
+		classLo = nl.MakeHandle(1, 20)
+		leaf    = nl.MakeHandle(10, 0)
+	)
+
+	// veth devices have no queue by default
+	if tree, err := i.QdiscTree(); err != nil {
+		t.Fatalf("Failed to get qdisc tree: %s", err)
+	} else if tree.Kind != "noqueue" || len(tree.Children) != 0 {
+		t.Errorf("Unexpected default qdisc tree: %s", tree)
+	}
+
+	htb := nl.NewHtb(nl.QdiscAttrs{
+		LinkIndex: idx,
+		Handle:    root,
+		Parent:    nl.HANDLE_ROOT,
+	})
+
+	if err := nlh.QdiscAdd(htb); err != nil {
+		t.Fatalf("Failed to add HTB qdisc: %s", err)
+	}
+
+	for _, c := range []struct{ parent, handle uint32 }{
+		{root, classP},
+		{classP, classHi},
+		{classP, classLo},
+	} {
+		if err := nlh.ClassAdd(nl.NewHtbClass(nl.ClassAttrs{
+			LinkIndex: idx,
+			Parent:    c.parent,
+			Handle:    c.handle,
+		}, nl.HtbClassAttrs{
+			Rate: 10e6,
+			Ceil: 10e6,
+		})); err != nil {
+			t.Fatalf("Failed to add HTB class: %s", err)
+		}
+	}
+
+	if err := nlh.QdiscAdd(&nl.GenericQdisc{
+		QdiscAttrs: nl.QdiscAttrs{
+			LinkIndex: idx,
+			Handle:    leaf,
+			Parent:    classHi,
+		},
+		QdiscType: "pfifo",
+	}); err != nil {
+		t.Fatalf("Failed to add leaf qdisc: %s", err)
+	}
+
+	tree, err := i.QdiscTree()
+	if err != nil {
+		t.Fatalf("Failed to get qdisc tree: %s", err)
+	}
+
+	t.Logf("Qdisc tree:\n%s", tree)
+
+	if tree.Kind != "htb" || tree.Handle != root || tree.IsClass() {
+		t.Fatalf("Unexpected root: %s %s", tree.Kind, nl.HandleStr(tree.Handle))
+	}
+
+	if len(tree.Children) != 1 || tree.Children[0].Handle != classP {
+		t.Fatalf("Root has unexpected children: %s", tree)
+	}
+
+	p := tree.Children[0]
+	if !p.IsClass() || p.Kind != "htb" {
+		t.Errorf("Parent class is not a HTB class: %s", p.Kind)
+	}
+
+	if len(p.Children) != 2 || p.Children[0].Handle != classHi || p.Children[1].Handle != classLo {
+		t.Fatalf("Parent class has unexpected children: %s", tree)
+	}
+
+	if c, ok := p.Children[0].Class.(*nl.HtbClass); !ok || c.Rate != 10e6/8 {
+		t.Errorf("Class does not contain HTB parameters: %+v", p.Children[0].Class)
+	}
+
+	hi := tree.Find(classHi)
+	if len(hi.Children) != 1 || hi.Children[0].Handle != leaf || hi.Children[0].Kind != "pfifo" {
+		t.Errorf("Class has unexpected leaf qdisc: %s", tree)
+	}
+
+	if lo := tree.Find(classLo); len(lo.Children) != 0 {
+		t.Errorf("Class has unexpected children: %s", tree)
+	}
+}
+
+// TestQdiscHandles builds a three-level stack below
+// a TBF qdisc which has been configured with an explicit handle
+//
+//  h1 <-> h2
+func TestQdiscHandles(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	var (
+		top    = nl.MakeHandle(10, 0)
+		middle = nl.MakeHandle(20, 0)
+		leaf   = nl.MakeHandle(30, 0)
+	)
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.WithTbf(
+				o.Rate(8000),
+				o.QdiscHandle(top),
+				o.QdiscParent(nl.HANDLE_ROOT))),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	i := h1.Interface("veth0")
+	nlh := h1.NetlinkHandle()
+	idx := i.Link.Attrs().Index
+
+	if err := nlh.QdiscAdd(&nl.Tbf{
+		QdiscAttrs: nl.QdiscAttrs{
+			LinkIndex: idx,
+			Handle:    middle,
+			Parent:    nl.MakeHandle(10, 1),
+		},
+		Rate:   8000,
+		Limit:  0x7000,
+		Buffer: 300000,
+	}); err != nil {
+		t.Fatalf("Failed to add TBF qdisc: %s", err)
+	}
+
+	if err := nlh.QdiscAdd(&nl.GenericQdisc{
+		QdiscAttrs: nl.QdiscAttrs{
+			LinkIndex: idx,
+			Handle:    leaf,
+			Parent:    nl.MakeHandle(20, 1),
+		},
+		QdiscType: "pfifo",
+	}); err != nil {
+		t.Fatalf("Failed to add leaf qdisc: %s", err)
+	}
+
+	tree, err := i.QdiscTree()
+	if err != nil {
+		t.Fatalf("Failed to get qdisc tree: %s", err)
+	}
+
+	t.Logf("Qdisc tree:\n%s", tree)
+
+	if tree.Kind != "tbf" || tree.Handle != top {
+		t.Fatalf("Unexpected root: %s %s", tree.Kind, nl.HandleStr(tree.Handle))
+	}
+
+	if m := tree.Find(middle); m == nil || m.Kind != "tbf" {
+		t.Fatalf("Middle qdisc is not part of the tree: %s", tree)
+	} else if l := m.Find(leaf); l == nil || l.Kind != "pfifo" {
+		t.Errorf("Leaf qdisc is not below the middle qdisc: %s", tree)
+	}
+
+	// Handles are validated before any qdisc is added
+	for _, tc := range []struct {
+		name string
+		opts []g.Option
+	}{
+		{"duplicate", []g.Option{
+			o.WithNetem(o.Latency(0), o.QdiscHandle(top)),
+			o.WithTbf(o.Rate(8000), o.QdiscHandle(top)),
+		}},
+		{"minor", []g.Option{
+			o.WithTbf(o.Rate(8000), o.QdiscHandle(nl.MakeHandle(10, 1))),
+		}},
+	} {
+		if err := n.AddLink(
+			o.Interface("veth1", append([]g.Option{h1}, tc.opts...)...),
+			o.Interface("veth1", h2),
+		); err == nil {
+			t.Errorf("Accepted invalid %s handle", tc.name)
+		}
+
+		if tree, err := h1.Interface("veth0").QdiscTree(); err != nil || tree.Find(leaf) == nil {
+			t.Errorf("Existing qdiscs have been modified: %s", tree)
+		}
+	}
+}
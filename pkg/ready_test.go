@@ -0,0 +1,158 @@
+package gont_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func hasTentativeAddress(t *testing.T, h *g.Host, iface string) bool {
+	link, err := h.NetlinkHandle().LinkByName(iface)
+	if err != nil {
+		t.Fatalf("Failed to find interface: %s", err)
+	}
+
+	addrs, err := h.NetlinkHandle().AddrList(link, nl.FAMILY_V6)
+	if err != nil {
+		t.Fatalf("Failed to list addresses: %s", err)
+	}
+
+	for _, addr := range addrs {
+		if addr.Flags&unix.IFA_F_TENTATIVE != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestWaitReady waits for the completion of the duplicate
+// address detection before connecting to the peer
+//
+//  h1 <-> h2
+func TestWaitReady(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.WithDAD(true),
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth0", h2,
+			o.WithDAD(true),
+			o.AddressIP("fc::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if !hasTentativeAddress(t, h1, "veth0") {
+		t.Fatal("Address is not tentative after setup")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, h := range []*g.Host{h1, h2} {
+		if err := h.WaitReady(ctx); err != nil {
+			t.Fatalf("Failed to wait for node: %s", err)
+		}
+	}
+
+	if hasTentativeAddress(t, h1, "veth0") {
+		t.Fatal("Address is still tentative")
+	}
+
+	var l net.Listener
+	if err := h2.RunFunc(func() (err error) {
+		l, err = net.Listen("tcp6", "[fc::2]:8080")
+		return
+	}); err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer l.Close()
+
+	c, err := h1.Dial("tcp6", "[fc::2]:8080")
+	if err != nil {
+		t.Fatalf("Failed to connect: %s", err)
+	}
+	c.Close()
+
+	// A context without time left must fail
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	if err := n.AddLink(
+		o.Interface("veth1", h1,
+			o.WithDAD(true),
+			o.AddressIP("fd::1/64")),
+		o.Interface("veth1", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if err := h1.WaitReady(ctx); err == nil {
+		t.Error("Node is ready despite tentative address")
+	}
+}
+
+// TestDisableDAD checks that addresses are usable immediately
+// if duplicate address detection has been disabled
+//
+//  h1 <-> h2
+func TestDisableDAD(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.WithDAD(false),
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth0", h2,
+			o.WithDAD(false),
+			o.AddressIP("fc::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if hasTentativeAddress(t, h1, "veth0") || hasTentativeAddress(t, h2, "veth0") {
+		t.Error("Address is tentative despite disabled DAD")
+	}
+}
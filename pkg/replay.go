@@ -0,0 +1,174 @@
+package gont
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+var pcapngMagic = []byte{0x0a, 0x0d, 0x0d, 0x0a}
+
+// Replayer re-transmits packets from a PCAP or PCAPng file
+type Replayer struct {
+	// Options
+
+	// Speed is a multiplier for the original timing of the packets.
+	// A value of 2 replays the packets twice as fast.
+	// A value of zero or less replays the packets without any delay.
+	Speed float64
+
+	// Loops is the number of times the file is replayed
+	Loops int
+
+	// PacketsPerSecond limits the rate at which packets are transmitted.
+	// A value of zero disables the limit.
+	PacketsPerSecond float64
+}
+
+// Replay re-transmits all packets from the PCAP or PCAPng file
+// at path out of the interface iface of the node.
+//
+// Only captures with Ethernet or raw IP link-layers are supported.
+func (n *BaseNode) Replay(iface, path string, opts ...Option) error {
+	r := &Replayer{
+		Speed: 1,
+		Loops: 1,
+	}
+
+	for _, opt := range opts {
+		if ropt, ok := opt.(ReplayOption); ok {
+			ropt.Apply(r)
+		}
+	}
+
+	fd, sa, err := n.openPacketSocket(iface)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	logger := n.logger.With(
+		zap.String("intf", iface),
+		zap.String("path", path),
+	)
+
+	for loop := 0; loop < r.Loops; loop++ {
+		logger.Info("Replaying packets", zap.Int("loop", loop))
+
+		if err := r.replayFile(path, func(data []byte, lt layers.LinkType) error {
+			switch lt {
+			case layers.LinkTypeEthernet:
+				return unix.Sendto(fd, data, 0, sa)
+
+			case layers.LinkTypeRaw, layers.LinkTypeIPv4, layers.LinkTypeIPv6:
+				return n.SendPacket(iface, decodePacket(data, lt))
+
+			default:
+				return fmt.Errorf("unsupported link-layer type: %s", lt)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type packetReader interface {
+	gopacket.PacketDataSource
+	LinkType() layers.LinkType
+}
+
+func (r *Replayer) replayFile(path string, send func(data []byte, lt layers.LinkType) error) error {
+	var interval time.Duration
+	if r.PacketsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / r.PacketsPerSecond)
+	}
+
+	var first time.Time
+	var last time.Time
+	start := time.Now()
+
+	return readPacketFile(path, func(data []byte, ci gopacket.CaptureInfo, lt layers.LinkType) error {
+		// Wait for original timing
+		if r.Speed > 0 {
+			if first.IsZero() {
+				first = ci.Timestamp
+			}
+
+			offset := time.Duration(float64(ci.Timestamp.Sub(first)) / r.Speed)
+			time.Sleep(time.Until(start.Add(offset)))
+		}
+
+		// Limit packet rate
+		if interval > 0 && !last.IsZero() {
+			time.Sleep(time.Until(last.Add(interval)))
+		}
+
+		if err := send(data, lt); err != nil {
+			return fmt.Errorf("failed to send packet: %w", err)
+		}
+
+		last = time.Now()
+
+		return nil
+	})
+}
+
+// readPacketFile invokes cb for each packet of the PCAP or PCAPng file at path
+func readPacketFile(path string, cb func(data []byte, ci gopacket.CaptureInfo, lt layers.LinkType) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+
+	magic, err := br.Peek(len(pcapngMagic))
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var rd packetReader
+	var ngrd *pcapgo.NgReader
+	if bytes.Equal(magic, pcapngMagic) {
+		if ngrd, err = pcapgo.NewNgReader(br, pcapgo.DefaultNgReaderOptions); err != nil {
+			return fmt.Errorf("failed to open PCAPng file: %w", err)
+		}
+		rd = ngrd
+	} else if rd, err = pcapgo.NewReader(br); err != nil {
+		return fmt.Errorf("failed to open PCAP file: %w", err)
+	}
+
+	for {
+		data, ci, err := rd.ReadPacketData()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read packet: %w", err)
+		}
+
+		lt := rd.LinkType()
+		if ngrd != nil {
+			if intf, err := ngrd.Interface(ci.InterfaceIndex); err == nil {
+				lt = intf.LinkType
+			}
+		}
+
+		if err := cb(data, ci, lt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package gont_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+const numReplayPackets = 10
+
+// writeReplayFile writes a PCAP file with numbered UDP datagrams
+// which are spaced 10ms apart
+func writeReplayFile(t *testing.T, path string, src, dst net.HardwareAddr) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file: %s", err)
+	}
+	defer f.Close()
+
+	w := pcapgo.NewWriter(f)
+	if err := w.WriteFileHeader(65536, layers.LinkTypeEthernet); err != nil {
+		t.Fatalf("Failed to write file header: %s", err)
+	}
+
+	ts := time.Now()
+
+	for i := 0; i < numReplayPackets; i++ {
+		eth := &layers.Ethernet{
+			SrcMAC:       src,
+			DstMAC:       dst,
+			EthernetType: layers.EthernetTypeIPv4,
+		}
+
+		ip := &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: layers.IPProtocolUDP,
+			SrcIP:    net.IPv4(10, 0, 0, 1),
+			DstIP:    net.IPv4(10, 0, 0, 2),
+		}
+
+		udp := &layers.UDP{
+			SrcPort: 1234,
+			DstPort: 5678,
+		}
+		if err := udp.SetNetworkLayerForChecksum(ip); err != nil {
+			t.Fatalf("Failed to set network layer: %s", err)
+		}
+
+		buf := gopacket.NewSerializeBuffer()
+		if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{
+			FixLengths:       true,
+			ComputeChecksums: true,
+		}, eth, ip, udp, gopacket.Payload{byte(i)}); err != nil {
+			t.Fatalf("Failed to serialize packet: %s", err)
+		}
+
+		if err := w.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     ts.Add(time.Duration(i) * 10 * time.Millisecond),
+			CaptureLength: len(buf.Bytes()),
+			Length:        len(buf.Bytes()),
+		}, buf.Bytes()); err != nil {
+			t.Fatalf("Failed to write packet: %s", err)
+		}
+	}
+}
+
+// receiveReplayedPackets returns the payloads of all replayed packets received on fd
+func receiveReplayedPackets(fd int, timeout time.Duration) []byte {
+	payloads := []byte{}
+
+	receivePacket(fd, timeout, func(p gopacket.Packet) bool {
+		if udp, ok := p.Layer(layers.LayerTypeUDP).(*layers.UDP); ok && udp.DstPort == 5678 && len(udp.Payload) == 1 {
+			payloads = append(payloads, udp.Payload[0])
+		}
+		return false
+	})
+
+	return payloads
+}
+
+//  h1 <-> h2
+func TestReplay(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "replay.pcap")
+	writeReplayFile(t, path,
+		h1.Interface("veth0").Link.Attrs().HardwareAddr,
+		h2.Interface("veth0").Link.Attrs().HardwareAddr)
+
+	fd := listenPacket(t, h2.BaseNode, "veth0")
+	defer unix.Close(fd)
+
+	// Original timing with two loops
+	start := time.Now()
+	if err := h1.Replay("veth0", path, o.Loops(2)); err != nil {
+		t.Fatalf("Failed to replay packets: %s", err)
+	}
+
+	if d := time.Since(start); d < 2*(numReplayPackets-1)*10*time.Millisecond {
+		t.Errorf("Replay was too fast: %s", d)
+	}
+
+	payloads := receiveReplayedPackets(fd, 500*time.Millisecond)
+	if len(payloads) != 2*numReplayPackets {
+		t.Fatalf("Received %d packets, expected %d", len(payloads), 2*numReplayPackets)
+	}
+
+	for i, p := range payloads {
+		if int(p) != i%numReplayPackets {
+			t.Fatalf("Packet %d out of order: got %d", i, p)
+		}
+	}
+
+	// Without delays but rate limited
+	start = time.Now()
+	if err := h1.Replay("veth0", path, o.Speed(0), o.PacketsPerSecond(200)); err != nil {
+		t.Fatalf("Failed to replay packets: %s", err)
+	}
+
+	if d := time.Since(start); d < (numReplayPackets-1)*5*time.Millisecond {
+		t.Errorf("Replay was not rate limited: %s", d)
+	}
+
+	if payloads := receiveReplayedPackets(fd, 500*time.Millisecond); len(payloads) != numReplayPackets {
+		t.Errorf("Received %d packets, expected %d", len(payloads), numReplayPackets)
+	}
+}
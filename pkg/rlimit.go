@@ -0,0 +1,83 @@
+package gont
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const rlimitEnvPrefix = "GONT_RLIMIT_"
+
+// Rlimit limits the consumption of a resource by a process
+// started via BaseNode.Run() or BaseNode.Start().
+//
+// See setrlimit(2) for a list of resources.
+type Rlimit struct {
+	Resource int
+	Soft     uint64
+	Hard     uint64
+}
+
+func (r Rlimit) Apply(c *exec.Cmd) {
+	if c.Env == nil {
+		c.Env = os.Environ()
+	}
+
+	// Later limits for the same resource take precedence
+	// as exec.Cmd only passes the last value of a variable
+	c.Env = append(c.Env, fmt.Sprintf("%s%d=%d:%d", rlimitEnvPrefix, r.Resource, r.Soft, r.Hard))
+}
+
+// hasRlimits returns true if any limits are passed in the environment env
+func hasRlimits(env []string) bool {
+	for _, e := range env {
+		if strings.HasPrefix(e, rlimitEnvPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyRlimits sets the resource limits passed in the
+// environment of the calling process and removes them from it
+func applyRlimits() error {
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, rlimitEnvPrefix) {
+			continue
+		}
+
+		name, value, _ := strings.Cut(e, "=")
+		if err := os.Unsetenv(name); err != nil {
+			return err
+		}
+
+		resource, err := strconv.Atoi(strings.TrimPrefix(name, rlimitEnvPrefix))
+		if err != nil {
+			return fmt.Errorf("invalid resource: %w", err)
+		}
+
+		soft, hard, _ := strings.Cut(value, ":")
+
+		var rlim syscall.Rlimit
+		if rlim.Cur, err = strconv.ParseUint(soft, 10, 64); err != nil {
+			return fmt.Errorf("invalid soft limit: %w", err)
+		}
+
+		if rlim.Max, err = strconv.ParseUint(hard, 10, 64); err != nil {
+			return fmt.Errorf("invalid hard limit: %w", err)
+		}
+
+		// We use syscall.Setrlimit() rather than unix.Setrlimit() as the
+		// Go runtime would otherwise restore its original limit for
+		// open files in syscall.Exec()
+		if err := syscall.Setrlimit(resource, &rlim); err != nil {
+			return fmt.Errorf("failed to set limit for resource %d: %w", resource, err)
+		}
+	}
+
+	return nil
+}
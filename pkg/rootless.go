@@ -0,0 +1,101 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const rootlessEnv = "GONT_ROOTLESS"
+
+// ErrNotRootless is returned by NewNetwork() for networks with a user
+// namespace if the process has not been re-executed by ExecRootless().
+var ErrNotRootless = errors.New("networks with a user namespace require ExecRootless()")
+
+// ExecRootless re-executes the current program as an unprivileged user
+// inside a new user, mount and network namespace in which the user is
+// mapped to root. This allows creating Gont networks without privileges.
+//
+// The function returns immediately if the process is already running as
+// root or inside a namespace created by ExecRootless. Otherwise, it does not
+// return but exits with the exit code of the re-executed program.
+//
+// As the program is re-executed from its start, ExecRootless should be
+// called early in main() or TestMain() before any network is created.
+//
+// A per-network user namespace can not be created instead, as the kernel
+// only allows single-threaded processes to join a new user namespace.
+// Hence all networks of the process share the user namespace. Networks which
+// depend on it should be created with the WithUserNamespace() option.
+//
+// The following features are unavailable in rootless mode:
+//   - Links to the network namespace of the host as the host node
+//     represents a new private network namespace
+//   - Existing namespaces and Docker containers
+//   - Kernel modules can not be loaded on demand so qdiscs like
+//     netem or tbf are only available if their modules are loaded already
+//   - Network names are not visible to other processes
+//     as /var/run is replaced by a private tmpfs
+func ExecRootless() error {
+	if os.Getenv(rootlessEnv) != "" {
+		return setupRootless()
+	}
+
+	if os.Geteuid() == 0 {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), rootlessEnv+"=1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+		},
+	}
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+
+		return fmt.Errorf("failed to re-execute in user namespace: %w", err)
+	}
+
+	os.Exit(0)
+
+	return nil
+}
+
+// IsRootless returns true if the process has been re-executed by ExecRootless()
+func IsRootless() bool {
+	return os.Getenv(rootlessEnv) != ""
+}
+
+func setupRootless() error {
+	// Do not propagate mounts to the parent namespace
+	if err := syscall.Mount("none", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+
+	// The unprivileged user can not write to /var/run
+	if err := syscall.Mount("tmpfs", "/var/run", "tmpfs", 0, "mode=0755"); err != nil {
+		return fmt.Errorf("failed to mount tmpfs: %w", err)
+	}
+
+	return nil
+}
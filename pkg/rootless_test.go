@@ -0,0 +1,138 @@
+package gont_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+const rootlessTestEnv = "GONT_TEST_ROOTLESS"
+
+// TestRootless re-executes the test binary as an unprivileged user
+// and pings across a two-node topology in rootless mode
+//
+//  h1 <-> h2
+func TestRootless(t *testing.T) {
+	if os.Getenv(rootlessTestEnv) != "" {
+		testRootless(t)
+		return
+	}
+
+	// Copy the test binary to a location accessible by the unprivileged user
+	dir, err := os.MkdirTemp("", "gont-rootless")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatalf("Failed to change permissions: %s", err)
+	}
+
+	exe := filepath.Join(dir, "gont.test")
+	if err := copyExecutable(exe); err != nil {
+		t.Fatalf("Failed to copy test binary: %s", err)
+	}
+
+	cmd := exec.Command(exe, "-test.run=^TestRootless$", "-test.v")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), rootlessTestEnv+"=1")
+
+	// Drop privileges
+	if os.Geteuid() == 0 {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Credential: &syscall.Credential{
+				Uid: 65534,
+				Gid: 65534,
+			},
+		}
+	}
+
+	out, err := cmd.CombinedOutput()
+	if strings.Contains(string(out), "--- SKIP") {
+		t.Skipf("Rootless mode is not supported:\n%s", out)
+	} else if err != nil {
+		t.Fatalf("Rootless test failed: %s\n%s", err, out)
+	}
+}
+
+func testRootless(t *testing.T) {
+	if err := g.ExecRootless(); err != nil {
+		t.Skipf("Failed to create user namespace: %s", err)
+	}
+
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, append([]g.Option{o.WithUserNamespace()}, opts...)...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if _, err := h1.Ping(h2); err != nil {
+		t.Errorf("Failed to ping: %s", err)
+	}
+}
+
+// TestUserNamespaceNotRootless checks that networks with a user
+// namespace are rejected outside of ExecRootless()
+func TestUserNamespaceNotRootless(t *testing.T) {
+	if g.IsRootless() {
+		t.Skip("Process is running rootless")
+	}
+
+	if _, err := g.NewNetwork(*nname, append([]g.Option{o.WithUserNamespace()}, opts...)...); !errors.Is(err, g.ErrNotRootless) {
+		t.Fatalf("Expected error for missing user namespace, got: %v", err)
+	}
+}
+
+func copyExecutable(dst string) error {
+	src, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+
+	return err
+}
@@ -4,8 +4,36 @@ import (
 	"net"
 
 	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
+// RouteType is the type of a route which discards packets
+type RouteType int
+
+const (
+	// RouteBlackhole silently discards packets
+	RouteBlackhole RouteType = unix.RTN_BLACKHOLE
+
+	// RouteUnreachable discards packets and signals an unreachable destination
+	RouteUnreachable RouteType = unix.RTN_UNREACHABLE
+
+	// RouteProhibit discards packets and signals an administratively prohibited destination
+	RouteProhibit RouteType = unix.RTN_PROHIBIT
+)
+
+func (t RouteType) String() string {
+	switch t {
+	case RouteBlackhole:
+		return "blackhole"
+	case RouteUnreachable:
+		return "unreachable"
+	case RouteProhibit:
+		return "prohibit"
+	}
+
+	return "unknown"
+}
+
 var (
 	DefaultIPv4Mask = net.IPNet{
 		IP:   net.IPv4zero,
@@ -20,6 +48,10 @@ var (
 
 type Route struct {
 	nl.Route
+
+	// Device is the name of the outgoing interface.
+	// It is resolved to a link index by BaseNode.ImportRoutes.
+	Device string
 }
 
 func (r Route) Apply(h *Host) {
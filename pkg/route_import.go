@@ -0,0 +1,316 @@
+package gont
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	routeTypes = map[string]int{
+		"unicast":     unix.RTN_UNICAST,
+		"local":       unix.RTN_LOCAL,
+		"broadcast":   unix.RTN_BROADCAST,
+		"multicast":   unix.RTN_MULTICAST,
+		"anycast":     unix.RTN_ANYCAST,
+		"blackhole":   unix.RTN_BLACKHOLE,
+		"unreachable": unix.RTN_UNREACHABLE,
+		"prohibit":    unix.RTN_PROHIBIT,
+		"throw":       unix.RTN_THROW,
+		"nat":         unix.RTN_NAT,
+	}
+
+	routeProtocols = map[string]nl.RouteProtocol{
+		"redirect": unix.RTPROT_REDIRECT,
+		"kernel":   unix.RTPROT_KERNEL,
+		"boot":     unix.RTPROT_BOOT,
+		"static":   unix.RTPROT_STATIC,
+		"ra":       unix.RTPROT_RA,
+		"dhcp":     unix.RTPROT_DHCP,
+		"zebra":    unix.RTPROT_ZEBRA,
+		"bird":     unix.RTPROT_BIRD,
+		"babel":    unix.RTPROT_BABEL,
+		"bgp":      unix.RTPROT_BGP,
+		"isis":     unix.RTPROT_ISIS,
+		"ospf":     unix.RTPROT_OSPF,
+		"rip":      unix.RTPROT_RIP,
+		"eigrp":    unix.RTPROT_EIGRP,
+	}
+
+	routeScopes = map[string]nl.Scope{
+		"global":  nl.SCOPE_UNIVERSE,
+		"site":    nl.SCOPE_SITE,
+		"link":    nl.SCOPE_LINK,
+		"host":    nl.SCOPE_HOST,
+		"nowhere": nl.SCOPE_NOWHERE,
+	}
+
+	routeTables = map[string]int{
+		"default": unix.RT_TABLE_DEFAULT,
+		"main":    unix.RT_TABLE_MAIN,
+		"local":   unix.RT_TABLE_LOCAL,
+	}
+
+	// Keywords of "ip route" which are followed by a value we do not interpret
+	routeIgnoredKeywords = map[string]bool{
+		"pref":    true,
+		"expires": true,
+		"error":   true,
+		"uid":     true,
+	}
+
+	// Keywords of "ip route" which are not followed by a value
+	routeIgnoredFlags = map[string]bool{
+		"linkdown":  true,
+		"dead":      true,
+		"offload":   true,
+		"trap":      true,
+		"pervasive": true,
+		"notify":    true,
+		"cache":     true,
+	}
+)
+
+// ParseRoutes parses a routing table in the format of the "ip route show" command.
+//
+// Both IPv4 and IPv6 routes are supported. The address family of default
+// routes without a gateway or source address is inferred from the other
+// routes of the table, as "ip -6 route" does not include it. IPv4 is
+// assumed if the table contains only such routes or both families.
+// Multipath routes are not supported.
+//
+// The routes are returned as Route rather than nl.Route, as the latter
+// references the outgoing interface by an index which is only valid within
+// a network namespace. The name of the interface is kept in Route.Device.
+func ParseRoutes(r io.Reader) ([]Route, error) {
+	routes := []Route{}
+	families := map[int]bool{}
+	unknown := []int{} // indices of default routes of unknown family
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		route, err := parseRoute(strings.Fields(line))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse route in line %d: %w", lineNo, err)
+		}
+
+		if route.Dst == nil {
+			unknown = append(unknown, len(routes))
+		} else if route.Dst.IP.To4() != nil {
+			families[nl.FAMILY_V4] = true
+		} else {
+			families[nl.FAMILY_V6] = true
+		}
+
+		routes = append(routes, route)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Like "ip route", we fall back to IPv4 if the family is ambiguous
+	if len(unknown) > 0 {
+		dst := &DefaultIPv4Mask
+		if len(families) == 1 && families[nl.FAMILY_V6] {
+			dst = &DefaultIPv6Mask
+		}
+
+		for _, i := range unknown {
+			routes[i].Dst = dst
+		}
+	}
+
+	return routes, nil
+}
+
+func parseRoute(fields []string) (Route, error) {
+	r := Route{}
+
+	if typ, ok := routeTypes[fields[0]]; ok {
+		r.Type = typ
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 {
+		return r, fmt.Errorf("missing destination")
+	}
+
+	isDefault := false
+	if dst := fields[0]; dst == "default" {
+		isDefault = true
+	} else if dst == "nexthop" {
+		return r, fmt.Errorf("multipath routes are not supported")
+	} else {
+		if !strings.Contains(dst, "/") {
+			if strings.Contains(dst, ":") {
+				dst += "/128"
+			} else {
+				dst += "/32"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(dst)
+		if err != nil {
+			return r, fmt.Errorf("invalid destination: %w", err)
+		}
+
+		r.Dst = n
+	}
+
+	var err error
+	for i := 1; i < len(fields); i++ {
+		key := fields[i]
+
+		if key == "onlink" {
+			r.Flags |= int(nl.FLAG_ONLINK)
+			continue
+		} else if routeIgnoredFlags[key] {
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			return r, fmt.Errorf("missing value for keyword: %s", key)
+		}
+
+		i++
+		value := fields[i]
+
+		switch key {
+		case "via":
+			// Skip optional address family
+			if value == "inet" || value == "inet6" {
+				if i+1 >= len(fields) {
+					return r, fmt.Errorf("missing value for keyword: %s", key)
+				}
+
+				i++
+				value = fields[i]
+			}
+
+			if r.Gw = net.ParseIP(value); r.Gw == nil {
+				return r, fmt.Errorf("invalid gateway: %s", value)
+			}
+
+		case "dev":
+			r.Device = value
+
+		case "src":
+			if r.Src = net.ParseIP(value); r.Src == nil {
+				return r, fmt.Errorf("invalid source address: %s", value)
+			}
+
+		case "proto":
+			if proto, ok := routeProtocols[value]; ok {
+				r.Protocol = proto
+			} else if proto, err := strconv.Atoi(value); err == nil {
+				r.Protocol = nl.RouteProtocol(proto)
+			} else {
+				return r, fmt.Errorf("invalid protocol: %s", value)
+			}
+
+		case "scope":
+			if scope, ok := routeScopes[value]; ok {
+				r.Scope = scope
+			} else if scope, err := strconv.ParseUint(value, 10, 8); err == nil {
+				r.Scope = nl.Scope(scope)
+			} else {
+				return r, fmt.Errorf("invalid scope: %s", value)
+			}
+
+		case "table":
+			if table, ok := routeTables[value]; ok {
+				r.Table = table
+			} else if r.Table, err = strconv.Atoi(value); err != nil {
+				return r, fmt.Errorf("invalid table: %s", value)
+			}
+
+		case "metric":
+			if r.Priority, err = strconv.Atoi(value); err != nil {
+				return r, fmt.Errorf("invalid metric: %s", value)
+			}
+
+		case "mtu":
+			if r.MTU, err = strconv.Atoi(value); err != nil {
+				return r, fmt.Errorf("invalid MTU: %s", value)
+			}
+
+		case "advmss":
+			if r.AdvMSS, err = strconv.Atoi(value); err != nil {
+				return r, fmt.Errorf("invalid advmss: %s", value)
+			}
+
+		case "realm":
+			if r.Realm, err = strconv.Atoi(value); err != nil {
+				return r, fmt.Errorf("invalid realm: %s", value)
+			}
+
+		case "hoplimit":
+			if r.Hoplimit, err = strconv.Atoi(value); err != nil {
+				return r, fmt.Errorf("invalid hoplimit: %s", value)
+			}
+
+		default:
+			if !routeIgnoredKeywords[key] {
+				return r, fmt.Errorf("unsupported keyword: %s", key)
+			}
+		}
+	}
+
+	// The address family of default routes without
+	// gateway and source address is inferred by ParseRoutes()
+	if isDefault {
+		ip := r.Gw
+		if ip == nil {
+			ip = r.Src
+		}
+
+		if ip == nil {
+			return r, nil
+		} else if ip.To4() != nil {
+			r.Dst = &DefaultIPv4Mask
+		} else {
+			r.Dst = &DefaultIPv6Mask
+		}
+	}
+
+	return r, nil
+}
+
+// ImportRoutes parses a routing table in the format of the
+// "ip route show" command and installs the routes in the node.
+func (n *BaseNode) ImportRoutes(r io.Reader) error {
+	routes, err := ParseRoutes(r)
+	if err != nil {
+		return err
+	}
+
+	nlRoutes := []*nl.Route{}
+	for _, route := range routes {
+		nlRoute := route.Route
+
+		if route.Device != "" {
+			link, err := n.nlHandle.LinkByName(route.Device)
+			if err != nil {
+				return fmt.Errorf("failed to find interface %s: %w", route.Device, err)
+			}
+
+			nlRoute.LinkIndex = link.Attrs().Index
+		}
+
+		nlRoutes = append(nlRoutes, &nlRoute)
+	}
+
+	return n.AddRoutes(nlRoutes...)
+}
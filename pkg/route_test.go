@@ -0,0 +1,614 @@
+package gont_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-ping/ping"
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const numRoutes = 1000
+
+func prepareRoutes(t testing.TB) (*g.Network, *g.Host, []*nl.Route) {
+	n, err := g.NewNetwork(*nname, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+
+	h1, err := n.AddHost("h1")
+	if err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	h2, err := n.AddHost("h2")
+	if err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	routes := []*nl.Route{}
+	for i := 0; i < numRoutes; i++ {
+		_, dst, _ := net.ParseCIDR(fmt.Sprintf("10.%d.%d.0/24", 1+i/250, i%250))
+
+		routes = append(routes, &nl.Route{
+			Dst: dst,
+			Gw:  net.IPv4(10, 0, 0, 2),
+		})
+	}
+
+	return n, h1, routes
+}
+
+func TestAddRoutes(t *testing.T) {
+	n, h1, routes := prepareRoutes(t)
+	defer n.Close()
+
+	if err := h1.AddRoutes(routes...); err != nil {
+		t.Fatalf("Failed to add routes: %s", err)
+	}
+
+	installed, err := h1.NetlinkHandle().RouteList(nil, nl.FAMILY_V4)
+	if err != nil {
+		t.Fatalf("Failed to list routes: %s", err)
+	}
+
+	found := 0
+	for _, r := range installed {
+		if r.Gw.Equal(net.IPv4(10, 0, 0, 2)) {
+			found++
+		}
+	}
+
+	if found != numRoutes {
+		t.Errorf("Found %d instead of %d routes", found, numRoutes)
+	}
+
+	// Adding the same routes again must fail for each of them
+	if err := h1.AddRoutes(routes[:2]...); err == nil {
+		t.Error("Adding duplicate routes did not fail")
+	} else {
+		t.Logf("Got expected error: %s", err)
+	}
+}
+
+func BenchmarkAddRoutes(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		n, h1, routes := prepareRoutes(b)
+		b.StartTimer()
+
+		if err := h1.AddRoutes(routes...); err != nil {
+			b.Fatalf("Failed to add routes: %s", err)
+		}
+
+		b.StopTimer()
+		n.Close()
+	}
+}
+
+func BenchmarkAddRoute(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		n, h1, routes := prepareRoutes(b)
+		b.StartTimer()
+
+		for _, r := range routes {
+			if err := h1.AddRoute(r); err != nil {
+				b.Fatalf("Failed to add route: %s", err)
+			}
+		}
+
+		b.StopTimer()
+		n.Close()
+	}
+}
+
+var routeDump = `default via 10.0.0.254 dev veth0 proto static metric 100
+10.0.1.0/24 dev veth0 proto kernel scope link
+192.168.0.0/16 via 10.0.0.2 dev veth0
+10.0.2.7 via 10.0.0.2 dev veth0 onlink
+blackhole 10.99.0.0/16
+fc00:1::/64 via fc::2 dev veth0 metric 1024 pref medium
+default via fc::254 dev veth0 metric 1024 pref medium
+`
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := g.ParseRoutes(strings.NewReader(routeDump))
+	if err != nil {
+		t.Fatalf("Failed to parse routes: %s", err)
+	}
+
+	if len(routes) != 7 {
+		t.Fatalf("Parsed %d instead of 7 routes", len(routes))
+	}
+
+	if r := routes[0]; r.Dst.String() != "0.0.0.0/0" || !r.Gw.Equal(net.IPv4(10, 0, 0, 254)) || r.Device != "veth0" || r.Priority != 100 || r.Protocol != unix.RTPROT_STATIC {
+		t.Errorf("Invalid default route: %+v", r)
+	}
+
+	if r := routes[1]; r.Dst.String() != "10.0.1.0/24" || r.Gw != nil || r.Scope != nl.SCOPE_LINK || r.Protocol != unix.RTPROT_KERNEL {
+		t.Errorf("Invalid connected route: %+v", r)
+	}
+
+	if r := routes[3]; r.Dst.String() != "10.0.2.7/32" || r.Flags&int(nl.FLAG_ONLINK) == 0 {
+		t.Errorf("Invalid host route: %+v", r)
+	}
+
+	if r := routes[4]; r.Dst.String() != "10.99.0.0/16" || r.Type != unix.RTN_BLACKHOLE {
+		t.Errorf("Invalid blackhole route: %+v", r)
+	}
+
+	if r := routes[6]; r.Dst.String() != "::/0" || !r.Gw.Equal(net.ParseIP("fc::254")) {
+		t.Errorf("Invalid IPv6 default route: %+v", r)
+	}
+
+	if _, err := g.ParseRoutes(strings.NewReader("10.0.0.0/8 via 10.0.0.1 foo bar")); err == nil {
+		t.Error("Parsing an invalid route did not fail")
+	}
+
+	// Default routes without gateway in the output of "ip -6 route"
+	routes, err = g.ParseRoutes(strings.NewReader("fc00:1::/64 dev veth0 proto kernel metric 256\ndefault dev veth0 metric 1024\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse routes: %s", err)
+	}
+
+	if r := routes[1]; r.Dst.String() != "::/0" || r.Device != "veth0" {
+		t.Errorf("Invalid IPv6 default route without gateway: %+v", r)
+	}
+
+	// Without other routes, the output of "ip route" is assumed
+	routes, err = g.ParseRoutes(strings.NewReader("default dev wg0\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse routes: %s", err)
+	}
+
+	if r := routes[0]; r.Dst.String() != "0.0.0.0/0" || r.Device != "wg0" {
+		t.Errorf("Invalid IPv4 default route without gateway: %+v", r)
+	}
+}
+
+func TestImportRoutes(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24),
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24),
+			o.AddressIP("fc::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if err := h1.ImportRoutes(strings.NewReader(routeDump)); err != nil {
+		t.Fatalf("Failed to import routes: %s", err)
+	}
+
+	for _, dst := range []string{"192.168.1.1", "10.0.1.1", "fc00:1::1", "fd00::1"} {
+		routes, err := h1.NetlinkHandle().RouteGet(net.ParseIP(dst))
+		if err != nil {
+			t.Errorf("Failed to get route for %s: %s", dst, err)
+			continue
+		}
+
+		if len(routes) != 1 || routes[0].LinkIndex != h1.Interface("veth0").Link.Attrs().Index {
+			t.Errorf("Invalid route for %s: %v", dst, routes)
+		}
+	}
+
+	if _, err := h1.NetlinkHandle().RouteGet(net.IPv4(10, 99, 1, 1)); err == nil {
+		t.Error("Blackhole route has not been installed")
+	}
+}
+
+func TestAddBlackholeRoute(t *testing.T) {
+	n, h1, _ := prepareRoutes(t)
+	defer n.Close()
+
+	if err := h1.LinkAddAddress("veth0", net.IPNet{
+		IP:   net.ParseIP("fc::1"),
+		Mask: net.CIDRMask(64, 128),
+	}); err != nil {
+		t.Fatalf("Failed to add address: %s", err)
+	}
+
+	for _, tc := range []struct {
+		dst      string
+		typ      g.RouteType
+		expected error
+	}{
+		{"10.1.0.0/16", g.RouteBlackhole, unix.EINVAL},
+		{"10.2.0.0/16", g.RouteUnreachable, unix.EHOSTUNREACH},
+		{"10.3.0.0/16", g.RouteProhibit, unix.EACCES},
+		{"fc:1::/64", g.RouteBlackhole, unix.EINVAL},
+		{"fc:2::/64", g.RouteUnreachable, unix.EHOSTUNREACH},
+		{"fc:3::/64", g.RouteProhibit, unix.EACCES},
+	} {
+		_, dst, _ := net.ParseCIDR(tc.dst)
+
+		if err := h1.AddBlackholeRoute(*dst, tc.typ); err != nil {
+			t.Fatalf("Failed to add %s route: %s", tc.typ, err)
+		}
+
+		dst.IP[len(dst.IP)-1] = 1
+		addr := net.JoinHostPort(dst.IP.String(), "80")
+
+		if _, err := h1.Dial("tcp", addr); !errors.Is(err, tc.expected) {
+			t.Errorf("Unexpected error for %s route to %s: %v", tc.typ, addr, err)
+		}
+	}
+
+	if err := h1.AddBlackholeRoute(net.IPNet{}, g.RouteType(unix.RTN_UNICAST)); err == nil {
+		t.Errorf("Added blackhole route with invalid type")
+	}
+}
+
+// TestReplaceDefaultRoute switches the default gateway of h1
+// from r1 to r2 while pinging h2
+//
+//       /- r1 -\
+//  h1 -+        +- h2
+//       \- r2 -/
+func TestReplaceDefaultRoute(t *testing.T) {
+	var (
+		err      error
+		n        *g.Network
+		sw1, sw2 *g.Switch
+		h1, h2   *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if sw1, err = n.AddSwitch("sw1"); err != nil {
+		t.Fatalf("Failed to add switch: %s", err)
+	}
+
+	if sw2, err = n.AddSwitch("sw2"); err != nil {
+		t.Fatalf("Failed to add switch: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1",
+		o.DefaultGatewayIPv4(10, 0, 1, 1),
+		o.Interface("veth0", sw1,
+			o.AddressIPv4(10, 0, 1, 10, 24),
+			o.AddressIP("fc:1::10/64")),
+	); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2",
+		o.DefaultGatewayIPv4(10, 0, 2, 1),
+		o.Interface("veth0", sw2,
+			o.AddressIPv4(10, 0, 2, 10, 24)),
+	); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if _, err := n.AddRouter(fmt.Sprintf("r%d", i),
+			o.Interface("veth0", sw1,
+				o.AddressIPv4(10, 0, 1, byte(i), 24),
+				o.AddressIP(fmt.Sprintf("fc:1::%d/64", i))),
+			o.Interface("veth1", sw2,
+				o.AddressIPv4(10, 0, 2, byte(i), 24)),
+		); err != nil {
+			t.Fatalf("Failed to add router: %s", err)
+		}
+	}
+
+	if err := h1.ReplaceDefaultRoute(net.IPv4(10, 0, 3, 1)); err == nil {
+		t.Errorf("Replaced default route with unreachable gateway")
+	}
+
+	type result struct {
+		stats *ping.Statistics
+		err   error
+	}
+
+	const count = 100
+
+	results := make(chan result)
+	go func() {
+		stats, err := h1.PingWithOptions(h2, "ip", count, 5*time.Second, 10*time.Millisecond, false)
+		results <- result{stats, err}
+	}()
+
+	time.Sleep(count / 2 * 10 * time.Millisecond)
+
+	if err := h1.ReplaceDefaultRoute(net.IPv4(10, 0, 1, 2)); err != nil {
+		t.Fatalf("Failed to replace default route: %s", err)
+	}
+
+	if err := h1.ReplaceDefaultRoute(net.ParseIP("fc:1::2")); err != nil {
+		t.Fatalf("Failed to replace default route: %s", err)
+	}
+
+	r := <-results
+	if r.err != nil {
+		t.Fatalf("Failed to ping: %s", r.err)
+	}
+
+	if lost := r.stats.PacketsSent - r.stats.PacketsRecv; lost > 1 {
+		t.Errorf("Lost %d packets while replacing the default route", lost)
+	}
+
+	for _, family := range []int{nl.FAMILY_V4, nl.FAMILY_V6} {
+		routes, err := h1.NetlinkHandle().RouteListFiltered(family, &nl.Route{
+			Table: unix.RT_TABLE_MAIN,
+		}, nl.RT_FILTER_TABLE)
+		if err != nil {
+			t.Fatalf("Failed to list routes: %s", err)
+		}
+
+		defaults := []net.IP{}
+		for _, route := range routes {
+			if route.Dst == nil || route.Dst.IP.IsUnspecified() {
+				defaults = append(defaults, route.Gw)
+			}
+		}
+
+		if len(defaults) != 1 || defaults[0][len(defaults[0])-1] != 2 {
+			t.Errorf("Unexpected default routes: %v", defaults)
+		}
+	}
+}
+
+// Deprecated addresses are avoided as source addresses (RFC 6724 rule 3)
+// even though they would be preferred by the longest matching prefix (rule 8).
+func TestSourceAddressDeprecated(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIP("fc::1/64")),
+		o.Interface("veth0", h2,
+			o.AddressIP("fc::2/64")),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	if err := h1.LinkAddAddress("veth0", net.IPNet(o.AddressIP("fc::3/64")), o.WithCgroup("/")); err == nil {
+		t.Error("Accepted invalid address option")
+	}
+
+	if err := h1.LinkAddAddress("veth0", net.IPNet(o.AddressIP("fc::3/64")),
+		o.WithDeprecated(),
+		o.WithNoDAD(),
+	); err != nil {
+		t.Fatalf("Failed to add address: %s", err)
+	}
+
+	addrs, err := h1.NetlinkHandle().AddrList(h1.Interface("veth0").Link, nl.FAMILY_V6)
+	if err != nil {
+		t.Fatalf("Failed to list addresses: %s", err)
+	}
+
+	found := false
+	for _, a := range addrs {
+		if !a.IP.Equal(net.ParseIP("fc::3")) {
+			continue
+		}
+
+		found = true
+
+		if a.Flags&unix.IFA_F_DEPRECATED == 0 || a.Flags&unix.IFA_F_NODAD == 0 {
+			t.Errorf("Invalid address flags: %#x", a.Flags)
+		}
+
+		if a.PreferedLft != 0 {
+			t.Errorf("Invalid preferred lifetime: %d", a.PreferedLft)
+		}
+	}
+
+	if !found {
+		t.Fatal("Address has not been added")
+	}
+
+	var src net.IP
+	if err := h1.RunFunc(func() error {
+		conn, err := net.Dial("udp", "[fc::2]:9")
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		src = conn.LocalAddr().(*net.UDPAddr).IP
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+
+	if !src.Equal(net.ParseIP("fc::1")) {
+		t.Errorf("Deprecated address has been chosen as source: %s", src)
+	}
+}
+
+// TestRouteAttributes installs two routes from h1 to h2 with different metrics
+// and a host route with a reduced MTU
+//
+//       /- r1 -\
+//  h1 -+        +- h2
+//       \- r2 -/
+func TestRouteAttributes(t *testing.T) {
+	var (
+		err      error
+		n        *g.Network
+		sw1, sw2 *g.Switch
+		h1, h2   *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if sw1, err = n.AddSwitch("sw1"); err != nil {
+		t.Fatalf("Failed to add switch: %s", err)
+	}
+
+	if sw2, err = n.AddSwitch("sw2"); err != nil {
+		t.Fatalf("Failed to add switch: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1",
+		o.Interface("veth0", sw1,
+			o.AddressIPv4(10, 0, 1, 10, 24)),
+	); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2",
+		o.DefaultGatewayIPv4(10, 0, 2, 1),
+		o.Interface("veth0", sw2,
+			o.AddressIPv4(10, 0, 2, 10, 24)),
+	); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if _, err := n.AddRouter(fmt.Sprintf("r%d", i),
+			o.Interface("veth0", sw1,
+				o.AddressIPv4(10, 0, 1, byte(i), 24)),
+			o.Interface("veth1", sw2,
+				o.AddressIPv4(10, 0, 2, byte(i), 24)),
+		); err != nil {
+			t.Fatalf("Failed to add router: %s", err)
+		}
+	}
+
+	_, dst, _ := net.ParseCIDR("10.0.2.0/24")
+
+	if err := h1.AddRouteWithMetric(*dst, net.IPv4(10, 0, 1, 1), 200); err != nil {
+		t.Fatalf("Failed to add route: %s", err)
+	}
+
+	if err := h1.AddRouteWithMetric(*dst, net.IPv4(10, 0, 1, 2), 100); err != nil {
+		t.Fatalf("Failed to add route: %s", err)
+	}
+
+	routes, err := h1.NetlinkHandle().RouteGet(net.IPv4(10, 0, 2, 10))
+	if err != nil {
+		t.Fatalf("Failed to get route: %s", err)
+	}
+
+	if len(routes) != 1 || !routes[0].Gw.Equal(net.IPv4(10, 0, 1, 2)) {
+		t.Errorf("Route with lower metric is not preferred: %v", routes)
+	}
+
+	if _, err := h1.Ping(h2); err != nil {
+		t.Errorf("Failed to ping: %s", err)
+	}
+
+	if err := h1.AddRoute(&nl.Route{
+		Dst: &net.IPNet{
+			IP:   net.IPv4(10, 0, 2, 10),
+			Mask: net.CIDRMask(32, 32),
+		},
+		Gw:     net.IPv4(10, 0, 1, 1),
+		MTU:    1280,
+		AdvMSS: 1200,
+	}); err != nil {
+		t.Fatalf("Failed to add route: %s", err)
+	}
+
+	routes, err = h1.NetlinkHandle().RouteGet(net.IPv4(10, 0, 2, 10))
+	if err != nil {
+		t.Fatalf("Failed to get route: %s", err)
+	}
+
+	if len(routes) != 1 || routes[0].MTU != 1280 || routes[0].AdvMSS != 1200 {
+		t.Errorf("Invalid route attributes: %v", routes)
+	}
+
+	// The route MTU is the path MTU for datagrams which must not be fragmented
+	if err := h1.RunFunc(func() error {
+		fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+		if err != nil {
+			return err
+		}
+		defer unix.Close(fd)
+
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_DO); err != nil {
+			return err
+		}
+
+		if err := unix.Connect(fd, &unix.SockaddrInet4{
+			Addr: [4]byte{10, 0, 2, 10},
+			Port: 9,
+		}); err != nil {
+			return err
+		}
+
+		if mtu, err := unix.GetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_MTU); err != nil {
+			return err
+		} else if mtu != 1280 {
+			t.Errorf("Invalid path MTU: %d", mtu)
+		}
+
+		if err := unix.Send(fd, make([]byte, 1400), 0); !errors.Is(err, unix.EMSGSIZE) {
+			t.Errorf("Datagram exceeding the path MTU was not rejected: %v", err)
+		}
+
+		return unix.Send(fd, make([]byte, 1200), 0)
+	}); err != nil {
+		t.Errorf("Failed to check path MTU: %s", err)
+	}
+
+	if err := h1.AddRouteWithMetric(*dst, nil, -1); err == nil {
+		t.Error("Added route with negative metric")
+	}
+}
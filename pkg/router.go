@@ -9,7 +9,15 @@ func (h *Router) Apply(i *Interface) {
 }
 
 func (n *Network) AddRouter(name string, opts ...Option) (*Router, error) {
-	host, err := n.AddHost(name, opts...)
+	if err := checkOptions(opts, targetNode, targetHost); err != nil {
+		return nil, err
+	}
+
+	return n.addRouter(name, opts)
+}
+
+func (n *Network) addRouter(name string, opts []Option) (*Router, error) {
+	host, err := n.addHost(name, opts)
 	if err != nil {
 		return nil, err
 	}
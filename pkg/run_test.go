@@ -1,14 +1,26 @@
 package gont_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
 	"github.com/vishvananda/netns"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/sys/unix"
 )
 
+const rlimitHelperEnv = "GONT_TEST_RLIMIT_HELPER"
+
 func prepare(t *testing.T) (*g.Network, *g.BaseNode) {
 	n, err := g.NewNetwork(*nname, opts...)
 	if err != nil {
@@ -134,3 +146,160 @@ func TestStart(t *testing.T) {
 		t.Errorf("Got invalid namespace: %s", string(out))
 	}
 }
+
+func TestRunLogged(t *testing.T) {
+	n, n1 := prepare(t)
+	defer n.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+
+	if _, err := n1.RunLogged(context.Background(), logger, "sh", "-c", "for i in 1 2 3; do echo line$i; sleep 0.2; done; echo failed >&2"); err != nil {
+		t.Fatalf("Failed to run: %s", err)
+	}
+
+	lines := map[string]observer.LoggedEntry{}
+	for _, e := range logs.All() {
+		lines[e.Message] = e
+
+		fields := e.ContextMap()
+		if fields["node"] != "n1" || fields["cmd"] != "sh" {
+			t.Errorf("Missing fields: %v", fields)
+		}
+	}
+
+	for _, line := range []string{"line1", "line2", "line3", "failed"} {
+		if _, ok := lines[line]; !ok {
+			t.Fatalf("Missing line %s", line)
+		}
+	}
+
+	// Lines must be forwarded while the command is still running
+	if d := lines["line3"].Time.Sub(lines["line1"].Time); d < 300*time.Millisecond {
+		t.Errorf("Lines have not been logged incrementally: %s", d)
+	}
+
+	if lvl := lines["line1"].Level; lvl != zap.InfoLevel {
+		t.Errorf("Invalid level for standard output: %s", lvl)
+	}
+
+	if lvl := lines["failed"].Level; lvl != zap.WarnLevel {
+		t.Errorf("Invalid level for standard error: %s", lvl)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := n1.RunLogged(ctx, logger, "sleep", 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline to be exceeded: %v", err)
+	}
+
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Command has not been killed: %s", d)
+	}
+}
+
+func TestRunHostname(t *testing.T) {
+	n, err := g.NewNetwork(*nname, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create new network: %s", err)
+	}
+	defer n.Close()
+
+	n1, err := n.AddNode("n1", o.WithHostname("db-primary"))
+	if err != nil {
+		t.Fatalf("Failed to create node: %s", err)
+	}
+
+	n2, err := n.AddNode("n2")
+	if err != nil {
+		t.Fatalf("Failed to create node: %s", err)
+	}
+
+	for node, expected := range map[*g.BaseNode]string{
+		n1: "db-primary",
+		n2: fmt.Sprintf("n2.%s.gont", n.Name),
+	} {
+		out, _, err := node.Run("hostname")
+		if err != nil {
+			t.Fatalf("Failed to run hostname: %s", err)
+		}
+
+		if hostname := strings.TrimSpace(string(out)); hostname != expected {
+			t.Errorf("Unexpected hostname of node %s: %s != %s", node, hostname, expected)
+		}
+	}
+}
+
+// TestRlimitHelper is not a real test but is executed
+// by TestRunRlimit within a node to exhaust its open files
+func TestRlimitHelper(t *testing.T) {
+	if os.Getenv(rlimitHelperEnv) == "" {
+		t.Skip("Only used as a helper process")
+	}
+
+	for {
+		if _, err := os.Open("/dev/null"); err != nil {
+			fmt.Printf("open=%v\n", err)
+			return
+		}
+	}
+}
+
+func TestRunRlimit(t *testing.T) {
+	n, n1 := prepare(t)
+	defer n.Close()
+
+	out, _, err := n1.Run("/proc/self/exe", "-test.run=^TestRlimitHelper$",
+		o.WithEnv(map[string]string{rlimitHelperEnv: "1"}),
+		o.WithRlimit(unix.RLIMIT_NOFILE, 16, 16))
+	if err != nil {
+		t.Fatalf("Failed to run helper: %s\n%s", err, out)
+	}
+
+	if !strings.Contains(string(out), unix.EMFILE.Error()) {
+		t.Errorf("Helper did not hit the limit:\n%s", out)
+	}
+
+	out, _, err = n1.Run("sh", "-c", "ulimit -Sn; ulimit -Hn",
+		o.WithRlimit(unix.RLIMIT_NOFILE, 32, 64))
+	if err != nil {
+		t.Fatalf("Failed to run ulimit: %s", err)
+	}
+
+	if string(out) != "32\n64\n" {
+		t.Errorf("Unexpected limits:\n%s", out)
+	}
+
+	if _, _, err := n.HostNode.Run("true", o.WithRlimit(unix.RLIMIT_NOFILE, 16, 16)); err == nil {
+		t.Error("Limits have been accepted for the host node")
+	}
+}
+
+func TestRunPath(t *testing.T) {
+	n, n1 := prepare(t)
+	defer n.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "gont-hello"), []byte("#!/bin/sh\necho \"hello $PATH\"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %s", err)
+	}
+
+	path := dir + ":/bin:/usr/bin"
+
+	for _, node := range []*g.BaseNode{n1, n.HostNode.BaseNode} {
+		out, _, err := node.Run("gont-hello", o.WithPath(path))
+		if err != nil {
+			t.Fatalf("Failed to run script in node %s: %s", node, err)
+		}
+
+		if string(out) != "hello "+path+"\n" {
+			t.Errorf("Unexpected output in node %s: %s", node, out)
+		}
+	}
+
+	if _, _, err := n1.Run("gont-hello"); err == nil {
+		t.Error("Script has been found without custom path")
+	}
+}
@@ -0,0 +1,155 @@
+package gont
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Not yet defined by golang.org/x/sys/unix
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetKillThread  = 0x00000000
+	seccompRetTrap        = 0x00030000
+	seccompRetErrno       = 0x00050000
+	seccompRetLog         = 0x7ffc0000
+	seccompRetAllow       = 0x7fff0000
+	seccompRetData        = 0x0000ffff
+
+	// Offsets of fields in struct seccomp_data
+	seccompDataNr   = 0
+	seccompDataArch = 4
+
+	// System calls of the x32 ABI
+	x32SyscallBit = 0x40000000
+)
+
+// See: include/uapi/linux/audit.h
+var auditArches = map[string]uint32{
+	"386":     0x40000003,
+	"amd64":   0xc000003e,
+	"arm":     0x40000028,
+	"arm64":   0xc00000b7,
+	"ppc64le": 0xc0000015,
+	"riscv64": 0xc00000f3,
+	"s390x":   0x80000016,
+}
+
+// SeccompAction is the action taken by the kernel
+// when a process invokes a filtered system call.
+type SeccompAction uint32
+
+const (
+	SeccompActionAllow       SeccompAction = seccompRetAllow
+	SeccompActionLog         SeccompAction = seccompRetLog
+	SeccompActionTrap        SeccompAction = seccompRetTrap
+	SeccompActionKillThread  SeccompAction = seccompRetKillThread
+	SeccompActionKillProcess SeccompAction = seccompRetKillProcess
+)
+
+// SeccompActionErrno fails filtered system calls with errno
+// instead of executing them.
+func SeccompActionErrno(errno unix.Errno) SeccompAction {
+	return SeccompAction(seccompRetErrno | uint32(errno)&seccompRetData)
+}
+
+// SeccompRule applies an action to a set of system calls.
+// System calls are identified by their numbers like unix.SYS_MKDIRAT.
+type SeccompRule struct {
+	Syscalls []uintptr     `json:"syscalls"`
+	Action   SeccompAction `json:"action"`
+}
+
+// SeccompProfile restricts the system calls which processes started
+// in a node are allowed to invoke.
+//
+// The first rule matching a system call determines the action.
+// All other system calls are subject to the DefaultAction.
+// Invocations of system calls of a foreign architecture kill the process.
+type SeccompProfile struct {
+	DefaultAction SeccompAction `json:"default_action"`
+	Rules         []SeccompRule `json:"rules"`
+}
+
+// compile translates the profile into a classic BPF program
+// as expected by the kernel.
+func (p *SeccompProfile) compile() ([]unix.SockFilter, error) {
+	arch, ok := auditArches[runtime.GOARCH]
+	if !ok {
+		return nil, fmt.Errorf("unsupported architecture for seccomp: %s", runtime.GOARCH)
+	}
+
+	stmt := func(code uint16, k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: code, K: k}
+	}
+
+	jump := func(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+
+	prog := []unix.SockFilter{
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArch),
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, arch, 1, 0),
+		stmt(unix.BPF_RET|unix.BPF_K, seccompRetKillProcess),
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNr),
+	}
+
+	// The x32 ABI shares the architecture identifier with amd64
+	if runtime.GOARCH == "amd64" {
+		prog = append(prog,
+			jump(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, x32SyscallBit, 0, 1),
+			stmt(unix.BPF_RET|unix.BPF_K, seccompRetKillProcess),
+		)
+	}
+
+	for _, rule := range p.Rules {
+		for _, nr := range rule.Syscalls {
+			prog = append(prog,
+				jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, uint32(nr), 0, 1),
+				stmt(unix.BPF_RET|unix.BPF_K, uint32(rule.Action)),
+			)
+		}
+	}
+
+	prog = append(prog, stmt(unix.BPF_RET|unix.BPF_K, uint32(p.DefaultAction)))
+
+	if len(prog) > unix.BPF_MAXINSNS {
+		return nil, fmt.Errorf("seccomp profile is too large: %d instructions", len(prog))
+	}
+
+	return prog, nil
+}
+
+// installSeccompProfile installs the JSON encoded profile for the calling thread.
+// The filter is inherited by the program executed via execve().
+func installSeccompProfile(encoded string) error {
+	var p SeccompProfile
+	if err := json.Unmarshal([]byte(encoded), &p); err != nil {
+		return fmt.Errorf("invalid seccomp profile: %w", err)
+	}
+
+	prog, err := p.compile()
+	if err != nil {
+		return err
+	}
+
+	// Required for installing filters without CAP_SYS_ADMIN
+	// and to prevent gaining privileges via setuid binaries
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+
+	fprog := unix.SockFprog{
+		Len:    uint16(len(prog)),
+		Filter: &prog[0],
+	}
+
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&fprog)), 0, 0); err != nil {
+		return fmt.Errorf("failed to install seccomp filter: %w", err)
+	}
+
+	return nil
+}
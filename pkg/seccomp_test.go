@@ -0,0 +1,101 @@
+package gont_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+const seccompHelperEnv = "GONT_TEST_SECCOMP_HELPER"
+
+// TestSeccompHelper is not a real test but is executed
+// by TestSeccompProfile within a node to invoke filtered system calls
+func TestSeccompHelper(t *testing.T) {
+	switch os.Getenv(seccompHelperEnv) {
+	case "errno":
+		_, err := unix.Getpriority(unix.PRIO_PROCESS, 0)
+		fmt.Printf("getpriority=%v\n", err)
+	case "kill":
+		unix.Sync()
+		fmt.Println("survived")
+	default:
+		t.Skip("Only used as a helper process")
+	}
+}
+
+func TestSeccompProfile(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1", o.WithSeccompProfile(&g.SeccompProfile{
+		DefaultAction: g.SeccompActionAllow,
+		Rules: []g.SeccompRule{
+			{
+				Syscalls: []uintptr{unix.SYS_GETPRIORITY},
+				Action:   g.SeccompActionErrno(unix.EPERM),
+			},
+			{
+				Syscalls: []uintptr{unix.SYS_SYNC},
+				Action:   g.SeccompActionKillProcess,
+			},
+		},
+	})); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	c := h1.Command("/proc/self/exe", "-test.run=^TestSeccompHelper$")
+	c.Env = append(c.Env, seccompHelperEnv+"=errno")
+
+	out, err := c.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run helper: %s\n%s", err, out)
+	}
+
+	if !strings.Contains(string(out), "getpriority="+unix.EPERM.Error()) {
+		t.Errorf("System call has not been denied:\n%s", out)
+	}
+
+	c = h1.Command("/proc/self/exe", "-test.run=^TestSeccompHelper$")
+	c.Env = append(c.Env, seccompHelperEnv+"=kill")
+
+	out, err = c.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Helper has not been killed: %v\n%s", err, out)
+	}
+
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); !ok || !ws.Signaled() || ws.Signal() != unix.SIGSYS {
+		t.Errorf("Helper has not been killed by SIGSYS: %s", err)
+	}
+
+	if strings.Contains(string(out), "survived") {
+		t.Errorf("Helper survived filtered system call:\n%s", out)
+	}
+
+	// Processes of other nodes are not affected
+	c = n.HostNode.Command("/proc/self/exe", "-test.run=^TestSeccompHelper$")
+	c.Env = append(os.Environ(), seccompHelperEnv+"=errno")
+
+	if out, err = c.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run helper: %s\n%s", err, out)
+	} else if !strings.Contains(string(out), "getpriority=<nil>") {
+		t.Errorf("System call has been denied:\n%s", out)
+	}
+}
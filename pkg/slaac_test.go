@@ -0,0 +1,202 @@
+package gont_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+// sendRouterAdvertisement announces an autonomous /64 prefix
+// to all nodes attached to the interface iface of the router
+func sendRouterAdvertisement(t *testing.T, r *g.Router, iface string, prefix net.IP) {
+	intf := r.Interface(iface)
+
+	// Type, code, checksum (filled by kernel), hop limit, flags,
+	// router lifetime, reachable time and retransmit timer
+	ra := []byte{134, 0, 0, 0, 64, 0, 0x07, 0x08, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	// Prefix information option with on-link and autonomous flags
+	// as well as valid and preferred lifetimes of one hour
+	ra = append(ra, 3, 4, 64, 0xc0, 0, 0, 0x0e, 0x10, 0, 0, 0x0e, 0x10, 0, 0, 0, 0)
+	ra = append(ra, prefix.To16()...)
+
+	if err := r.RunFunc(func() error {
+		fd, err := unix.Socket(unix.AF_INET6, unix.SOCK_RAW, unix.IPPROTO_ICMPV6)
+		if err != nil {
+			return err
+		}
+		defer unix.Close(fd)
+
+		// Hosts only accept advertisements which have not been forwarded
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_MULTICAST_HOPS, 255); err != nil {
+			return err
+		}
+
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_MULTICAST_IF, intf.Link.Attrs().Index); err != nil {
+			return err
+		}
+
+		sa := &unix.SockaddrInet6{}
+		copy(sa.Addr[:], net.IPv6linklocalallnodes)
+
+		return unix.Sendto(fd, ra, 0, sa)
+	}); err != nil {
+		t.Fatalf("Failed to send router advertisement: %s", err)
+	}
+}
+
+//  r1 <-> h1
+func TestWaitForGlobalAddress(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		r1  *g.Router
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if r1, err = n.AddRouter("r1"); err != nil {
+		t.Fatalf("Failed to create router: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", r1,
+			o.AddressIP("2001:db8:1::1/64")),
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect nodes: %s", err)
+	}
+
+	i := h1.Interface("veth0")
+
+	addrs, err := i.GlobalAddresses()
+	if err != nil {
+		t.Fatalf("Failed to get global addresses: %s", err)
+	}
+
+	// Only the static IPv4 address but not the link-local IPv6 address
+	if len(addrs) != 1 || !addrs[0].IP.Equal(net.IPv4(10, 0, 0, 2)) {
+		t.Errorf("Unexpected global addresses: %v", addrs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := i.WaitForGlobalAddress(ctx, unix.AF_INET6); err == nil {
+		t.Fatal("Got global IPv6 address without router advertisement")
+	}
+
+	sendRouterAdvertisement(t, r1, "veth0", net.ParseIP("2001:db8:1::"))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addr, err := i.WaitForGlobalAddress(ctx, unix.AF_INET6)
+	if err != nil {
+		t.Fatalf("Failed to wait for global address: %s", err)
+	}
+
+	_, prefix, _ := net.ParseCIDR("2001:db8:1::/64")
+	if !prefix.Contains(addr.IP) || addr.IP.IsLinkLocalUnicast() {
+		t.Errorf("Address %s is not from advertised prefix", addr.IP)
+	}
+
+	if addr, err := i.WaitForGlobalAddress(ctx, unix.AF_INET); err != nil {
+		t.Fatalf("Failed to wait for global address: %s", err)
+	} else if !addr.IP.Equal(net.IPv4(10, 0, 0, 2)) {
+		t.Errorf("Unexpected IPv4 address: %s", addr.IP)
+	}
+}
+
+// TestIPv6PrivacyExtensions generates a temporary address
+// alongside the stable address configured by SLAAC
+//
+//  r1 <-> h1
+func TestIPv6PrivacyExtensions(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		r1  *g.Router
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if r1, err = n.AddRouter("r1"); err != nil {
+		t.Fatalf("Failed to create router: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", r1,
+			o.AddressIP("2001:db8:1::1/64")),
+		o.Interface("veth0", h1),
+	); err != nil {
+		t.Fatalf("Failed to connect nodes: %s", err)
+	}
+
+	for _, level := range []int{-1, 3} {
+		if err := h1.SetIPv6PrivacyExtensions("veth0", level); err == nil {
+			t.Errorf("Accepted invalid level %d", level)
+		}
+	}
+
+	if err := h1.SetIPv6PrivacyExtensions("veth0", 2); err != nil {
+		t.Fatalf("Failed to enable privacy extensions: %s", err)
+	}
+
+	sendRouterAdvertisement(t, r1, "veth0", net.ParseIP("2001:db8:1::"))
+
+	_, prefix, _ := net.ParseCIDR("2001:db8:1::/64")
+	link := h1.Interface("veth0").Link
+
+	var stable, temporary net.IP
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		addrs, err := h1.NetlinkHandle().AddrList(link, unix.AF_INET6)
+		if err != nil {
+			t.Fatalf("Failed to list addresses: %s", err)
+		}
+
+		for _, addr := range addrs {
+			if !prefix.Contains(addr.IP) {
+				continue
+			}
+
+			if addr.Flags&unix.IFA_F_TEMPORARY != 0 {
+				temporary = addr.IP
+			} else {
+				stable = addr.IP
+			}
+		}
+
+		if stable != nil && temporary != nil {
+			break
+		}
+	}
+
+	if stable == nil || temporary == nil {
+		t.Fatalf("Missing addresses: stable=%s, temporary=%s", stable, temporary)
+	}
+
+	t.Logf("Stable address: %s, temporary address: %s", stable, temporary)
+}
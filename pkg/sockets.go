@@ -0,0 +1,258 @@
+package gont
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SocketState is the state of a socket as listed in /proc/net/{tcp,udp}.
+type SocketState uint8
+
+const (
+	SocketEstablished SocketState = 0x01
+	SocketSynSent     SocketState = 0x02
+	SocketSynRecv     SocketState = 0x03
+	SocketFinWait1    SocketState = 0x04
+	SocketFinWait2    SocketState = 0x05
+	SocketTimeWait    SocketState = 0x06
+	SocketClose       SocketState = tcpStateClose
+	SocketCloseWait   SocketState = 0x08
+	SocketLastAck     SocketState = 0x09
+	SocketListen      SocketState = tcpStateListen
+	SocketClosing     SocketState = 0x0b
+)
+
+func (s SocketState) String() string {
+	switch s {
+	case SocketEstablished:
+		return "ESTABLISHED"
+	case SocketSynSent:
+		return "SYN_SENT"
+	case SocketSynRecv:
+		return "SYN_RECV"
+	case SocketFinWait1:
+		return "FIN_WAIT1"
+	case SocketFinWait2:
+		return "FIN_WAIT2"
+	case SocketTimeWait:
+		return "TIME_WAIT"
+	case SocketClose:
+		return "CLOSE"
+	case SocketCloseWait:
+		return "CLOSE_WAIT"
+	case SocketLastAck:
+		return "LAST_ACK"
+	case SocketListen:
+		return "LISTEN"
+	case SocketClosing:
+		return "CLOSING"
+	}
+
+	return "UNKNOWN"
+}
+
+// SocketInfo describes a socket of a network namespace.
+type SocketInfo struct {
+	// Protocol is one of "tcp", "tcp6", "udp" or "udp6"
+	Protocol string
+
+	LocalAddress net.IP
+	LocalPort    int
+
+	State SocketState
+
+	// Inode is the inode number of the socket
+	Inode uint64
+
+	// PID is the ID of a process holding a file descriptor of
+	// the socket or zero if none could be found.
+	PID int
+}
+
+// ListeningSockets returns the sockets which are listening within
+// the network namespace of the node.
+//
+// These are TCP sockets in the LISTEN state as well as bound but
+// unconnected UDP sockets (CLOSE state).
+// The owning process is resolved by searching the file descriptors
+// of all processes for the inode of the socket.
+func (n *BaseNode) ListeningSockets() ([]SocketInfo, error) {
+	socks := []SocketInfo{}
+
+	if err := n.RunFunc(func() error {
+		for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+			s, err := readSockets(proto)
+			if err != nil {
+				return err
+			}
+
+			state := SocketListen
+			if strings.HasPrefix(proto, "udp") {
+				state = SocketClose
+			}
+
+			for _, sock := range s {
+				if sock.State == state {
+					socks = append(socks, sock)
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read sockets: %w", err)
+	}
+
+	pids, err := socketOwners()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find socket owners: %w", err)
+	}
+
+	for i := range socks {
+		socks[i].PID = pids[socks[i].Inode]
+	}
+
+	return socks, nil
+}
+
+// readSockets parses the socket table of the protocol proto
+// in the network namespace of the current thread.
+func readSockets(proto string) ([]SocketInfo, error) {
+	// /proc/net refers to the namespace of the main thread
+	// rather than the one of the current thread
+	f, err := os.Open(filepath.Join("/proc/thread-self/net", proto))
+	if errors.Is(err, os.ErrNotExist) {
+		// IPv6 might be disabled
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	socks, err := parseSockets(f, proto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", proto, err)
+	}
+
+	return socks, nil
+}
+
+func parseSockets(r io.Reader, proto string) ([]SocketInfo, error) {
+	socks := []SocketInfo{}
+
+	scanner := bufio.NewScanner(r)
+
+	// Skip header
+	scanner.Scan()
+
+	for scanner.Scan() {
+		// Columns: sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		ip, port, err := parseSocketAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		st, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		socks = append(socks, SocketInfo{
+			Protocol:     proto,
+			LocalAddress: ip,
+			LocalPort:    port,
+			State:        SocketState(st),
+			Inode:        inode,
+		})
+	}
+
+	return socks, scanner.Err()
+}
+
+// parseSocketAddress parses an address like "0100007F:1F90".
+// The address is printed as a sequence of 32-bit words in host byte order.
+func parseSocketAddress(s string) (net.IP, int, error) {
+	idx := strings.LastIndexByte(s, ':')
+	if idx < 0 {
+		return nil, 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	port, err := strconv.ParseUint(s[idx+1:], 16, 16)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ip, err := hex.DecodeString(s[:idx])
+	if err != nil {
+		return nil, 0, err
+	} else if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+		return nil, 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	for i := 0; i < len(ip); i += 4 {
+		w := ip[i : i+4]
+		w[0], w[1], w[2], w[3] = w[3], w[2], w[1], w[0]
+	}
+
+	return net.IP(ip), int(port), nil
+}
+
+// socketOwners maps the inodes of all sockets to the ID of a process
+// which holds a file descriptor of the socket.
+func socketOwners() (map[uint64]int, error) {
+	pids := map[uint64]int{}
+
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+
+		// Processes might exit while iterating
+		fds, err := os.ReadDir(filepath.Join("/proc", dir.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join("/proc", dir.Name(), "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+
+			var inode uint64
+			if _, err := fmt.Sscanf(target, "socket:[%d]", &inode); err != nil {
+				continue
+			}
+
+			if _, ok := pids[inode]; !ok {
+				pids[inode] = pid
+			}
+		}
+	}
+
+	return pids, nil
+}
@@ -0,0 +1,113 @@
+package gont
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SoftnetStat contains the per-CPU packet processing statistics
+// of the kernel as reported by /proc/net/softnet_stat
+type SoftnetStat struct {
+	CPU int `json:"cpu"`
+
+	Processed      uint32 `json:"processed"`
+	Dropped        uint32 `json:"dropped"`
+	TimeSqueeze    uint32 `json:"time_squeeze"`
+	CPUCollision   uint32 `json:"cpu_collision"`
+	ReceivedRPS    uint32 `json:"received_rps"`
+	FlowLimitCount uint32 `json:"flow_limit_count"`
+	BacklogLen     uint32 `json:"backlog_len"`
+}
+
+// Columns of /proc/net/softnet_stat.
+// Older kernels provide less columns which are left empty.
+const (
+	softnetColProcessed = iota
+	softnetColDropped
+	softnetColTimeSqueeze
+	softnetColCPUCollision = 8
+	softnetColReceivedRPS  = 9
+	softnetColFlowLimit    = 10
+	softnetColBacklogLen   = 11 // since Linux 5.10
+	softnetColCPU          = 12 // since Linux 5.10
+	softnetMinColumns      = 3
+)
+
+// SoftnetStats returns the per-CPU backlog statistics from within the namespace of the node.
+//
+// Please note that the statistics are maintained per CPU by the kernel
+// and not per network namespace.
+func (n *BaseNode) SoftnetStats() ([]SoftnetStat, error) {
+	var stats []SoftnetStat
+
+	if err := n.RunFunc(func() error {
+		// /proc/net refers to the namespace of the main thread
+		// rather than the one of the current thread
+		f, err := os.Open("/proc/thread-self/net/softnet_stat")
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		stats, err = parseSoftnetStats(f)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read softnet statistics: %w", err)
+	}
+
+	return stats, nil
+}
+
+func parseSoftnetStats(r io.Reader) ([]SoftnetStat, error) {
+	stats := []SoftnetStat{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 0; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < softnetMinColumns {
+			return nil, fmt.Errorf("invalid number of columns in line %d: %d", line+1, len(fields))
+		}
+
+		cols := make([]uint32, len(fields))
+		for i, field := range fields {
+			v, err := strconv.ParseUint(field, 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in line %d: %w", line+1, err)
+			}
+
+			cols[i] = uint32(v)
+		}
+
+		col := func(i int) uint32 {
+			if i < len(cols) {
+				return cols[i]
+			}
+
+			return 0
+		}
+
+		// Lines of offline CPUs are omitted. Hence the line number
+		// is only an approximation of the CPU index on older kernels.
+		cpu := line
+		if len(cols) > softnetColCPU {
+			cpu = int(cols[softnetColCPU])
+		}
+
+		stats = append(stats, SoftnetStat{
+			CPU:            cpu,
+			Processed:      col(softnetColProcessed),
+			Dropped:        col(softnetColDropped),
+			TimeSqueeze:    col(softnetColTimeSqueeze),
+			CPUCollision:   col(softnetColCPUCollision),
+			ReceivedRPS:    col(softnetColReceivedRPS),
+			FlowLimitCount: col(softnetColFlowLimit),
+			BacklogLen:     col(softnetColBacklogLen),
+		})
+	}
+
+	return stats, scanner.Err()
+}
@@ -0,0 +1,77 @@
+package gont_test
+
+import (
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+func processedPackets(t *testing.T, h *g.Host) uint64 {
+	stats, err := h.SoftnetStats()
+	if err != nil {
+		t.Fatalf("Failed to read softnet stats: %s", err)
+	}
+
+	if len(stats) == 0 {
+		t.Fatalf("No softnet stats found")
+	}
+
+	var processed uint64
+	for _, s := range stats {
+		processed += uint64(s.Processed)
+	}
+
+	return processed
+}
+
+// TestSoftnetStats checks that packets received via a veth link
+// are accounted in softnet statistics
+//
+//  h1 <-> h2
+func TestSoftnetStats(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	before := processedPackets(t, h2)
+
+	c, err := h1.Dial("udp", "10.0.0.2:5000")
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 1000; i++ {
+		// Writes might fail due to ICMP port unreachable errors of preceding packets
+		_, _ = c.Write([]byte("hello"))
+	}
+
+	if after := processedPackets(t, h2); after <= before {
+		t.Errorf("No packets have been processed: %d <= %d", after, before)
+	}
+}
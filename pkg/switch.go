@@ -20,7 +20,11 @@ func (sw *Switch) Apply(i *Interface) {
 
 // AddSwitch adds a new Linux virtual bridge in a dedicated namespace
 func (n *Network) AddSwitch(name string, opts ...Option) (*Switch, error) {
-	node, err := n.AddNode(name, opts...)
+	if err := checkOptions(opts, targetNode, targetSwitch, targetBridge, targetLink); err != nil {
+		return nil, err
+	}
+
+	node, err := n.addNode(name, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create node: %w", err)
 	}
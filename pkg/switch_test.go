@@ -1,10 +1,15 @@
 package gont_test
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
 	g "github.com/stv0g/gont/pkg"
 	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
 )
 
 // TestPing performs and end-to-end ping test
@@ -57,3 +62,108 @@ func TestPingCascadedSwitches(t *testing.T) {
 		t.Errorf("Failed to check connectivity: %s", err)
 	}
 }
+
+// receiveReflected waits for an incoming frame carrying the payload
+func receiveReflected(fd int, timeout time.Duration, payload []byte) bool {
+	buf := make([]byte, 1<<16)
+
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		n, from, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			continue
+		}
+
+		// Skip our own transmitted frame
+		if sa, ok := from.(*unix.SockaddrLinklayer); !ok || sa.Pkttype == unix.PACKET_OUTGOING {
+			continue
+		}
+
+		p := gopacket.NewPacket(buf[:n], layers.LayerTypeEthernet, gopacket.Default)
+		if eth, ok := p.LinkLayer().(*layers.Ethernet); ok && bytes.Equal(eth.Payload, payload) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//  h1 <-> sw1
+func TestHairpin(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		sw1 *g.Switch
+		h1  *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if sw1, err = n.AddSwitch("sw1"); err != nil {
+		t.Fatalf("Failed to add switch: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1",
+		o.Interface("veth0", sw1),
+	); err != nil {
+		t.Fatalf("Failed to add host: %s", err)
+	}
+
+	if err := h1.Interface("veth0").SetHairpin(true); err == nil {
+		t.Error("Enabled hairpin mode on interface which is not a bridge port")
+	}
+
+	port := sw1.Interface("veth-h1")
+
+	if hairpin, err := port.Hairpin(); err != nil {
+		t.Fatalf("Failed to get hairpin mode: %s", err)
+	} else if hairpin {
+		t.Error("Hairpin mode is enabled by default")
+	}
+
+	fd := listenPacket(t, h1.BaseNode, "veth0")
+	defer unix.Close(fd)
+
+	payload := bytes.Repeat([]byte("hairpin "), 8)
+
+	send := func() {
+		eth := &layers.Ethernet{
+			SrcMAC:       h1.Interface("veth0").Link.Attrs().HardwareAddr,
+			DstMAC:       layers.EthernetBroadcast,
+			EthernetType: 0x88b5, // Local experimental
+		}
+
+		buf := gopacket.NewSerializeBuffer()
+		if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{}, eth, gopacket.Payload(payload)); err != nil {
+			t.Fatalf("Failed to serialize packet: %s", err)
+		}
+
+		if err := h1.SendPacket("veth0", gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)); err != nil {
+			t.Fatalf("Failed to send packet: %s", err)
+		}
+	}
+
+	send()
+
+	if receiveReflected(fd, 200*time.Millisecond, payload) {
+		t.Error("Frame has been reflected without hairpin mode")
+	}
+
+	if err := port.SetHairpin(true); err != nil {
+		t.Fatalf("Failed to enable hairpin mode: %s", err)
+	}
+
+	if hairpin, err := port.Hairpin(); err != nil {
+		t.Fatalf("Failed to get hairpin mode: %s", err)
+	} else if !hairpin {
+		t.Error("Hairpin mode is not enabled")
+	}
+
+	send()
+
+	if !receiveReflected(fd, 2*time.Second, payload) {
+		t.Error("Frame has not been reflected in hairpin mode")
+	}
+}
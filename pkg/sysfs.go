@@ -0,0 +1,103 @@
+package gont
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+const sysfsPath = "/sys"
+
+// runWithSysFS invokes cb with a sysfs mounted at /sys which
+// reflects the network devices of the network namespace ns.
+//
+// The callback runs in a dedicated OS thread with private mount namespace
+// which gets terminated afterwards.
+func runWithSysFS(ns netns.NsHandle, cb Callback) error {
+	errs := make(chan error, 1)
+
+	go func() {
+		// We do not unlock the thread so that it gets terminated
+		// by the Go runtime once the goroutine finishes
+		runtime.LockOSThread()
+
+		errs <- func() error {
+			if err := unix.Unshare(unix.CLONE_NEWNS | unix.CLONE_FS); err != nil {
+				return fmt.Errorf("failed to unshare mount namespace: %w", err)
+			}
+
+			if err := unix.Setns(int(ns), unix.CLONE_NEWNET); err != nil {
+				return fmt.Errorf("failed to enter network namespace: %w", err)
+			}
+
+			if err := unix.Mount("none", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+				return fmt.Errorf("failed to make mounts private: %w", err)
+			}
+
+			if err := unix.Mount("sysfs", sysfsPath, "sysfs", 0, ""); err != nil {
+				return fmt.Errorf("failed to mount sysfs: %w", err)
+			}
+
+			return cb()
+		}()
+	}()
+
+	return <-errs
+}
+
+// possibleCPUs returns the number of CPUs which can be brought online.
+// This includes CPUs which are currently offline or excluded from the
+// affinity mask of the process unlike runtime.NumCPU().
+func possibleCPUs() (int, error) {
+	b, err := os.ReadFile(filepath.Join(sysfsPath, "devices/system/cpu/possible"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read possible CPUs: %w", err)
+	}
+
+	// The list is sorted, e.g. "0-3,8-11"
+	ranges := strings.Split(strings.TrimSpace(string(b)), ",")
+	last := ranges[len(ranges)-1]
+	if idx := strings.LastIndexByte(last, '-'); idx >= 0 {
+		last = last[idx+1:]
+	}
+
+	highest, err := strconv.Atoi(last)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse possible CPUs: %w", err)
+	}
+
+	return highest + 1, nil
+}
+
+// cpuMask encodes a list of CPU indices in the
+// comma-separated hex format used by sysfs for CPU masks.
+func cpuMask(cpus []int) (string, error) {
+	num, err := possibleCPUs()
+	if err != nil {
+		return "", err
+	}
+
+	words := make([]uint32, (num+31)/32)
+
+	for _, cpu := range cpus {
+		if cpu < 0 || cpu >= num {
+			return "", fmt.Errorf("invalid CPU index: %d", cpu)
+		}
+
+		words[cpu/32] |= 1 << (cpu % 32)
+	}
+
+	// Most significant words come first
+	strs := make([]string, len(words))
+	for i, word := range words {
+		strs[len(words)-1-i] = fmt.Sprintf("%08x", word)
+	}
+
+	return strings.Join(strs, ","), nil
+}
@@ -0,0 +1,186 @@
+package gont
+
+import (
+	"fmt"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/multierr"
+)
+
+// TCConfig describes the complete egress traffic control
+// configuration of an interface which is applied by Interface.ApplyTC().
+//
+// The link indices of the qdiscs and classes are set automatically.
+type TCConfig struct {
+	Qdiscs  []nl.Qdisc
+	Classes []nl.Class
+	Filters []TCFilter
+}
+
+// ApplyTC replaces the egress qdiscs, classes and filters of the interface
+// by the hierarchy described in config.
+//
+// Qdiscs and classes are installed in the order of their dependencies,
+// followed by the filters. If any object can not be installed, the
+// hierarchy is removed again and the previous qdiscs and classes are
+// restored. Previous filters are not restored.
+func (i *Interface) ApplyTC(config TCConfig) error {
+	link, err := i.currentLink()
+	if err != nil {
+		return err
+	}
+
+	prev, err := i.QdiscTree()
+	if err != nil {
+		return err
+	}
+
+	if err := i.deleteRootQdisc(prev); err != nil {
+		return err
+	}
+
+	if err := i.installTC(link, config); err != nil {
+		if cur, err2 := i.QdiscTree(); err2 != nil {
+			err = multierr.Append(err, err2)
+		} else if err2 := i.deleteRootQdisc(cur); err2 != nil {
+			err = multierr.Append(err, err2)
+		} else if err2 := i.restoreTC(prev); err2 != nil {
+			err = multierr.Append(err, fmt.Errorf("failed to restore previous configuration: %w", err2))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (i *Interface) installTC(link nl.Link, config TCConfig) error {
+	h := i.Node.NetlinkHandle()
+	idx := link.Attrs().Index
+
+	type object struct {
+		handle, parent uint32
+		add            func() error
+	}
+
+	pending := []object{}
+
+	for _, q := range config.Qdiscs {
+		q := q
+		attrs := q.Attrs()
+		attrs.LinkIndex = idx
+
+		pending = append(pending, object{attrs.Handle, attrs.Parent, func() error {
+			if err := h.QdiscAdd(q); err != nil {
+				return fmt.Errorf("failed to add qdisc %s: %w", nl.HandleStr(q.Attrs().Handle), err)
+			}
+			return nil
+		}})
+	}
+
+	for _, c := range config.Classes {
+		c := c
+		attrs := c.Attrs()
+		attrs.LinkIndex = idx
+
+		pending = append(pending, object{attrs.Handle, attrs.Parent, func() error {
+			if err := h.ClassAdd(c); err != nil {
+				return fmt.Errorf("failed to add class %s: %w", nl.HandleStr(c.Attrs().Handle), err)
+			}
+			return nil
+		}})
+	}
+
+	installed := map[uint32]bool{
+		nl.HANDLE_ROOT: true,
+	}
+
+	for len(pending) > 0 {
+		remaining := []object{}
+
+		for _, o := range pending {
+			if !installed[o.parent] {
+				remaining = append(remaining, o)
+				continue
+			}
+
+			if err := o.add(); err != nil {
+				return err
+			}
+
+			installed[o.handle] = true
+		}
+
+		if len(remaining) == len(pending) {
+			return fmt.Errorf("parent %s of %s does not exist", nl.HandleStr(remaining[0].parent), nl.HandleStr(remaining[0].handle))
+		}
+
+		pending = remaining
+	}
+
+	for _, f := range config.Filters {
+		if err := i.AddFilter(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreTC re-adds the qdiscs and classes of a tree returned by QdiscTree()
+func (i *Interface) restoreTC(tree *QdiscNode) error {
+	// The default qdisc is restored by the kernel
+	if tree.Handle == 0 {
+		return nil
+	}
+
+	h := i.Node.NetlinkHandle()
+
+	var add func(n *QdiscNode) error
+	add = func(n *QdiscNode) error {
+		if n.IsClass() {
+			if err := h.ClassAdd(n.Class); err != nil {
+				return fmt.Errorf("failed to add class %s: %w", nl.HandleStr(n.Handle), err)
+			}
+		} else if err := h.QdiscAdd(restorableQdisc(n.Qdisc)); err != nil {
+			return fmt.Errorf("failed to add qdisc %s: %w", nl.HandleStr(n.Handle), err)
+		}
+
+		for _, c := range n.Children {
+			if err := add(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return add(tree)
+}
+
+// restorableQdisc adjusts the parameters of a dumped qdisc
+// so that they are accepted when adding it again
+func restorableQdisc(q nl.Qdisc) nl.Qdisc {
+	if htb, ok := q.(*nl.Htb); ok {
+		// The kernel reports the full version but expects only the major
+		c := *htb
+		c.Version >>= 16
+		return &c
+	}
+
+	return q
+}
+
+// deleteRootQdisc removes the root qdisc together with all its classes and filters
+func (i *Interface) deleteRootQdisc(tree *QdiscNode) error {
+	// The default qdisc can not be deleted
+	if tree.Handle == 0 {
+		return nil
+	}
+
+	if err := i.Node.NetlinkHandle().QdiscDel(tree.Qdisc); err != nil {
+		return fmt.Errorf("failed to delete root qdisc: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package gont_test
+
+import (
+	"testing"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// htbConfig returns a HTB qdisc with a class for each of the minors
+// and a u32 filter steering UDP traffic into the first class
+func htbConfig(major uint16, minors ...uint16) g.TCConfig {
+	root := nl.MakeHandle(major, 0)
+
+	htb := nl.NewHtb(nl.QdiscAttrs{
+		Handle: root,
+		Parent: nl.HANDLE_ROOT,
+	})
+	htb.Defcls = uint32(minors[len(minors)-1])
+
+	cfg := g.TCConfig{
+		Qdiscs: []nl.Qdisc{htb},
+	}
+
+	// Classes are deliberately listed before their parent
+	for j := len(minors) - 1; j >= 0; j-- {
+		parent := root
+		if j > 0 {
+			parent = nl.MakeHandle(major, minors[0])
+		}
+
+		cfg.Classes = append(cfg.Classes, nl.NewHtbClass(nl.ClassAttrs{
+			Parent: parent,
+			Handle: nl.MakeHandle(major, minors[j]),
+		}, nl.HtbClassAttrs{
+			Rate: 10e6,
+			Ceil: 10e6,
+		}))
+	}
+
+	cfg.Filters = []g.TCFilter{
+		&g.U32Filter{
+			TCFilterAttrs: g.TCFilterAttrs{
+				Parent:  root,
+				ClassID: nl.MakeHandle(major, minors[len(minors)-1]),
+			},
+			Protocol: unix.ETH_P_IP,
+			Keys: []g.U32Key{
+				{Offset: 8, Value: unix.IPPROTO_UDP << 16, Mask: 0x00ff0000},
+			},
+		},
+	}
+
+	return cfg
+}
+
+//  h1 <-> h2
+func TestApplyTC(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1),
+		o.Interface("veth0", h2),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	i := h1.Interface("veth0")
+
+	// HTB 1: with parent class 1:1 and children 1:10, 1:20
+	if err := i.ApplyTC(htbConfig(1, 1, 10, 20)); err != nil {
+		t.Fatalf("Failed to apply configuration: %s", err)
+	}
+
+	checkTree := func(major uint16, minors ...uint16) {
+		t.Helper()
+
+		tree, err := i.QdiscTree()
+		if err != nil {
+			t.Fatalf("Failed to get qdisc tree: %s", err)
+		}
+
+		if tree.Kind != "htb" || tree.Handle != nl.MakeHandle(major, 0) {
+			t.Fatalf("Unexpected root qdisc:\n%s", tree)
+		}
+
+		for j, minor := range minors {
+			c := tree.Find(nl.MakeHandle(major, minor))
+			if c == nil || !c.IsClass() {
+				t.Fatalf("Missing class %d:%d:\n%s", major, minor, tree)
+			}
+
+			if j > 0 && c.Parent != nl.MakeHandle(major, minors[0]) {
+				t.Errorf("Class %d:%d has wrong parent:\n%s", major, minor, tree)
+			}
+		}
+
+		filters, err := h1.NetlinkHandle().FilterList(i.Link, tree.Handle)
+		if err != nil {
+			t.Fatalf("Failed to list filters: %s", err)
+		}
+
+		if len(filters) == 0 {
+			t.Error("Filter has not been installed")
+		}
+	}
+
+	checkTree(1, 1, 10, 20)
+
+	// A filter referencing a missing class must restore the previous configuration
+	bad := htbConfig(2, 1, 10)
+	bad.Filters = append(bad.Filters, &g.U32Filter{
+		TCFilterAttrs: g.TCFilterAttrs{
+			Parent:  nl.MakeHandle(2, 0),
+			ClassID: nl.MakeHandle(2, 99),
+		},
+	})
+
+	if err := i.ApplyTC(bad); err == nil {
+		t.Fatal("Applied configuration with invalid filter")
+	}
+
+	tree, err := i.QdiscTree()
+	if err != nil {
+		t.Fatalf("Failed to get qdisc tree: %s", err)
+	}
+
+	if tree.Handle != nl.MakeHandle(1, 0) || tree.Find(nl.MakeHandle(1, 20)) == nil {
+		t.Errorf("Previous configuration has not been restored:\n%s", tree)
+	}
+
+	// Objects with missing parents are rejected
+	orphan := htbConfig(3, 1)
+	orphan.Classes[0].Attrs().Parent = nl.MakeHandle(4, 0)
+
+	if err := i.ApplyTC(orphan); err == nil {
+		t.Error("Applied configuration with missing parent")
+	}
+
+	// Replace the configuration
+	if err := i.ApplyTC(htbConfig(5, 1, 30)); err != nil {
+		t.Fatalf("Failed to apply configuration: %s", err)
+	}
+
+	checkTree(5, 1, 30)
+
+	if tree, err = i.QdiscTree(); err != nil {
+		t.Fatalf("Failed to get qdisc tree: %s", err)
+	} else if tree.Find(nl.MakeHandle(1, 20)) != nil {
+		t.Errorf("Previous configuration has not been removed:\n%s", tree)
+	}
+}
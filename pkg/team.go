@@ -0,0 +1,152 @@
+package gont
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TeamRunner is the teamd runner which determines the mode of a team device
+type TeamRunner string
+
+const (
+	TeamBroadcast    TeamRunner = "broadcast"
+	TeamRoundRobin   TeamRunner = "roundrobin"
+	TeamActiveBackup TeamRunner = "activebackup"
+	TeamLoadBalance  TeamRunner = "loadbalance"
+	TeamLACP         TeamRunner = "lacp"
+)
+
+const teamTimeout = 5 * time.Second
+
+type team struct {
+	name   string
+	cmd    *exec.Cmd
+	exited chan error
+}
+
+// AddTeam aggregates the existing interfaces ports of the node
+// into a new team device (netlink link kind "team") named name.
+//
+// The team device is created and managed by a teamd daemon which is
+// started in the node and configured by a JSON configuration using
+// the runner and the ethtool link watcher. The daemon is stopped
+// when the node is torn down.
+//
+// Teaming requires the team kernel module and the teamd executable.
+func (n *BaseNode) AddTeam(name string, runner TeamRunner, ports ...string) (*Interface, error) {
+	if _, err := exec.LookPath("teamd"); err != nil {
+		return nil, fmt.Errorf("teamd is not available: %w", err)
+	}
+
+	portsConfig := map[string]any{}
+	for _, port := range ports {
+		if _, err := n.nlHandle.LinkByName(port); err != nil {
+			return nil, fmt.Errorf("failed to find port %s: %w", port, err)
+		}
+
+		portsConfig[port] = map[string]any{}
+	}
+
+	cfg, err := json.Marshal(map[string]any{
+		"device": name,
+		"runner": map[string]any{
+			"name": runner,
+		},
+		"link_watch": map[string]any{
+			"name": "ethtool",
+		},
+		"ports": portsConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// teamd uses a pid file per team device which
+	// must not collide with those of other nodes
+	pidFile := filepath.Join(n.BasePath, fmt.Sprintf("teamd-%s.pid", name))
+
+	n.logger.Info("Starting teamd",
+		zap.String("team", name),
+		zap.Any("runner", runner),
+		zap.Strings("ports", ports))
+
+	out := &bytes.Buffer{}
+
+	cmd := n.Command("teamd", "--config", string(cfg), "--pid-file", pidFile, "--force-recreate")
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start teamd: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	t := &team{
+		name:   name,
+		cmd:    cmd,
+		exited: exited,
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	timeout := time.After(teamTimeout)
+
+	for {
+		if link, err := n.nlHandle.LinkByName(name); err == nil && link.Type() == "team" {
+			i := &Interface{
+				Name: name,
+				Node: n.node(),
+				Link: link,
+			}
+
+			n.teams = append(n.teams, t)
+			n.Interfaces = append(n.Interfaces, i)
+
+			return i, nil
+		}
+
+		select {
+		case err := <-exited:
+			return nil, fmt.Errorf("teamd failed: %v: %s", err, out)
+
+		case <-timeout:
+			cmd.Process.Kill()
+			<-exited
+			return nil, fmt.Errorf("timed out waiting for team device %s: %s", name, out)
+
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopTeams terminates the teamd daemons of the node which
+// remove their team devices and release the ports.
+func (n *BaseNode) stopTeams() error {
+	for _, t := range n.teams {
+		if err := t.cmd.Process.Signal(syscall.SIGTERM); err != nil && err != os.ErrProcessDone {
+			return fmt.Errorf("failed to stop teamd of %s: %w", t.name, err)
+		}
+
+		<-t.exited
+
+		n.logger.Info("Stopped teamd",
+			zap.String("team", t.name))
+	}
+
+	n.teams = nil
+
+	return nil
+}
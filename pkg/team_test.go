@@ -0,0 +1,100 @@
+package gont_test
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+//  h1 (team0 = veth0 + veth1) <-> sw1 <-> h2
+func TestTeamActiveBackup(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		sw1    *g.Switch
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if sw1, err = n.AddSwitch("sw1"); err != nil {
+		t.Fatalf("Failed to create switch: %s", err)
+	}
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	for _, port := range []string{"veth0", "veth1"} {
+		if err := n.AddLink(
+			o.Interface(port, h1),
+			o.Interface("h1-"+port, sw1),
+		); err != nil {
+			t.Fatalf("Failed to connect host: %s", err)
+		}
+	}
+
+	if h2, err = n.AddHost("h2",
+		o.Interface("veth0", sw1,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if _, err := exec.LookPath("teamd"); err != nil {
+		if _, err := h1.AddTeam("team0", g.TeamActiveBackup, "veth0", "veth1"); err == nil || !strings.Contains(err.Error(), "teamd is not available") {
+			t.Errorf("Unexpected error without teamd: %v", err)
+		}
+
+		t.Skip("teamd is not available")
+	}
+
+	if _, err := h1.AddTeam("team1", g.TeamActiveBackup, "veth9"); err == nil {
+		t.Error("Created team with non-existing port")
+	}
+
+	team, err := h1.AddTeam("team0", g.TeamActiveBackup, "veth0", "veth1")
+	if err != nil {
+		t.Fatalf("Failed to create team: %s", err)
+	}
+
+	if err := h1.LinkAddAddress(team.Name, net.IPNet(o.AddressIPv4(10, 0, 0, 1, 24))); err != nil {
+		t.Fatalf("Failed to add address: %s", err)
+	}
+
+	ping := func() {
+		t.Helper()
+
+		if _, err := h1.PingWithOptions(h2, "ip", 3, 3*time.Second, 100*time.Millisecond, false); err != nil {
+			t.Errorf("Failed to ping: %s", err)
+		}
+	}
+
+	ping()
+
+	// Fail over in both directions regardless of the initially active port
+	for _, tc := range []struct{ down, up string }{
+		{"veth0", ""},
+		{"veth1", "veth0"},
+	} {
+		if tc.up != "" {
+			if err := h1.Interface(tc.up).SetUp(); err != nil {
+				t.Fatalf("Failed to set up %s: %s", tc.up, err)
+			}
+		}
+
+		if err := h1.Interface(tc.down).SetDown(); err != nil {
+			t.Fatalf("Failed to set down %s: %s", tc.down, err)
+		}
+
+		ping()
+	}
+}
@@ -8,11 +8,68 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"sync"
 
 	"github.com/vishvananda/netns"
+	"go.uber.org/multierr"
 	"golang.org/x/sys/unix"
 )
 
+var (
+	// networks contains all networks created by this process
+	// which have not been torn down yet.
+	networks     = map[*Network]struct{}{}
+	networksLock sync.Mutex
+)
+
+func registerNetwork(n *Network) {
+	networksLock.Lock()
+	defer networksLock.Unlock()
+
+	networks[n] = struct{}{}
+}
+
+func unregisterNetwork(n *Network) {
+	networksLock.Lock()
+	defer networksLock.Unlock()
+
+	delete(networks, n)
+}
+
+// Networks returns all networks created by this process
+// which have not been torn down yet sorted by their names.
+//
+// In contrast to NetworkNames() networks of other processes are not included.
+func Networks() []*Network {
+	networksLock.Lock()
+	defer networksLock.Unlock()
+
+	nets := []*Network{}
+	for n := range networks {
+		nets = append(nets, n)
+	}
+
+	sort.Slice(nets, func(i, j int) bool {
+		return nets[i].Name < nets[j].Name
+	})
+
+	return nets
+}
+
+// CleanupAll tears down all networks returned by Networks().
+// Networks which fail to be torn down are skipped and their errors are combined.
+func CleanupAll() error {
+	var errs error
+
+	for _, n := range Networks() {
+		if err := n.Teardown(); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to teardown network %s: %w", n.Name, err))
+		}
+	}
+
+	return errs
+}
+
 func NetworkNames() []string {
 	names := []string{}
 
@@ -98,6 +155,10 @@ func TeardownNetwork(name string) error {
 		nodeName := fi.Name()
 		netNsName := fmt.Sprintf("gont-%s-%s", name, nodeName)
 
+		if err := stopInitFromPIDFile(filepath.Join(nodesDir, nodeName)); err != nil {
+			return err
+		}
+
 		nsMount := filepath.Join(nodesDir, nodeName, "ns", "net")
 		if err := unix.Unmount(nsMount, 0); err != nil {
 			return err
@@ -0,0 +1,87 @@
+package gont_test
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/sys/unix"
+)
+
+const monotonicHelperEnv = "GONT_TEST_MONOTONIC_HELPER"
+
+func monotonic() time.Duration {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		panic(err)
+	}
+
+	return time.Duration(ts.Nano())
+}
+
+// TestMonotonicHelper is not a real test but is executed
+// by TestTimeOffset within a node to print its monotonic clock
+func TestMonotonicHelper(t *testing.T) {
+	if os.Getenv(monotonicHelperEnv) == "" {
+		t.Skip("Only used as a helper process")
+	}
+
+	fmt.Printf("monotonic=%d\n", monotonic())
+}
+
+func TestTimeOffset(t *testing.T) {
+	var (
+		err error
+		n   *g.Network
+		h1  *g.Host
+	)
+
+	if _, err := os.Stat("/proc/self/ns/time"); err != nil {
+		t.Skip("Kernel lacks support for time namespaces")
+	}
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	const offset = 24 * time.Hour
+
+	if h1, err = n.AddHost("h1", o.WithTimeOffset(offset)); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	c := h1.Command("/proc/self/exe", "-test.run=^TestMonotonicHelper$")
+	c.Env = append(c.Env, monotonicHelperEnv+"=1")
+
+	before := monotonic()
+
+	out, err := c.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run helper: %s\n%s", err, out)
+	}
+
+	after := monotonic()
+
+	var inside time.Duration
+	for _, line := range strings.Split(string(out), "\n") {
+		if v := strings.TrimPrefix(line, "monotonic="); v != line {
+			ns, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				t.Fatalf("Failed to parse clock: %s", err)
+			}
+
+			inside = time.Duration(ns)
+		}
+	}
+
+	if inside < before+offset || inside > after+offset {
+		t.Errorf("Monotonic clock of node is not shifted by %s: %s not in [%s, %s]",
+			offset, inside, before+offset, after+offset)
+	}
+}
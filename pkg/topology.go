@@ -0,0 +1,268 @@
+package gont
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+
+	nl "github.com/vishvananda/netlink"
+)
+
+// Topology contains the nodes and links which have been created
+// by one of the topology generators like Network.BuildMesh().
+type Topology struct {
+	Hosts []*Host
+	Links []TopologyLink
+
+	// Switch is the center of a star topology
+	Switch *Switch
+}
+
+// TopologyLink is a link of a generated topology
+type TopologyLink struct {
+	Left, Right *Interface
+
+	// Subnet is the IPv4 subnet which has been allocated for the link
+	Subnet net.IPNet
+}
+
+// BuildMesh adds num hosts named h1..hN and links every pair of them.
+//
+// Each link gets a /30 subnet allocated from the IPv4 subnet pools of the
+// network. The interfaces are named after the peer host like "veth-h2".
+// Host routes to the addresses of the remaining links of a peer are added
+// so that every address of a host is reachable from all other hosts.
+// The options are passed to AddHost().
+func (n *Network) BuildMesh(num int, opts ...Option) (*Topology, error) {
+	t, err := n.buildHosts(num, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, left := range t.Hosts {
+		for _, right := range t.Hosts[i+1:] {
+			if err := t.link(n, left, right); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Route the addresses of the peers via the direct link
+	for _, l := range t.Links {
+		for _, dir := range [][2]*Interface{{l.Left, l.Right}, {l.Right, l.Left}} {
+			local, peer := dir[0], dir[1]
+
+			for _, i := range peer.Node.(*Host).Interfaces {
+				if i == peer || i.IsLoopback() {
+					continue
+				}
+
+				for _, addr := range i.Addresses {
+					if addr.IP.To4() == nil {
+						continue
+					}
+
+					if err := local.Node.(*Host).AddRoute(&nl.Route{
+						Dst: &net.IPNet{
+							IP:   addr.IP,
+							Mask: net.CIDRMask(32, 32),
+						},
+						Gw: peer.Addresses[0].IP,
+					}); err != nil {
+						return nil, fmt.Errorf("failed to add route: %w", err)
+					}
+				}
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// BuildStar adds num hosts named h1..hN and links all of them to
+// a switch named sw.
+//
+// The hosts get addresses from a single subnet which is allocated
+// from the IPv4 subnet pools of the network.
+// The options are passed to AddHost().
+func (n *Network) BuildStar(num int, opts ...Option) (*Topology, error) {
+	t, err := n.buildHosts(num, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Switch, err = n.AddSwitch("sw"); err != nil {
+		return nil, fmt.Errorf("failed to add switch: %w", err)
+	}
+
+	// Reserve the network and broadcast addresses
+	prefixLen := 32 - new(big.Int).SetInt64(int64(num+1)).BitLen()
+	if prefixLen > 30 {
+		prefixLen = 30
+	}
+
+	subnet, err := n.AllocSubnet(prefixLen)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, h := range t.Hosts {
+		l := &Interface{
+			Name: "veth0",
+			Node: h,
+			Addresses: []net.IPNet{
+				{
+					IP:   subnetHost(subnet, k+1),
+					Mask: subnet.Mask,
+				},
+			},
+		}
+
+		r := &Interface{
+			Name: fmt.Sprintf("veth-%s", h.Name()),
+			Node: t.Switch,
+		}
+
+		if err := n.AddLink(l, r); err != nil {
+			return nil, fmt.Errorf("failed to link %s with switch: %w", h, err)
+		}
+
+		t.Links = append(t.Links, TopologyLink{
+			Left:   l,
+			Right:  r,
+			Subnet: subnet,
+		})
+	}
+
+	return t, nil
+}
+
+// BuildLine adds num routers named h1..hN and links each of them with
+// its successor.
+//
+// Each link gets a /30 subnet allocated from the IPv4 subnet pools of the
+// network. Routes to the subnets of non-adjacent links are added via the
+// neighbors so that every router can reach all other routers.
+// The hosts of the returned topology are the hosts of the routers.
+// The options are passed to AddRouter().
+func (n *Network) BuildLine(num int, opts ...Option) (*Topology, error) {
+	if num < 1 {
+		return nil, fmt.Errorf("invalid number of nodes: %d", num)
+	}
+
+	t := &Topology{}
+
+	routers := []*Router{}
+	for k := 1; k <= num; k++ {
+		r, err := n.AddRouter(fmt.Sprintf("h%d", k), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add router: %w", err)
+		}
+
+		routers = append(routers, r)
+		t.Hosts = append(t.Hosts, r.Host)
+	}
+
+	for k := 0; k < num-1; k++ {
+		if err := t.link(n, routers[k], routers[k+1]); err != nil {
+			return nil, err
+		}
+	}
+
+	for k, h := range t.Hosts {
+		for j, l := range t.Links {
+			var gw net.IP
+			switch {
+			case j < k-1: // Left of the left neighbor
+				gw = t.Links[k-1].Left.Addresses[0].IP
+			case j > k: // Right of the right neighbor
+				gw = t.Links[k].Right.Addresses[0].IP
+			default:
+				continue
+			}
+
+			subnet := l.Subnet
+			if err := h.AddRoute(&nl.Route{
+				Dst: &subnet,
+				Gw:  gw,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to add route: %w", err)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// buildHosts adds the hosts h1..hN
+func (n *Network) buildHosts(num int, opts []Option) (*Topology, error) {
+	if num < 1 {
+		return nil, fmt.Errorf("invalid number of nodes: %d", num)
+	}
+
+	t := &Topology{}
+
+	for k := 1; k <= num; k++ {
+		h, err := n.AddHost(fmt.Sprintf("h%d", k), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add host: %w", err)
+		}
+
+		t.Hosts = append(t.Hosts, h)
+	}
+
+	return t, nil
+}
+
+// link connects two hosts via a point-to-point link with a /30 subnet
+func (t *Topology) link(n *Network, left, right Node) error {
+	subnet, err := n.AllocSubnet(30)
+	if err != nil {
+		return err
+	}
+
+	l := TopologyLink{
+		Left: &Interface{
+			Name: fmt.Sprintf("veth-%s", right.Name()),
+			Node: left,
+			Addresses: []net.IPNet{
+				{
+					IP:   subnetHost(subnet, 1),
+					Mask: subnet.Mask,
+				},
+			},
+		},
+		Right: &Interface{
+			Name: fmt.Sprintf("veth-%s", left.Name()),
+			Node: right,
+			Addresses: []net.IPNet{
+				{
+					IP:   subnetHost(subnet, 2),
+					Mask: subnet.Mask,
+				},
+			},
+		},
+		Subnet: subnet,
+	}
+
+	if err := n.AddLink(l.Left, l.Right); err != nil {
+		return fmt.Errorf("failed to link %s with %s: %w", left, right, err)
+	}
+
+	t.Links = append(t.Links, l)
+
+	return nil
+}
+
+// subnetHost returns the k-th address of the subnet
+func subnetHost(subnet net.IPNet, k int) net.IP {
+	ip := subnet.IP.Mask(subnet.Mask)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	i := new(big.Int).SetBytes(ip)
+	i.Add(i, big.NewInt(int64(k)))
+
+	return bigIntToIP(i, len(ip))
+}
@@ -0,0 +1,66 @@
+package gont_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+)
+
+// TestBuildTopology checks the connectivity between all
+// hosts of the generated topologies
+func TestBuildTopology(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		build func(*g.Network, int, ...g.Option) (*g.Topology, error)
+		num   int
+		links int
+	}{
+		{"mesh", (*g.Network).BuildMesh, 5, 10},
+		{"star", (*g.Network).BuildStar, 4, 4},
+		{"line", (*g.Network).BuildLine, 4, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := g.NewNetwork(*nname, append(opts,
+				o.SubnetPoolIP("10.0.0.0/24"),
+			)...)
+			if err != nil {
+				t.Fatalf("Failed to create network: %s", err)
+			}
+			defer n.Close()
+
+			topo, err := tc.build(n, tc.num)
+			if err != nil {
+				t.Fatalf("Failed to build topology: %s", err)
+			}
+
+			if len(topo.Hosts) != tc.num {
+				t.Fatalf("Expected %d hosts, got %d", tc.num, len(topo.Hosts))
+			}
+
+			if len(topo.Links) != tc.links {
+				t.Fatalf("Expected %d links, got %d", tc.links, len(topo.Links))
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			results, err := n.ConnectivityMatrix(ctx, topo.Hosts...)
+			if err != nil {
+				t.Fatalf("Failed to check connectivity: %s", err)
+			}
+
+			if len(results) != tc.num*(tc.num-1) {
+				t.Fatalf("Expected %d pairs, got %d", tc.num*(tc.num-1), len(results))
+			}
+
+			for p, s := range results {
+				if s.Err != nil || s.PacketLoss != 0 {
+					t.Errorf("Pair %s -> %s has no connectivity: %v", p.Source, p.Destination, s.Err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"go.uber.org/zap"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+// AssertNoTraffic observes the interface iface for the duration d and returns
+// an error if any packet sent or received by the interface matches the filter.
+//
+// The filter is a classic BPF program which is attached to a packet socket.
+// Packets are accepted by a non-zero return value. Programs generated by
+// "tcpdump -dd" can be passed as a slice of bpf.RawInstruction.
+// An empty filter matches all packets.
+func (n *Network) AssertNoTraffic(iface *Interface, filter []bpf.Instruction, d time.Duration) error {
+	node := iface.Node.base()
+	if node.network != n {
+		return errors.New("interface must belong to the network")
+	}
+
+	link, err := iface.currentLink()
+	if err != nil {
+		return err
+	}
+
+	if len(filter) == 0 {
+		filter = []bpf.Instruction{
+			bpf.RetConstant{Val: 0xffff},
+		}
+	}
+
+	prog, err := bpf.Assemble(filter)
+	if err != nil {
+		return fmt.Errorf("failed to assemble filter: %w", err)
+	}
+
+	// The socket does not receive any packets until it is bound.
+	// This ensures that all received packets have passed the filter.
+	var fd int
+	if err := node.RunFunc(func() (err error) {
+		fd, err = unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, 0)
+		return
+	}); err != nil {
+		return fmt.Errorf("failed to open packet socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	if err := unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &unix.SockFprog{
+		Len:    uint16(len(prog)),
+		Filter: (*unix.SockFilter)(unsafe.Pointer(&prog[0])),
+	}); err != nil {
+		return fmt.Errorf("failed to attach filter: %w", err)
+	}
+
+	// Wake up regularly to check the deadline
+	tv := unix.NsecToTimeval(int64(10 * time.Millisecond))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		return fmt.Errorf("failed to set timeout: %w", err)
+	}
+
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{
+		Protocol: htons(unix.ETH_P_ALL),
+		Ifindex:  link.Attrs().Index,
+	}); err != nil {
+		return fmt.Errorf("failed to bind packet socket: %w", err)
+	}
+
+	n.logger.Debug("Observing interface for unexpected traffic",
+		zap.Any("intf", iface),
+		zap.Duration("duration", d))
+
+	var first []string
+	var count int
+
+	frame := make([]byte, 1<<16)
+	for deadline := time.Now().Add(d); time.Now().Before(deadline); {
+		l, _, err := unix.Recvfrom(fd, frame, 0)
+		if err != nil {
+			continue
+		}
+
+		if count++; first == nil {
+			p := gopacket.NewPacket(frame[:l], layers.LayerTypeEthernet, gopacket.Default)
+			for _, lyr := range p.Layers() {
+				first = append(first, lyr.LayerType().String())
+			}
+		}
+	}
+
+	if count > 0 {
+		return fmt.Errorf("observed %d unexpected packets on interface %s, first: %s", count, iface, strings.Join(first, "/"))
+	}
+
+	return nil
+}
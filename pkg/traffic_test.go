@@ -0,0 +1,113 @@
+package gont_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	g "github.com/stv0g/gont/pkg"
+	o "github.com/stv0g/gont/pkg/options"
+	"golang.org/x/net/bpf"
+)
+
+// udpPortFilter matches IPv4 UDP packets destined to the port
+func udpPortFilter(port uint32) []bpf.Instruction {
+	return []bpf.Instruction{
+		bpf.LoadAbsolute{Off: 12, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpNotEqual, Val: 0x0800, SkipTrue: 6},
+		bpf.LoadAbsolute{Off: 23, Size: 1},
+		bpf.JumpIf{Cond: bpf.JumpNotEqual, Val: 17, SkipTrue: 4},
+		bpf.LoadMemShift{Off: 14},
+		bpf.LoadIndirect{Off: 16, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: port, SkipFalse: 1},
+		bpf.RetConstant{Val: 0xffff},
+		bpf.RetConstant{Val: 0},
+	}
+}
+
+func TestAssertNoTraffic(t *testing.T) {
+	var (
+		err    error
+		n      *g.Network
+		h1, h2 *g.Host
+	)
+
+	if n, err = g.NewNetwork(*nname, opts...); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer n.Close()
+
+	if h1, err = n.AddHost("h1"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if h2, err = n.AddHost("h2"); err != nil {
+		t.Fatalf("Failed to create host: %s", err)
+	}
+
+	if err := n.AddLink(
+		o.Interface("veth0", h1,
+			o.AddressIPv4(10, 0, 0, 1, 24)),
+		o.Interface("veth0", h2,
+			o.AddressIPv4(10, 0, 0, 2, 24)),
+	); err != nil {
+		t.Fatalf("Failed to connect hosts: %s", err)
+	}
+
+	// generate sends UDP datagrams from h1 to the port of h2 until stop is closed
+	generate := func(port string) (stop chan struct{}) {
+		stop = make(chan struct{})
+
+		c, err := h1.Dial("udp", net.JoinHostPort("10.0.0.2", port))
+		if err != nil {
+			t.Fatalf("Failed to dial: %s", err)
+		}
+
+		go func() {
+			defer c.Close()
+
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(10 * time.Millisecond):
+					c.Write([]byte("traffic"))
+				}
+			}
+		}()
+
+		return stop
+	}
+
+	iface := h2.Interface("veth0")
+
+	stop := generate("8888")
+	err = n.AssertNoTraffic(iface, udpPortFilter(9999), 300*time.Millisecond)
+	close(stop)
+
+	if err != nil {
+		t.Errorf("Unrelated traffic has been matched: %s", err)
+	}
+
+	stop = generate("9999")
+	err = n.AssertNoTraffic(iface, udpPortFilter(9999), 300*time.Millisecond)
+	close(stop)
+
+	if err == nil {
+		t.Error("Matching traffic has not been detected")
+	} else if !strings.Contains(err.Error(), "UDP") {
+		t.Errorf("Error does not describe the packet: %s", err)
+	}
+
+	// Without a filter, all packets are matched
+	for _, filter := range [][]bpf.Instruction{nil, {}} {
+		stop = generate("8888")
+		err = n.AssertNoTraffic(iface, filter, 100*time.Millisecond)
+		close(stop)
+
+		if err == nil {
+			t.Error("Traffic has not been detected")
+		}
+	}
+}